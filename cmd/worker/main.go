@@ -0,0 +1,25 @@
+package main
+
+import (
+	"context"
+	"log"
+	"os"
+	"os/signal"
+	"syscall"
+
+	"github.com/Vinayak9769/loadagg/internal/worker"
+)
+
+func main() {
+	cfg, err := worker.LoadConfig()
+	if err != nil {
+		log.Fatalf("Error: %v", err)
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	if err := worker.Run(ctx, cfg); err != nil {
+		log.Fatalf("Load test worker failed: %v", err)
+	}
+}
@@ -1,5 +1,5 @@
 import React, { useState } from 'react';
-import { X, Plus, RefreshCw } from 'lucide-react';
+import { X, Plus, RefreshCw, Upload } from 'lucide-react';
 import { getAuthToken, removeAuthToken } from '../utils/auth';
 
 interface NewTestModalProps {
@@ -21,6 +21,28 @@ interface TestFormData {
   timeout: number;
 }
 
+// ImportResult is a test drafted by one of the /api/v1/imports endpoints
+interface ImportResult {
+  name: string;
+  target_url: string;
+  config: {
+    duration: number;
+    requests_per_sec: number;
+    worker_count: number;
+    http_method: string;
+    headers?: { [name: string]: string };
+    body?: string;
+    steps?: object[];
+    endpoints?: object[];
+    feeder?: object;
+    protocol?: string;
+    tls?: object;
+    network?: object;
+  };
+  skipped?: { item: string; reason: string }[];
+  warnings?: string[];
+}
+
 const NewTestModal: React.FC<NewTestModalProps> = ({ isOpen, onClose, onTestCreated, onLogout }) => {
   const [formData, setFormData] = useState<TestFormData>({
     name: '',
@@ -36,6 +58,171 @@ const NewTestModal: React.FC<NewTestModalProps> = ({ isOpen, onClose, onTestCrea
 
   const [isSubmitting, setIsSubmitting] = useState(false);
   const [error, setError] = useState('');
+  const [steps, setSteps] = useState('');
+  const [harFile, setHarFile] = useState<File | null>(null);
+  const [harHosts, setHarHosts] = useState('');
+  const [harIncludeStatic, setHarIncludeStatic] = useState(false);
+  const [endpoints, setEndpoints] = useState('');
+  const [feeder, setFeeder] = useState('');
+  const [executor, setExecutor] = useState('arrival_rate');
+  const [pacingMS, setPacingMS] = useState(0);
+  const [thinkTime, setThinkTime] = useState('');
+  const [cookies, setCookies] = useState('');
+  const [specFile, setSpecFile] = useState<File | null>(null);
+  const [specBaseURL, setSpecBaseURL] = useState('');
+  const [curlCommand, setCurlCommand] = useState('');
+  // imported options the form has no fields for, sent along unchanged
+  const [importedOptions, setImportedOptions] = useState<Pick<ImportResult['config'], 'protocol'>>({});
+  const [tlsOptions, setTlsOptions] = useState('');
+  const [network, setNetwork] = useState('');
+  const [isImporting, setIsImporting] = useState(false);
+  const [skipped, setSkipped] = useState<{ item: string; reason: string }[]>([]);
+  const [warnings, setWarnings] = useState<string[]>([]);
+
+  // applyImport loads a drafted test into the form for review
+  const applyImport = (result: ImportResult) => {
+    setFormData(prev => ({
+      ...prev,
+      name: result.name,
+      target_url: result.target_url,
+      duration: result.config.duration,
+      requests_per_sec: result.config.requests_per_sec,
+      worker_count: result.config.worker_count,
+      http_method: result.config.http_method,
+      headers: JSON.stringify(result.config.headers || {}, null, 2),
+      body: result.config.body || ''
+    }));
+    setSteps(result.config.steps?.length ? JSON.stringify(result.config.steps, null, 2) : '');
+    setEndpoints(result.config.endpoints?.length ? JSON.stringify(result.config.endpoints, null, 2) : '');
+    setFeeder(result.config.feeder ? JSON.stringify(result.config.feeder, null, 2) : '');
+    setImportedOptions({ protocol: result.config.protocol });
+    setTlsOptions(result.config.tls ? JSON.stringify(result.config.tls, null, 2) : '');
+    setNetwork(result.config.network ? JSON.stringify(result.config.network, null, 2) : '');
+    setSkipped(result.skipped || []);
+    setWarnings(result.warnings || []);
+  };
+
+  const handleImportHAR = async () => {
+    if (!harFile) return;
+    setIsImporting(true);
+    setError('');
+
+    try {
+      const token = getAuthToken();
+      if (!token) {
+        onLogout();
+        return;
+      }
+
+      const params = new URLSearchParams();
+      if (harHosts.trim()) params.set('host', harHosts.trim());
+      if (harIncludeStatic) params.set('static', 'include');
+
+      const response = await fetch(`http://localhost:8080/api/v1/imports/har?${params}`, {
+        method: 'POST',
+        headers: {
+          'Authorization': `Bearer ${token}`,
+          'Content-Type': 'application/json',
+        },
+        body: await harFile.text()
+      });
+
+      if (!response.ok) {
+        if (response.status === 401) {
+          removeAuthToken();
+          onLogout();
+          return;
+        }
+        throw new Error((await response.text()) || `HTTP ${response.status}: Failed to import HAR file`);
+      }
+
+      applyImport(await response.json());
+    } catch (err) {
+      setError(err instanceof Error ? err.message : 'Failed to import HAR file');
+    } finally {
+      setIsImporting(false);
+    }
+  };
+
+  const handleImportOpenAPI = async () => {
+    if (!specFile) return;
+    setIsImporting(true);
+    setError('');
+
+    try {
+      const token = getAuthToken();
+      if (!token) {
+        onLogout();
+        return;
+      }
+
+      const response = await fetch('http://localhost:8080/api/v1/imports/openapi', {
+        method: 'POST',
+        headers: {
+          'Authorization': `Bearer ${token}`,
+          'Content-Type': 'application/json',
+        },
+        body: JSON.stringify({
+          spec: await specFile.text(),
+          base_url: specBaseURL.trim() || undefined,
+          feeder: feeder.trim() ? JSON.parse(feeder) : undefined
+        })
+      });
+
+      if (!response.ok) {
+        if (response.status === 401) {
+          removeAuthToken();
+          onLogout();
+          return;
+        }
+        throw new Error((await response.text()) || `HTTP ${response.status}: Failed to import OpenAPI spec`);
+      }
+
+      applyImport(await response.json());
+    } catch (err) {
+      setError(err instanceof Error ? err.message : 'Failed to import OpenAPI spec');
+    } finally {
+      setIsImporting(false);
+    }
+  };
+
+  const handleImportCurl = async () => {
+    if (!curlCommand.trim()) return;
+    setIsImporting(true);
+    setError('');
+
+    try {
+      const token = getAuthToken();
+      if (!token) {
+        onLogout();
+        return;
+      }
+
+      const response = await fetch('http://localhost:8080/api/v1/imports/curl', {
+        method: 'POST',
+        headers: {
+          'Authorization': `Bearer ${token}`,
+          'Content-Type': 'application/json',
+        },
+        body: JSON.stringify({ command: curlCommand })
+      });
+
+      if (!response.ok) {
+        if (response.status === 401) {
+          removeAuthToken();
+          onLogout();
+          return;
+        }
+        throw new Error((await response.text()) || `HTTP ${response.status}: Failed to import curl command`);
+      }
+
+      applyImport(await response.json());
+    } catch (err) {
+      setError(err instanceof Error ? err.message : 'Failed to import curl command');
+    } finally {
+      setIsImporting(false);
+    }
+  };
 
   const handleInputChange = (e: React.ChangeEvent<HTMLInputElement | HTMLSelectElement | HTMLTextAreaElement>) => {
     const { name, value } = e.target;
@@ -69,6 +256,69 @@ const NewTestModal: React.FC<NewTestModalProps> = ({ isOpen, onClose, onTestCrea
         }
       }
 
+      let parsedSteps;
+      if (steps.trim()) {
+        try {
+          parsedSteps = JSON.parse(steps);
+        } catch (err) {
+          throw new Error('Invalid JSON format in steps');
+        }
+      }
+
+      let parsedEndpoints;
+      if (endpoints.trim()) {
+        try {
+          parsedEndpoints = JSON.parse(endpoints);
+        } catch (err) {
+          throw new Error('Invalid JSON format in endpoints');
+        }
+      }
+
+      let parsedFeeder;
+      if (feeder.trim()) {
+        try {
+          parsedFeeder = JSON.parse(feeder);
+        } catch (err) {
+          throw new Error('Invalid JSON format in feeder');
+        }
+      }
+
+      let parsedThinkTime;
+      if (thinkTime.trim()) {
+        try {
+          parsedThinkTime = JSON.parse(thinkTime);
+        } catch (err) {
+          throw new Error('Invalid JSON format in think time');
+        }
+      }
+
+      let parsedTLS;
+      if (tlsOptions.trim()) {
+        try {
+          parsedTLS = JSON.parse(tlsOptions);
+        } catch (err) {
+          throw new Error('Invalid JSON format in TLS options');
+        }
+      }
+
+      let parsedNetwork;
+      if (network.trim()) {
+        try {
+          parsedNetwork = JSON.parse(network);
+        } catch (err) {
+          throw new Error('Invalid JSON format in network options');
+        }
+      }
+
+      let parsedCookies;
+      if (cookies.trim()) {
+        try {
+          parsedCookies = JSON.parse(cookies);
+        } catch (err) {
+          throw new Error('Invalid JSON format in cookies');
+        }
+      }
+
       const payload = {
         name: formData.name,
         target_url: formData.target_url,
@@ -79,7 +329,17 @@ const NewTestModal: React.FC<NewTestModalProps> = ({ isOpen, onClose, onTestCrea
           http_method: formData.http_method,
           headers: parsedHeaders,
           body: formData.body,
-          timeout: formData.timeout
+          timeout: formData.timeout,
+          steps: parsedSteps,
+          endpoints: parsedEndpoints,
+          feeder: parsedFeeder,
+          executor,
+          pacing_ms: pacingMS,
+          think_time: parsedThinkTime,
+          cookies: parsedCookies,
+          tls: parsedTLS,
+          network: parsedNetwork,
+          ...importedOptions
         }
       };
 
@@ -117,6 +377,19 @@ const NewTestModal: React.FC<NewTestModalProps> = ({ isOpen, onClose, onTestCrea
         body: '',
         timeout: 30
       });
+      setSteps('');
+      setEndpoints('');
+      setFeeder('');
+      setExecutor('arrival_rate');
+      setPacingMS(0);
+      setThinkTime('');
+      setCookies('');
+      setCurlCommand('');
+      setImportedOptions({});
+      setTlsOptions('');
+      setNetwork('');
+      setSkipped([]);
+      setWarnings([]);
 
     } catch (err) {
       setError(err instanceof Error ? err.message : 'Failed to create test');
@@ -149,6 +422,113 @@ const NewTestModal: React.FC<NewTestModalProps> = ({ isOpen, onClose, onTestCrea
             </div>
           )}
 
+          {/* Import */}
+          <div className="space-y-4">
+            <h3 className="text-lg font-extralight text-white tracking-tight">Import from HAR (optional)</h3>
+            <div className="grid grid-cols-2 gap-4">
+              <input
+                type="file"
+                accept=".har,application/json"
+                onChange={(e) => setHarFile(e.target.files?.[0] || null)}
+                className="w-full text-gray-400 font-extralight text-sm"
+              />
+              <input
+                type="text"
+                value={harHosts}
+                onChange={(e) => setHarHosts(e.target.value)}
+                className="w-full bg-black border border-white/10 text-white font-extralight py-2 px-3 rounded-none focus:outline-none focus:border-white/30"
+                placeholder="Hosts to keep, e.g. api.example.com"
+              />
+            </div>
+            <div className="flex items-center justify-between">
+              <label className="flex items-center space-x-2 text-gray-400 font-light text-sm">
+                <input
+                  type="checkbox"
+                  checked={harIncludeStatic}
+                  onChange={(e) => setHarIncludeStatic(e.target.checked)}
+                />
+                <span>Include static assets</span>
+              </label>
+              <button
+                type="button"
+                onClick={handleImportHAR}
+                disabled={!harFile || isImporting}
+                className="bg-black text-white hover:bg-neutral-800 transition-colors font-extralight py-2 px-4 rounded-none border border-white/10 flex items-center space-x-2 disabled:opacity-50 disabled:cursor-not-allowed"
+              >
+                {isImporting ? <RefreshCw className="h-4 w-4 animate-spin" /> : <Upload className="h-4 w-4" />}
+                <span>Import</span>
+              </button>
+            </div>
+            {skipped.length > 0 && (
+              <details className="text-gray-500 text-xs font-light">
+                <summary>{skipped.length} items skipped</summary>
+                {skipped.map((s, i) => (
+                  <p key={i} className="font-mono break-all">{s.reason}: {s.item}</p>
+                ))}
+              </details>
+            )}
+          </div>
+
+          <div className="space-y-4">
+            <h3 className="text-lg font-extralight text-white tracking-tight">Import from curl (optional)</h3>
+            <textarea
+              value={curlCommand}
+              onChange={(e) => setCurlCommand(e.target.value)}
+              rows={3}
+              className="w-full bg-black border border-white/10 text-white font-extralight py-2 px-3 rounded-none focus:outline-none focus:border-white/30 font-mono text-sm"
+              placeholder="curl -X POST https://api.example.com/cart -H 'Content-Type: application/json' -d '{}'"
+            />
+            <div className="flex justify-end">
+              <button
+                type="button"
+                onClick={handleImportCurl}
+                disabled={!curlCommand.trim() || isImporting}
+                className="bg-black text-white hover:bg-neutral-800 transition-colors font-extralight py-2 px-4 rounded-none border border-white/10 flex items-center space-x-2 disabled:opacity-50 disabled:cursor-not-allowed"
+              >
+                {isImporting ? <RefreshCw className="h-4 w-4 animate-spin" /> : <Upload className="h-4 w-4" />}
+                <span>Import</span>
+              </button>
+            </div>
+          </div>
+
+          <div className="space-y-4">
+            <h3 className="text-lg font-extralight text-white tracking-tight">Import from OpenAPI (optional)</h3>
+            <div className="grid grid-cols-2 gap-4">
+              <input
+                type="file"
+                accept=".json,.yaml,.yml"
+                onChange={(e) => setSpecFile(e.target.files?.[0] || null)}
+                className="w-full text-gray-400 font-extralight text-sm"
+              />
+              <input
+                type="text"
+                value={specBaseURL}
+                onChange={(e) => setSpecBaseURL(e.target.value)}
+                className="w-full bg-black border border-white/10 text-white font-extralight py-2 px-3 rounded-none focus:outline-none focus:border-white/30"
+                placeholder="Base URL, defaults to the spec's first server"
+              />
+            </div>
+            <div className="flex justify-end">
+              <button
+                type="button"
+                onClick={handleImportOpenAPI}
+                disabled={!specFile || isImporting}
+                className="bg-black text-white hover:bg-neutral-800 transition-colors font-extralight py-2 px-4 rounded-none border border-white/10 flex items-center space-x-2 disabled:opacity-50 disabled:cursor-not-allowed"
+              >
+                {isImporting ? <RefreshCw className="h-4 w-4 animate-spin" /> : <Upload className="h-4 w-4" />}
+                <span>Import</span>
+              </button>
+            </div>
+            {warnings.length > 0 && (
+              <details className="text-gray-500 text-xs font-light">
+                <summary>{warnings.length} warnings</summary>
+                {warnings.map((w, i) => (
+                  <p key={i} className="font-mono break-all">{w}</p>
+                ))}
+              </details>
+            )}
+          </div>
+
           {/* Basic Info */}
           <div className="space-y-4">
             <h3 className="text-lg font-extralight text-white tracking-tight">Basic Information</h3>
@@ -199,7 +579,7 @@ const NewTestModal: React.FC<NewTestModalProps> = ({ isOpen, onClose, onTestCrea
               </div>
 
               <div>
-                <label className="block text-gray-400 font-light text-sm mb-2">Maximum Requests per Second</label>
+                <label className="block text-gray-400 font-light text-sm mb-2">Total Requests per Second (split across workers)</label>
                 <input
                   type="number"
                   name="requests_per_sec"
@@ -269,6 +649,7 @@ const NewTestModal: React.FC<NewTestModalProps> = ({ isOpen, onClose, onTestCrea
                 className="w-full bg-black border border-white/10 text-white font-extralight py-2 px-3 rounded-none focus:outline-none focus:border-white/30 font-mono text-sm"
                 placeholder='{"Content-Type": "application/json"}'
               />
+              <p className="text-gray-500 text-xs mt-1 font-light">Reference stored secrets as {'{{secret "name"}}'} in headers and bodies instead of pasting API keys</p>
             </div>
 
             <div>
@@ -283,6 +664,115 @@ const NewTestModal: React.FC<NewTestModalProps> = ({ isOpen, onClose, onTestCrea
               />
               <p className="text-gray-500 text-xs mt-1 font-light">Leave empty for GET requests or when no body is needed</p>
             </div>
+
+            {steps && (
+              <div>
+                <label className="block text-gray-400 font-light text-sm mb-2">Steps (JSON, sent in order on every iteration)</label>
+                <textarea
+                  value={steps}
+                  onChange={(e) => setSteps(e.target.value)}
+                  rows={10}
+                  className="w-full bg-black border border-white/10 text-white font-extralight py-2 px-3 rounded-none focus:outline-none focus:border-white/30 font-mono text-sm"
+                />
+                <p className="text-gray-500 text-xs mt-1 font-light">Each step has a method, url, headers, body and the think_time_ms recorded before it</p>
+              </div>
+            )}
+
+            <div>
+              <label className="block text-gray-400 font-light text-sm mb-2">Endpoints (JSON, optional, one picked by weight on every iteration)</label>
+              <textarea
+                value={endpoints}
+                onChange={(e) => setEndpoints(e.target.value)}
+                rows={endpoints ? 10 : 4}
+                className="w-full bg-black border border-white/10 text-white font-extralight py-2 px-3 rounded-none focus:outline-none focus:border-white/30 font-mono text-sm"
+                placeholder='[{"name": "search", "method": "GET", "url": "https://api.example.com/search", "weight": 70}]'
+              />
+              <p className="text-gray-500 text-xs mt-1 font-light">Each endpoint has a name, method, url, headers, body and weight; {'{{column}}'} takes the value from the feeder. Metrics are reported per name</p>
+            </div>
+
+            <div>
+              <label className="block text-gray-400 font-light text-sm mb-2">Feeder (JSON, optional)</label>
+              <textarea
+                value={feeder}
+                onChange={(e) => setFeeder(e.target.value)}
+                rows={4}
+                className="w-full bg-black border border-white/10 text-white font-extralight py-2 px-3 rounded-none focus:outline-none focus:border-white/30 font-mono text-sm"
+                placeholder='{"columns": ["id"], "rows": [["1"], ["2"]]}'
+              />
+            </div>
+
+            <div className="grid grid-cols-2 gap-4">
+              <div>
+                <label className="block text-gray-400 font-light text-sm mb-2">Executor</label>
+                <select
+                  value={executor}
+                  onChange={(e) => setExecutor(e.target.value)}
+                  className="w-full bg-black border border-white/10 text-white font-extralight py-2 px-3 rounded-none focus:outline-none focus:border-white/30"
+                >
+                  <option value="arrival_rate">Arrival rate</option>
+                  <option value="vus">Virtual users</option>
+                </select>
+              </div>
+
+              <div>
+                <label className="block text-gray-400 font-light text-sm mb-2">Pacing (ms, virtual users)</label>
+                <input
+                  type="number"
+                  value={pacingMS}
+                  onChange={(e) => setPacingMS(parseInt(e.target.value) || 0)}
+                  min="0"
+                  className="w-full bg-black border border-white/10 text-white font-extralight py-2 px-3 rounded-none focus:outline-none focus:border-white/30"
+                />
+              </div>
+            </div>
+
+            <div>
+              <label className="block text-gray-400 font-light text-sm mb-2">Think Time (JSON, optional)</label>
+              <textarea
+                value={thinkTime}
+                onChange={(e) => setThinkTime(e.target.value)}
+                rows={2}
+                className="w-full bg-black border border-white/10 text-white font-extralight py-2 px-3 rounded-none focus:outline-none focus:border-white/30 font-mono text-sm"
+                placeholder='{"distribution": "normal", "ms": 2000, "stddev_ms": 500}'
+              />
+              <p className="text-gray-500 text-xs mt-1 font-light">Paused between steps, replacing recorded think times, and after each iteration of a virtual user. Distributions: fixed (ms), uniform (min_ms, max_ms), normal (ms, stddev_ms), exponential (ms)</p>
+            </div>
+
+            <div>
+              <label className="block text-gray-400 font-light text-sm mb-2">Cookies (JSON, optional)</label>
+              <textarea
+                value={cookies}
+                onChange={(e) => setCookies(e.target.value)}
+                rows={2}
+                className="w-full bg-black border border-white/10 text-white font-extralight py-2 px-3 rounded-none focus:outline-none focus:border-white/30 font-mono text-sm"
+                placeholder='{"reset_per_iteration": true, "initial": [{"name": "session", "value": "{{token}}"}]}'
+              />
+              <p className="text-gray-500 text-xs mt-1 font-light">Every virtual user keeps its own cookie jar; initial cookies may take values from the feeder. Set disabled to send no cookies</p>
+            </div>
+
+            <div>
+              <label className="block text-gray-400 font-light text-sm mb-2">TLS (JSON, optional)</label>
+              <textarea
+                value={tlsOptions}
+                onChange={(e) => setTlsOptions(e.target.value)}
+                rows={2}
+                className="w-full bg-black border border-white/10 text-white font-extralight py-2 px-3 rounded-none focus:outline-none focus:border-white/30 font-mono text-sm"
+                placeholder='{"client_cert_secret": "svc-cert", "client_key_secret": "svc-key", "ca_secret": "internal-ca"}'
+              />
+              <p className="text-gray-500 text-xs mt-1 font-light">Certificates, keys and CA bundles are PEM secrets referenced by name. Also takes server_name, min_version and insecure_skip_verify</p>
+            </div>
+
+            <div>
+              <label className="block text-gray-400 font-light text-sm mb-2">Network (JSON, optional)</label>
+              <textarea
+                value={network}
+                onChange={(e) => setNetwork(e.target.value)}
+                rows={2}
+                className="w-full bg-black border border-white/10 text-white font-extralight py-2 px-3 rounded-none focus:outline-none focus:border-white/30 font-mono text-sm"
+                placeholder='{"hosts": {"api.example.com": "203.0.113.10"}, "proxy": "http://proxy.internal:3128"}'
+              />
+              <p className="text-gray-500 text-xs mt-1 font-light">Hosts pins names to addresses, like curl --resolve; public addresses must be verified in the allowlist unless DNS already returns them. Also takes dns_server (host:port) and dns_cache_ttl in seconds. Proxy credentials may be secret references</p>
+            </div>
           </div>
 
           {/* Actions */}
@@ -8,6 +8,7 @@ interface LoadTestConfig {
   max_concurrency: number;
   worker_count: number;
   http_method: string;
+  target_type?: string;
 }
 
 interface LoadTest {
@@ -31,6 +32,15 @@ interface Worker {
   last_update: string;
 }
 
+interface EndpointMetrics {
+  total_requests: number;
+  failed_requests: number;
+  error_rate: number;
+  avg_response_time: number;
+  requests_per_second: number;
+  latency_corrected?: { p95: number };
+}
+
 interface MetricsSummary {
   total_requests: number;
   successful_requests: number;
@@ -38,13 +48,15 @@ interface MetricsSummary {
   overall_error_rate: number;
   avg_response_time: number;
   requests_per_second: number;
-  status_code_breakdown: {
-    "200": number;
-    "400": number;
-    "500": number;
-    "other": number;
-  };
+  iteration_rate?: number;
+  status_code_breakdown: { [code: string]: number };
+  error_breakdown?: { [category: string]: number };
+  error_samples?: { [category: string]: string[] };
   active_workers: number;
+  concurrent_connections?: number;
+  peak_connections?: number;
+  endpoints?: { [name: string]: EndpointMetrics };
+  target_ips?: { [ip: string]: number };
 }
 
 interface TestMetrics {
@@ -70,6 +82,12 @@ interface MetricsPageProps {
   onLogout: () => void;
 }
 
+// statusClassCount sums the exact status codes that start with the given digit
+const statusClassCount = (breakdown: { [code: string]: number }, digit: string) =>
+  Object.entries(breakdown || {})
+    .filter(([code]) => code.startsWith(digit))
+    .reduce((sum, [, count]) => sum + count, 0);
+
 const MetricsPage: React.FC<MetricsPageProps> = ({ test, onBackToDashboard, onLogout }) => {
   const [metrics, setMetrics] = useState<TestMetrics | null>(null);
   const [isLoadingMetrics, setIsLoadingMetrics] = useState(false);
@@ -80,6 +98,8 @@ const MetricsPage: React.FC<MetricsPageProps> = ({ test, onBackToDashboard, onLo
   const [expandedWorkers, setExpandedWorkers] = useState<Set<string>>(new Set());
   const abortControllerRef = useRef<AbortController | null>(null);
   const logContainerRefs = useRef<{ [workerId: string]: HTMLDivElement | null }>({});
+  // subscribers hold connections open, so their load is a connection count
+  const isSubscriptionTest = test.config.target_type === 'sse' || test.config.target_type === 'long_poll';
 
   const fetchStaticMetrics = async () => {
     setIsLoadingMetrics(true);
@@ -602,10 +622,19 @@ const MetricsPage: React.FC<MetricsPageProps> = ({ test, onBackToDashboard, onLo
 
               <div className="bg-black border border-white/10 p-6 rounded-none">
                 <div className="flex items-center justify-between">
-                  <div>
-                    <p className="text-gray-400 font-light text-sm">Requests/Second</p>
-                    <p className="text-2xl font-extralight text-yellow-400">{metrics.summary.requests_per_second.toFixed(2)}</p>
-                  </div>
+                  {isSubscriptionTest ? (
+                    <div>
+                      <p className="text-gray-400 font-light text-sm">Concurrent Connections</p>
+                      <p className="text-2xl font-extralight text-yellow-400">{metrics.summary.concurrent_connections ?? 0}</p>
+                      <p className="text-gray-500 font-light text-xs">peak {metrics.summary.peak_connections ?? 0}</p>
+                    </div>
+                  ) : (
+                    <div>
+                      <p className="text-gray-400 font-light text-sm">Requests/Second</p>
+                      <p className="text-2xl font-extralight text-yellow-400">{metrics.summary.requests_per_second.toFixed(2)}</p>
+                      <p className="text-gray-500 font-light text-xs">{(metrics.summary.iteration_rate ?? 0).toFixed(2)} iterations/s</p>
+                    </div>
+                  )}
                   <Server className="h-8 w-8 text-yellow-400" />
                 </div>
               </div>
@@ -616,24 +645,94 @@ const MetricsPage: React.FC<MetricsPageProps> = ({ test, onBackToDashboard, onLo
               <h3 className="text-xl font-extralight text-white mb-4 tracking-tight">Status Code Breakdown</h3>
               <div className="grid grid-cols-2 md:grid-cols-4 gap-4">
                 <div className="bg-black border border-white/10 p-4 rounded-none text-center">
-                  <p className="text-green-400 text-2xl font-extralight">{metrics.summary.status_code_breakdown["200"]}</p>
-                  <p className="text-gray-400 font-light text-sm">200 OK</p>
+                  <p className="text-green-400 text-2xl font-extralight">{statusClassCount(metrics.summary.status_code_breakdown, "2")}</p>
+                  <p className="text-gray-400 font-light text-sm">2xx</p>
                 </div>
                 <div className="bg-black border border-white/10 p-4 rounded-none text-center">
-                  <p className="text-yellow-400 text-2xl font-extralight">{metrics.summary.status_code_breakdown["400"]}</p>
-                  <p className="text-gray-400 font-light text-sm">400 Error</p>
+                  <p className="text-yellow-400 text-2xl font-extralight">{statusClassCount(metrics.summary.status_code_breakdown, "4")}</p>
+                  <p className="text-gray-400 font-light text-sm">4xx</p>
                 </div>
                 <div className="bg-black border border-white/10 p-4 rounded-none text-center">
-                  <p className="text-red-400 text-2xl font-extralight">{metrics.summary.status_code_breakdown["500"]}</p>
-                  <p className="text-gray-400 font-light text-sm">500 Error</p>
+                  <p className="text-red-400 text-2xl font-extralight">{statusClassCount(metrics.summary.status_code_breakdown, "5")}</p>
+                  <p className="text-gray-400 font-light text-sm">5xx</p>
                 </div>
                 <div className="bg-black border border-white/10 p-4 rounded-none text-center">
-                  <p className="text-gray-400 text-2xl font-extralight">{metrics.summary.status_code_breakdown["other"]}</p>
-                  <p className="text-gray-400 font-light text-sm">Other</p>
+                  <p className="text-gray-400 text-2xl font-extralight">{Object.values(metrics.summary.error_breakdown || {}).reduce((sum, count) => sum + count, 0)}</p>
+                  <p className="text-gray-400 font-light text-sm">No Response</p>
                 </div>
               </div>
             </div>
 
+            {/* Target Addresses */}
+            {metrics.summary.target_ips && Object.keys(metrics.summary.target_ips).length > 0 && (
+              <div className="mb-8">
+                <h3 className="text-xl font-extralight text-white mb-4 tracking-tight">Target Addresses</h3>
+                <div className="grid grid-cols-2 md:grid-cols-4 gap-4">
+                  {Object.entries(metrics.summary.target_ips).sort(([, a], [, b]) => b - a).map(([ip, count]) => (
+                    <div key={ip} className="bg-black border border-white/10 p-4 rounded-none text-center">
+                      <p className="text-white text-2xl font-extralight">{count}</p>
+                      <p className="text-gray-400 font-mono text-xs break-all">{ip}</p>
+                    </div>
+                  ))}
+                </div>
+              </div>
+            )}
+
+            {/* Endpoint Breakdown */}
+            {metrics.summary.endpoints && Object.keys(metrics.summary.endpoints).length > 0 && (
+              <div className="mb-8">
+                <h3 className="text-xl font-extralight text-white mb-4 tracking-tight">Endpoints</h3>
+                <div className="bg-black border border-white/10 rounded-none overflow-x-auto">
+                  <table className="w-full text-sm font-light">
+                    <thead>
+                      <tr className="text-gray-400 text-left border-b border-white/10">
+                        <th className="p-3 font-light">Endpoint</th>
+                        <th className="p-3 font-light text-right">Requests</th>
+                        <th className="p-3 font-light text-right">Share</th>
+                        <th className="p-3 font-light text-right">RPS</th>
+                        <th className="p-3 font-light text-right">Error Rate</th>
+                        <th className="p-3 font-light text-right">Avg</th>
+                        <th className="p-3 font-light text-right">p95</th>
+                      </tr>
+                    </thead>
+                    <tbody>
+                      {Object.entries(metrics.summary.endpoints).map(([name, endpoint]) => (
+                        <tr key={name} className="text-white border-b border-white/5">
+                          <td className="p-3 font-mono text-xs break-all">{name}</td>
+                          <td className="p-3 text-right">{endpoint.total_requests}</td>
+                          <td className="p-3 text-right">{metrics.summary.total_requests > 0 ? ((endpoint.total_requests / metrics.summary.total_requests) * 100).toFixed(1) : '0.0'}%</td>
+                          <td className="p-3 text-right">{endpoint.requests_per_second.toFixed(1)}</td>
+                          <td className="p-3 text-right">{endpoint.error_rate.toFixed(2)}%</td>
+                          <td className="p-3 text-right">{(endpoint.avg_response_time * 1000).toFixed(0)}ms</td>
+                          <td className="p-3 text-right">{((endpoint.latency_corrected?.p95 || 0) * 1000).toFixed(0)}ms</td>
+                        </tr>
+                      ))}
+                    </tbody>
+                  </table>
+                </div>
+              </div>
+            )}
+
+            {/* Error Breakdown */}
+            {metrics.summary.error_breakdown && Object.keys(metrics.summary.error_breakdown).length > 0 && (
+              <div className="mb-8">
+                <h3 className="text-xl font-extralight text-white mb-4 tracking-tight">Error Breakdown</h3>
+                <div className="space-y-2">
+                  {Object.entries(metrics.summary.error_breakdown).map(([category, count]) => (
+                    <div key={category} className="bg-black border border-white/10 p-4 rounded-none">
+                      <div className="flex items-center justify-between">
+                        <p className="text-white font-light">{category}</p>
+                        <p className="text-red-400 font-extralight">{count}</p>
+                      </div>
+                      {(metrics.summary.error_samples?.[category] || []).map((sample, i) => (
+                        <p key={i} className="text-gray-500 font-mono text-xs mt-1 break-all">{sample}</p>
+                      ))}
+                    </div>
+                  ))}
+                </div>
+              </div>
+            )}
+
             {/* Worker Details with Logs */}
             <div>
               <h3 className="text-xl font-extralight text-white mb-4 tracking-tight">Worker Performance & Logs</h3>
@@ -48,12 +48,50 @@ func ValidateJWT(tokenString string) (string, error) {
 		return "", fmt.Errorf("error parsing token: %w", err)
 	}
 	if claims, ok := token.Claims.(jwt.MapClaims); ok && token.Valid {
-		userID := claims["ID"].(string)
-		return userID, nil
+		if userID, ok := claims["ID"].(string); ok {
+			return userID, nil
+		}
 	}
 	return "", fmt.Errorf("invalid token")
 }
 
+// GenerateWorkerToken issues the token worker pods use to talk back to the API.
+// It is scoped to a single test and is not accepted on user endpoints.
+func GenerateWorkerToken(testID string, ttl time.Duration) (string, error) {
+	claims := jwt.MapClaims{
+		"test_id": testID,
+		"role":    "worker",
+		"exp":     time.Now().Add(ttl).Unix(),
+		"iat":     time.Now().Unix(),
+	}
+
+	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
+	signedToken, err := token.SignedString(secretKey)
+	if err != nil {
+		return "", fmt.Errorf("error signing worker token: %w", err)
+	}
+
+	return signedToken, nil
+}
+
+func ValidateWorkerToken(tokenString string) (string, error) {
+	token, err := jwt.Parse(tokenString, func(token *jwt.Token) (interface{}, error) {
+		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
+			return nil, fmt.Errorf("unexpected signing method: %v", token.Header["alg"])
+		}
+		return secretKey, nil
+	})
+	if err != nil {
+		return "", fmt.Errorf("error parsing token: %w", err)
+	}
+	if claims, ok := token.Claims.(jwt.MapClaims); ok && token.Valid && claims["role"] == "worker" {
+		if testID, ok := claims["test_id"].(string); ok {
+			return testID, nil
+		}
+	}
+	return "", fmt.Errorf("invalid worker token")
+}
+
 func HashPassword(password string) (string, error) {
 	bytes, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
 	return string(bytes), err
@@ -35,9 +35,34 @@ func JWTMiddleware(next http.Handler) http.Handler {
 		claims, ok := token.Claims.(jwt.MapClaims)
 		if !ok || !token.Valid {
 			http.Error(w, http.StatusText(http.StatusUnauthorized), http.StatusUnauthorized)
+			return
+		}
+		// worker tokens carry no user ID
+		if _, ok := claims["ID"].(string); !ok {
+			http.Error(w, http.StatusText(http.StatusUnauthorized), http.StatusUnauthorized)
+			return
 		}
 		ctx := context.WithValue(r.Context(), "claims", claims)
 		fmt.Println("claims:", claims)
 		next.ServeHTTP(w, r.WithContext(ctx))
 	})
 }
+
+// WorkerMiddleware authenticates worker pods by their test-scoped token and
+// stores the test ID in the request context under "worker_test_id".
+func WorkerMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		parts := strings.SplitN(r.Header.Get("Authorization"), " ", 2)
+		if !(len(parts) == 2 && parts[0] == "Bearer") {
+			http.Error(w, http.StatusText(http.StatusUnauthorized), http.StatusUnauthorized)
+			return
+		}
+		testID, err := ValidateWorkerToken(parts[1])
+		if err != nil {
+			http.Error(w, http.StatusText(http.StatusUnauthorized), http.StatusUnauthorized)
+			return
+		}
+		ctx := context.WithValue(r.Context(), "worker_test_id", testID)
+		next.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
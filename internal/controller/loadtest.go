@@ -5,38 +5,63 @@ import (
 	"encoding/json"
 	"fmt"
 	"log"
+	"strings"
 	"time"
 
+	"github.com/Vinayak9769/loadagg/internal/auth"
 	"github.com/Vinayak9769/loadagg/pkg/models"
 	batchv1 "k8s.io/api/batch/v1"
 	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
 	"k8s.io/client-go/kubernetes"
 	"k8s.io/utils/ptr"
 )
 
+// DefaultStartTimeout is how long, in seconds, the start barrier waits for
+// all workers to register when the test config does not set start_timeout.
+const DefaultStartTimeout = 60
+
 type LoadTestController struct {
 	kubeClient kubernetes.Interface
 	namespace  string
+	apiURL     string
 }
 
-func NewLoadTestController(kubeClient kubernetes.Interface, namespace string) *LoadTestController {
+// apiURL is the address worker pods use to reach the API for control updates.
+func NewLoadTestController(kubeClient kubernetes.Interface, namespace, apiURL string) *LoadTestController {
 	if kubeClient == nil {
 		log.Println("Warning: LoadTestController initialized with nil Kubernetes client")
 	}
 	return &LoadTestController{
 		kubeClient: kubeClient,
 		namespace:  namespace,
+		apiURL:     apiURL,
 	}
 }
 
-func (c *LoadTestController) StartLoadTest(ctx context.Context, test *models.LoadTest) error {
+// StartLoadTest creates the Job running test. secretValues holds the secrets
+// the test references by name; they are mounted into the worker pods.
+// allowedNetworks are the private CIDR ranges the workers may connect to.
+func (c *LoadTestController) StartLoadTest(ctx context.Context, test *models.LoadTest, secretValues map[string][]byte, allowedNetworks []string) error {
+	workerToken, err := auth.GenerateWorkerToken(test.ID, time.Duration(test.Config.Duration)*time.Second+24*time.Hour)
+	if err != nil {
+		return err
+	}
+
 	env := []corev1.EnvVar{
 		{Name: "TEST_ID", Value: test.ID},
+		{Name: "API_URL", Value: c.apiURL},
+		{Name: "WORKER_TOKEN", Value: workerToken},
 		{Name: "TARGET_URL", Value: test.TargetURL},
 		{Name: "DURATION_SECONDS", Value: fmt.Sprintf("%d", test.Config.Duration)},
 		{Name: "REQUESTS_PER_SEC", Value: fmt.Sprintf("%d", test.Config.RequestsPerSec)},
+		{Name: "WORKER_COUNT", Value: fmt.Sprintf("%d", test.Config.WorkerCount)},
+		{Name: "WORKER_RATES", Value: joinRates(SplitRate(test.Config.RequestsPerSec, test.Config.WorkerCount))},
+		{Name: "START_TIMEOUT_SECONDS", Value: fmt.Sprintf("%d", startTimeout(test.Config))},
+		{Name: "WORKER_CONCURRENCY", Value: joinRates(SplitRate(test.Config.MaxConcurrency, test.Config.WorkerCount))},
 		{Name: "HTTP_METHOD", Value: test.Config.HTTPMethod},
+		{Name: "ALLOWED_NETWORKS", Value: strings.Join(allowedNetworks, ",")},
 	}
 
 	if len(test.Config.Headers) > 0 {
@@ -49,6 +74,100 @@ func (c *LoadTestController) StartLoadTest(ctx context.Context, test *models.Loa
 		}
 	}
 
+	if len(test.Config.Steps) > 0 {
+		stepsJSON, err := json.Marshal(test.Config.Steps)
+		if err == nil {
+			env = append(env, corev1.EnvVar{
+				Name:  "HTTP_STEPS",
+				Value: string(stepsJSON),
+			})
+		}
+	}
+
+	if len(test.Config.Endpoints) > 0 {
+		endpointsJSON, err := json.Marshal(test.Config.Endpoints)
+		if err == nil {
+			env = append(env, corev1.EnvVar{
+				Name:  "HTTP_ENDPOINTS",
+				Value: string(endpointsJSON),
+			})
+		}
+	}
+
+	if test.Config.Feeder != nil {
+		feederJSON, err := json.Marshal(test.Config.Feeder)
+		if err == nil {
+			env = append(env, corev1.EnvVar{
+				Name:  "FEEDER",
+				Value: string(feederJSON),
+			})
+		}
+	}
+
+	if test.Config.Cookies != nil {
+		cookiesJSON, err := json.Marshal(test.Config.Cookies)
+		if err == nil {
+			env = append(env, corev1.EnvVar{
+				Name:  "COOKIES_CONFIG",
+				Value: string(cookiesJSON),
+			})
+		}
+	}
+
+	if test.Config.Executor != "" {
+		env = append(env, corev1.EnvVar{
+			Name:  "EXECUTOR",
+			Value: test.Config.Executor,
+		})
+	}
+
+	if test.Config.ThinkTime != nil {
+		thinkTimeJSON, err := json.Marshal(test.Config.ThinkTime)
+		if err == nil {
+			env = append(env, corev1.EnvVar{
+				Name:  "THINK_TIME",
+				Value: string(thinkTimeJSON),
+			})
+		}
+	}
+
+	if test.Config.PacingMS > 0 {
+		env = append(env, corev1.EnvVar{
+			Name:  "PACING_MS",
+			Value: fmt.Sprintf("%d", test.Config.PacingMS),
+		})
+	}
+
+	if test.Config.Replay != nil {
+		replayJSON, err := json.Marshal(test.Config.Replay)
+		if err == nil {
+			env = append(env, corev1.EnvVar{
+				Name:  "REPLAY_CONFIG",
+				Value: string(replayJSON),
+			})
+		}
+	}
+
+	if test.Config.TLS != nil {
+		tlsJSON, err := json.Marshal(test.Config.TLS)
+		if err == nil {
+			env = append(env, corev1.EnvVar{
+				Name:  "TLS_CONFIG",
+				Value: string(tlsJSON),
+			})
+		}
+	}
+
+	if test.Config.Network != nil {
+		networkJSON, err := json.Marshal(test.Config.Network)
+		if err == nil {
+			env = append(env, corev1.EnvVar{
+				Name:  "NETWORK_CONFIG",
+				Value: string(networkJSON),
+			})
+		}
+	}
+
 	if test.Config.Body != "" {
 		env = append(env, corev1.EnvVar{
 			Name:  "HTTP_BODY",
@@ -56,14 +175,88 @@ func (c *LoadTestController) StartLoadTest(ctx context.Context, test *models.Loa
 		})
 	}
 
+	if test.Config.TargetType != "" {
+		env = append(env, corev1.EnvVar{
+			Name:  "TARGET_TYPE",
+			Value: test.Config.TargetType,
+		})
+	}
+
+	if test.Config.GRPC != nil {
+		grpcJSON, err := json.Marshal(test.Config.GRPC)
+		if err == nil {
+			env = append(env, corev1.EnvVar{
+				Name:  "GRPC_CONFIG",
+				Value: string(grpcJSON),
+			})
+		}
+	}
+
+	if test.Config.WebSocket != nil {
+		wsJSON, err := json.Marshal(test.Config.WebSocket)
+		if err == nil {
+			env = append(env, corev1.EnvVar{
+				Name:  "WEBSOCKET_CONFIG",
+				Value: string(wsJSON),
+			})
+		}
+	}
+
+	if test.Config.Socket != nil {
+		socketJSON, err := json.Marshal(test.Config.Socket)
+		if err == nil {
+			env = append(env, corev1.EnvVar{
+				Name:  "SOCKET_CONFIG",
+				Value: string(socketJSON),
+			})
+		}
+	}
+
+	if test.Config.DNS != nil {
+		dnsJSON, err := json.Marshal(test.Config.DNS)
+		if err == nil {
+			env = append(env, corev1.EnvVar{
+				Name:  "DNS_CONFIG",
+				Value: string(dnsJSON),
+			})
+		}
+	}
+
+	if test.Config.Stream != nil {
+		streamJSON, err := json.Marshal(test.Config.Stream)
+		if err == nil {
+			env = append(env, corev1.EnvVar{
+				Name:  "STREAM_CONFIG",
+				Value: string(streamJSON),
+			})
+		}
+	}
+
+	if test.Config.Protocol != "" {
+		env = append(env, corev1.EnvVar{
+			Name:  "HTTP_PROTOCOL",
+			Value: test.Config.Protocol,
+		})
+	}
+
+	if test.Config.MaxResponseBytes > 0 {
+		env = append(env, corev1.EnvVar{
+			Name:  "MAX_RESPONSE_BYTES",
+			Value: fmt.Sprintf("%d", test.Config.MaxResponseBytes),
+		})
+	}
+
 	job := &batchv1.Job{
 		ObjectMeta: metav1.ObjectMeta{
 			Name:      fmt.Sprintf("loadtest-%s", test.ID),
 			Namespace: c.namespace,
 		},
 		Spec: batchv1.JobSpec{
-			Parallelism: ptr.To(int32(test.Config.WorkerCount)),
-			Completions: ptr.To(int32(test.Config.WorkerCount)),
+			// Indexed jobs can be scaled while running as long as
+			// parallelism and completions change together
+			CompletionMode: ptr.To(batchv1.IndexedCompletion),
+			Parallelism:    ptr.To(int32(test.Config.WorkerCount)),
+			Completions:    ptr.To(int32(test.Config.WorkerCount)),
 			Template: corev1.PodTemplateSpec{
 				Spec: corev1.PodSpec{
 					RestartPolicy: corev1.RestartPolicyNever,
@@ -79,8 +272,25 @@ func (c *LoadTestController) StartLoadTest(ctx context.Context, test *models.Loa
 		},
 	}
 
-	_, err := c.kubeClient.BatchV1().Jobs(c.namespace).Create(ctx, job, metav1.CreateOptions{})
-	return err
+	if len(secretValues) == 0 {
+		_, err = c.kubeClient.BatchV1().Jobs(c.namespace).Create(ctx, job, metav1.CreateOptions{})
+		return err
+	}
+
+	secret, err := c.createTestSecret(ctx, job.Name+"-secrets", secretValues)
+	if err != nil {
+		return fmt.Errorf("failed to create secret: %v", err)
+	}
+	mountTestSecret(job, secret.Name)
+	created, err := c.kubeClient.BatchV1().Jobs(c.namespace).Create(ctx, job, metav1.CreateOptions{})
+	if err != nil {
+		c.kubeClient.CoreV1().Secrets(c.namespace).Delete(ctx, secret.Name, metav1.DeleteOptions{})
+		return err
+	}
+	if err := c.adoptTestSecret(ctx, secret, created); err != nil {
+		log.Printf("Warning: secret %s is not owned by job %s and outlives it: %v", secret.Name, created.Name, err)
+	}
+	return nil
 }
 
 func (c *LoadTestController) StopLoadTest(ctx context.Context, tesID string) error {
@@ -90,6 +300,51 @@ func (c *LoadTestController) StopLoadTest(ctx context.Context, tesID string) err
 			PropagationPolicy: ptr.To(metav1.DeletePropagationBackground)})
 }
 
+func startTimeout(config models.LoadTestConfig) int {
+	if config.StartTimeout > 0 {
+		return config.StartTimeout
+	}
+	return DefaultStartTimeout
+}
+
+// SplitRate divides the total target RPS across workers. The remainder goes
+// one request each to the lowest completion indexes, so the rates always add
+// up to total.
+func SplitRate(total, workers int) []int {
+	if workers <= 0 {
+		return nil
+	}
+	rates := make([]int, workers)
+	for i := range rates {
+		rates[i] = total / workers
+		if i < total%workers {
+			rates[i]++
+		}
+	}
+	return rates
+}
+
+func joinRates(rates []int) string {
+	parts := make([]string, len(rates))
+	for i, rate := range rates {
+		parts[i] = fmt.Sprintf("%d", rate)
+	}
+	return strings.Join(parts, ",")
+}
+
+// ScaleLoadTest changes the number of worker pods of a running test. Scaling
+// down removes the pods with the highest completion indexes.
+func (c *LoadTestController) ScaleLoadTest(ctx context.Context, testID string, workers int) error {
+	jobName := fmt.Sprintf("loadtest-%s", testID)
+	patch := fmt.Sprintf(`{"spec":{"parallelism":%d,"completions":%d}}`, workers, workers)
+	_, err := c.kubeClient.BatchV1().Jobs(c.namespace).Patch(ctx, jobName, types.MergePatchType,
+		[]byte(patch), metav1.PatchOptions{})
+	if err != nil {
+		return fmt.Errorf("failed to scale job %s: %v", jobName, err)
+	}
+	return nil
+}
+
 func (c *LoadTestController) GetLoadTestStatus(ctx context.Context, testID string) (*models.LoadTestStatus, error) {
 	jobName := fmt.Sprintf("loadtest-%s", testID)
 	job, err := c.kubeClient.BatchV1().Jobs(c.namespace).Get(ctx, jobName, metav1.GetOptions{})
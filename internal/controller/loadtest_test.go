@@ -0,0 +1,52 @@
+package controller
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestSplitRate(t *testing.T) {
+	tests := []struct {
+		name    string
+		total   int
+		workers int
+		want    []int
+	}{
+		{"even", 9, 3, []int{3, 3, 3}},
+		{"remainder to the lowest indexes", 11, 4, []int{3, 3, 3, 2}},
+		{"fewer requests than workers", 2, 5, []int{1, 1, 0, 0, 0}},
+		{"single worker", 7, 1, []int{7}},
+		{"no rate", 0, 2, []int{0, 0}},
+		{"no workers", 7, 0, nil},
+		{"negative workers", 7, -1, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := SplitRate(tt.total, tt.workers); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("SplitRate(%d, %d) = %v, want %v", tt.total, tt.workers, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSplitRateAddsUp(t *testing.T) {
+	for total := 0; total <= 50; total++ {
+		for workers := 1; workers <= 12; workers++ {
+			rates := SplitRate(total, workers)
+			sum, lowest, highest := 0, rates[0], rates[0]
+			for _, rate := range rates {
+				sum += rate
+				lowest, highest = min(lowest, rate), max(highest, rate)
+			}
+			if sum != total || highest-lowest > 1 {
+				t.Fatalf("SplitRate(%d, %d) = %v, want shares adding up to %d within one of each other", total, workers, rates, total)
+			}
+		}
+	}
+}
+
+func TestJoinRates(t *testing.T) {
+	if got := joinRates(SplitRate(10, 3)); got != "4,3,3" {
+		t.Errorf("joinRates = %q, want %q", got, "4,3,3")
+	}
+}
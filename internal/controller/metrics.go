@@ -5,6 +5,7 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"sort"
 	"strings"
 	"time"
 
@@ -14,7 +15,26 @@ import (
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 )
 
-func (c *LoadTestController) GetLoadTestMetrics(ctx context.Context, testID string) (*models.MetricsSnapshot, error) {
+// MetricsTiming carries the parts of a test's timeline that the API tracks
+// outside Kubernetes.
+type MetricsTiming struct {
+	// StartedAt is the synchronized start instant broadcast to the workers;
+	// when nil the Job start time is used.
+	StartedAt *time.Time
+	Paused    time.Duration
+}
+
+// WorkerSnapshot is the metrics a worker pod last logged. The API keeps them,
+// so workers still count towards the test after their pods are gone.
+type WorkerSnapshot struct {
+	PodName   string
+	Metrics   *models.LoadTestMetrics
+	ScrapedAt time.Time
+}
+
+// GetLoadTestMetrics aggregates the metrics of the test's worker pods and of
+// the workers in history whose pods no longer exist.
+func (c *LoadTestController) GetLoadTestMetrics(ctx context.Context, testID string, timing MetricsTiming, history []WorkerSnapshot) (*models.MetricsSnapshot, error) {
 	labelSelector := fmt.Sprintf("job-name=loadtest-%s", testID)
 	pods, err := c.kubeClient.CoreV1().Pods(c.namespace).List(ctx, metav1.ListOptions{
 		LabelSelector: labelSelector,
@@ -23,14 +43,12 @@ func (c *LoadTestController) GetLoadTestMetrics(ctx context.Context, testID stri
 		return nil, fmt.Errorf("failed to list pods: %v", err)
 	}
 
-	var workerMetrics []models.WorkerMetrics
-	var totalRequests, successfulRequests, failedRequests int64
-	var totalResponseTime float64
-	var requestCount int64
-	statusCodes := make(map[string]int64)
+	var workers []workerRecord
+	listed := make(map[string]bool)
 	activeWorkers := 0
 
 	for _, pod := range pods.Items {
+		listed[pod.Name] = true
 		if pod.Status.Phase == corev1.PodRunning || pod.Status.Phase == corev1.PodSucceeded {
 			metrics, err := c.extractMetricsFromPod(ctx, pod.Name)
 			if err != nil {
@@ -39,30 +57,103 @@ func (c *LoadTestController) GetLoadTestMetrics(ctx context.Context, testID stri
 			}
 
 			if metrics != nil {
-				workerMetrics = append(workerMetrics, models.WorkerMetrics{
-					WorkerID:           pod.Name,
-					PodName:            pod.Name,
-					TotalRequests:      metrics.TotalRequests,
-					SuccessfulRequests: metrics.SuccessfulRequests,
-					FailedRequests:     metrics.FailedRequests,
-					AvgResponseTime:    metrics.AvgResponseTime,
-					LastUpdate:         metrics.Timestamp,
-				})
-
-				totalRequests += metrics.TotalRequests
-				successfulRequests += metrics.SuccessfulRequests
-				failedRequests += metrics.FailedRequests
-				totalResponseTime += metrics.AvgResponseTime * float64(metrics.TotalRequests)
-				requestCount += metrics.TotalRequests
-
-				for code, count := range metrics.StatusCodes {
-					statusCodes[code] += count
-				}
+				workers = append(workers, newWorkerRecord(pod.Name, strings.ToLower(string(pod.Status.Phase)), metrics))
 				activeWorkers++
 			}
 		}
 	}
 
+	// pods removed by scaling down no longer show up in the listing
+	workers = append(workers, removedWorkers(history, listed)...)
+
+	var workerMetrics []models.WorkerMetrics
+	var totalRequests, successfulRequests, failedRequests int64
+	var totalResponseTime float64
+	var requestCount int64
+	var droppedIterations, iterations int64
+	var newConnections, reusedConnections, streams int64
+	var messagesReceived, messagesSent int64
+	var concurrentConnections, peakConnections, reconnects int64
+	timeToFirstEvent := models.NewHistogram()
+	eventGaps := models.NewHistogram()
+	connectTime := models.NewHistogram()
+	disconnects := make(map[string]int64)
+	protocols := make(map[string]int64)
+	targetIPs := make(map[string]int64)
+	statusCodes := make(map[string]int64)
+	errorCounts := make(map[string]int64)
+	errorSamples := make(map[string][]string)
+	latencyCorrected := models.NewHistogram()
+	latencyUncorrected := models.NewHistogram()
+	phases := models.NewPhaseHistograms()
+	endpoints := make(map[string]*models.EndpointMetrics)
+
+	for _, record := range workers {
+		metrics := record.metrics
+		workerMetrics = append(workerMetrics, record.worker)
+
+		totalRequests += metrics.TotalRequests
+		successfulRequests += metrics.SuccessfulRequests
+		failedRequests += metrics.FailedRequests
+		totalResponseTime += metrics.AvgResponseTime * float64(metrics.TotalRequests)
+		requestCount += metrics.TotalRequests
+
+		for code, count := range metrics.StatusCodes {
+			statusCodes[code] += count
+		}
+		for category, count := range metrics.Errors {
+			errorCounts[category] += count
+		}
+		for category, samples := range metrics.ErrorSamples {
+			for _, sample := range samples {
+				models.AddErrorSample(errorSamples, category, sample)
+			}
+		}
+
+		droppedIterations += metrics.DroppedIterations
+		iterations += metrics.Iterations
+		latencyCorrected.Merge(metrics.LatencyCorrected)
+		latencyUncorrected.Merge(metrics.LatencyUncorrected)
+		phases.Merge(metrics.Phases)
+		newConnections += metrics.NewConnections
+		reusedConnections += metrics.ReusedConnections
+		streams += metrics.Streams
+		messagesReceived += metrics.MessagesReceived
+		messagesSent += metrics.MessagesSent
+		connectTime.Merge(metrics.ConnectTime)
+		if record.worker.Status != "removed" {
+			concurrentConnections += metrics.ActiveConnections
+		}
+		peakConnections += metrics.PeakConnections
+		reconnects += metrics.Reconnects
+		timeToFirstEvent.Merge(metrics.TimeToFirstEvent)
+		eventGaps.Merge(metrics.EventGaps)
+		for reason, count := range metrics.Disconnects {
+			disconnects[reason] += count
+		}
+		for proto, count := range metrics.Protocols {
+			protocols[proto] += count
+		}
+		for ip, count := range metrics.TargetIPs {
+			targetIPs[ip] += count
+		}
+		models.MergeEndpoints(endpoints, metrics.Endpoints)
+	}
+	latencyCorrected.Summarize()
+	latencyUncorrected.Summarize()
+	phases.Summarize()
+	connectTime.Summarize()
+	timeToFirstEvent.Summarize()
+	eventGaps.Summarize()
+
+	var connectionReuseRatio, streamsPerConnection float64
+	if connections := newConnections + reusedConnections; connections > 0 {
+		connectionReuseRatio = float64(reusedConnections) / float64(connections)
+	}
+	if newConnections > 0 {
+		streamsPerConnection = float64(streams) / float64(newConnections)
+	}
+
 	var avgResponseTime float64
 	if requestCount > 0 {
 		avgResponseTime = totalResponseTime / float64(requestCount)
@@ -79,8 +170,13 @@ func (c *LoadTestController) GetLoadTestMetrics(ctx context.Context, testID stri
 	var actualElapsed float64
 	var isCompleted bool
 
-	if err == nil && job.Status.StartTime != nil {
-		startTime := job.Status.StartTime.Time
+	if err == nil && (timing.StartedAt != nil || job.Status.StartTime != nil) {
+		var startTime time.Time
+		if timing.StartedAt != nil {
+			startTime = *timing.StartedAt
+		} else {
+			startTime = job.Status.StartTime.Time
+		}
 
 		for _, condition := range job.Status.Conditions {
 			if (condition.Type == batchv1.JobComplete || condition.Type == batchv1.JobFailed) &&
@@ -94,22 +190,56 @@ func (c *LoadTestController) GetLoadTestMetrics(ctx context.Context, testID stri
 		if !isCompleted {
 			actualElapsed = time.Since(startTime).Seconds()
 		}
+		// load is not generated while paused or before the synchronized start
+		actualElapsed = max(0, actualElapsed-timing.Paused.Seconds())
 	}
 
-	var rps float64
+	var rps, messagesPerSecond, iterationRate float64
 	if actualElapsed > 0 {
 		rps = float64(totalRequests) / actualElapsed
+		messagesPerSecond = float64(messagesReceived) / actualElapsed
+		iterationRate = float64(iterations) / actualElapsed
+	}
+	for _, endpoint := range endpoints {
+		endpoint.Summarize(actualElapsed)
 	}
 
 	summary := models.AggregatedMetrics{
-		TotalRequests:       totalRequests,
-		SuccessfulRequests:  successfulRequests,
-		FailedRequests:      failedRequests,
-		OverallErrorRate:    errorRate,
-		AvgResponseTime:     avgResponseTime,
-		RequestsPerSecond:   rps,
-		StatusCodeBreakdown: statusCodes,
-		ActiveWorkers:       activeWorkers,
+		TotalRequests:         totalRequests,
+		SuccessfulRequests:    successfulRequests,
+		FailedRequests:        failedRequests,
+		OverallErrorRate:      errorRate,
+		AvgResponseTime:       avgResponseTime,
+		RequestsPerSecond:     rps,
+		StatusCodeBreakdown:   statusCodes,
+		ErrorBreakdown:        errorCounts,
+		ErrorSamples:          errorSamples,
+		ActiveWorkers:         activeWorkers,
+		ElapsedSeconds:        actualElapsed,
+		PausedSeconds:         timing.Paused.Seconds(),
+		DroppedIterations:     droppedIterations,
+		Iterations:            iterations,
+		IterationRate:         iterationRate,
+		LatencyCorrected:      latencyCorrected,
+		LatencyUncorrected:    latencyUncorrected,
+		Phases:                phases,
+		ConnectionReuseRatio:  connectionReuseRatio,
+		Connections:           newConnections,
+		Streams:               streams,
+		StreamsPerConnection:  streamsPerConnection,
+		Protocols:             protocols,
+		TargetIPs:             targetIPs,
+		MessagesReceived:      messagesReceived,
+		MessagesSent:          messagesSent,
+		MessagesPerSecond:     messagesPerSecond,
+		ConnectTime:           connectTime,
+		Disconnects:           disconnects,
+		ConcurrentConnections: concurrentConnections,
+		PeakConnections:       peakConnections,
+		Reconnects:            reconnects,
+		TimeToFirstEvent:      timeToFirstEvent,
+		EventGaps:             eventGaps,
+		Endpoints:             endpoints,
 	}
 
 	return &models.MetricsSnapshot{
@@ -120,6 +250,77 @@ func (c *LoadTestController) GetLoadTestMetrics(ctx context.Context, testID stri
 	}, nil
 }
 
+// workerRecord pairs the per-worker summary with the raw metrics it came from.
+type workerRecord struct {
+	worker  models.WorkerMetrics
+	metrics *models.LoadTestMetrics
+}
+
+func newWorkerRecord(podName, status string, metrics *models.LoadTestMetrics) workerRecord {
+	return workerRecord{
+		worker: models.WorkerMetrics{
+			WorkerID:           podName,
+			PodName:            podName,
+			Status:             status,
+			WorkerIndex:        metrics.WorkerIndex,
+			TargetRPS:          metrics.TargetRPS,
+			TotalRequests:      metrics.TotalRequests,
+			SuccessfulRequests: metrics.SuccessfulRequests,
+			FailedRequests:     metrics.FailedRequests,
+			AvgResponseTime:    metrics.AvgResponseTime,
+			DroppedIterations:  metrics.DroppedIterations,
+			IterationRate:      metrics.IterationRate,
+			ActiveConnections:  metrics.ActiveConnections,
+			Endpoints:          metrics.Endpoints,
+			LastUpdate:         metrics.Timestamp,
+		},
+		metrics: metrics,
+	}
+}
+
+// removedWorkers returns the workers in history whose pods are gone, marked
+// as removed as of the last time their metrics were scraped.
+func removedWorkers(history []WorkerSnapshot, listed map[string]bool) []workerRecord {
+	var removed []workerRecord
+	for _, snapshot := range history {
+		if listed[snapshot.PodName] || snapshot.Metrics == nil {
+			continue
+		}
+		record := newWorkerRecord(snapshot.PodName, "removed", snapshot.Metrics)
+		removedAt := snapshot.ScrapedAt
+		record.worker.RemovedAt = &removedAt
+		removed = append(removed, record)
+	}
+	sort.Slice(removed, func(i, j int) bool {
+		return removed[i].worker.PodName < removed[j].worker.PodName
+	})
+	return removed
+}
+
+// ScrapeWorkers returns the metrics last logged by each running or finished
+// worker pod of the test.
+func (c *LoadTestController) ScrapeWorkers(ctx context.Context, testID string) ([]WorkerSnapshot, error) {
+	pods, err := c.kubeClient.CoreV1().Pods(c.namespace).List(ctx, metav1.ListOptions{
+		LabelSelector: fmt.Sprintf("job-name=loadtest-%s", testID),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list pods: %v", err)
+	}
+
+	var snapshots []WorkerSnapshot
+	for _, pod := range pods.Items {
+		if pod.Status.Phase != corev1.PodRunning && pod.Status.Phase != corev1.PodSucceeded {
+			continue
+		}
+		metrics, err := c.extractMetricsFromPod(ctx, pod.Name)
+		if err != nil || metrics == nil {
+			continue
+		}
+		snapshots = append(snapshots, WorkerSnapshot{PodName: pod.Name, Metrics: metrics, ScrapedAt: time.Now()})
+	}
+	return snapshots, nil
+}
+
 func (c *LoadTestController) extractMetricsFromPod(ctx context.Context, podName string) (*models.LoadTestMetrics, error) {
 	req := c.kubeClient.CoreV1().Pods(c.namespace).GetLogs(podName, &corev1.PodLogOptions{
 		TailLines: &[]int64{100}[0], // last 100 lines
@@ -132,6 +333,8 @@ func (c *LoadTestController) extractMetricsFromPod(ctx context.Context, podName
 	defer podLogs.Close()
 
 	scanner := bufio.NewScanner(podLogs)
+	// metrics lines carry histograms and can exceed the default token size
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
 	var lastMetrics *models.LoadTestMetrics
 
 	var capturing bool
@@ -144,6 +347,13 @@ func (c *LoadTestController) extractMetricsFromPod(ctx context.Context, podName
 		// start of json
 		if strings.Contains(line, "METRICS:") {
 			idx := strings.Index(line, "{")
+			// single-line JSON
+			var metrics models.LoadTestMetrics
+			if idx != -1 && json.Unmarshal([]byte(line[idx:]), &metrics) == nil {
+				lastMetrics = &metrics
+				capturing = false
+				continue
+			}
 			if idx != -1 {
 				capturing = true
 				braceCount = 1
@@ -181,7 +391,9 @@ func (c *LoadTestController) extractMetricsFromPod(ctx context.Context, podName
 	return lastMetrics, nil
 }
 
-func (c *LoadTestController) StreamLoadTestMetrics(ctx context.Context, testID string) (<-chan *models.MetricsSnapshot, error) {
+// timing and history are called before every snapshot so pauses and removed
+// workers are reflected mid-stream.
+func (c *LoadTestController) StreamLoadTestMetrics(ctx context.Context, testID string, timing func() MetricsTiming, history func() []WorkerSnapshot) (<-chan *models.MetricsSnapshot, error) {
 	metricsChan := make(chan *models.MetricsSnapshot, 10)
 
 	go func() {
@@ -194,7 +406,7 @@ func (c *LoadTestController) StreamLoadTestMetrics(ctx context.Context, testID s
 			case <-ctx.Done():
 				return
 			case <-ticker.C:
-				metrics, err := c.GetLoadTestMetrics(ctx, testID)
+				metrics, err := c.GetLoadTestMetrics(ctx, testID, timing(), history())
 				if err != nil {
 					fmt.Printf("Failed to get metrics for test %s: %v\n", testID, err)
 					continue
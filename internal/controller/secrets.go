@@ -0,0 +1,67 @@
+package controller
+
+import (
+	"context"
+
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/utils/ptr"
+)
+
+// secretsDir is where worker pods find one file per secret their test
+// references.
+const secretsDir = "/var/run/secrets/loadtest"
+
+// createTestSecret stores the secret values of a test in a Kubernetes
+// Secret, so they never appear in the Job spec.
+func (c *LoadTestController) createTestSecret(ctx context.Context, name string, values map[string][]byte) (*corev1.Secret, error) {
+	secret := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      name,
+			Namespace: c.namespace,
+		},
+		Type: corev1.SecretTypeOpaque,
+		Data: values,
+	}
+	return c.kubeClient.CoreV1().Secrets(c.namespace).Create(ctx, secret, metav1.CreateOptions{})
+}
+
+// mountTestSecret makes the secret readable in the worker containers of
+// job under secretsDir.
+func mountTestSecret(job *batchv1.Job, secretName string) {
+	pod := &job.Spec.Template.Spec
+	pod.Volumes = append(pod.Volumes, corev1.Volume{
+		Name: "secrets",
+		VolumeSource: corev1.VolumeSource{
+			Secret: &corev1.SecretVolumeSource{
+				SecretName:  secretName,
+				DefaultMode: ptr.To(int32(0o400)),
+			},
+		},
+	})
+	for i := range pod.Containers {
+		pod.Containers[i].VolumeMounts = append(pod.Containers[i].VolumeMounts, corev1.VolumeMount{
+			Name:      "secrets",
+			MountPath: secretsDir,
+			ReadOnly:  true,
+		})
+		pod.Containers[i].Env = append(pod.Containers[i].Env, corev1.EnvVar{
+			Name:  "SECRETS_DIR",
+			Value: secretsDir,
+		})
+	}
+}
+
+// adoptTestSecret makes job the owner of secret, so that deleting the job
+// also deletes the secret.
+func (c *LoadTestController) adoptTestSecret(ctx context.Context, secret *corev1.Secret, job *batchv1.Job) error {
+	secret.OwnerReferences = []metav1.OwnerReference{{
+		APIVersion: "batch/v1",
+		Kind:       "Job",
+		Name:       job.Name,
+		UID:        job.UID,
+	}}
+	_, err := c.kubeClient.CoreV1().Secrets(c.namespace).Update(ctx, secret, metav1.UpdateOptions{})
+	return err
+}
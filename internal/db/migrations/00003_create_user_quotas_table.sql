@@ -0,0 +1,23 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE TABLE user_quotas (
+    user_id INTEGER PRIMARY KEY REFERENCES users(id) ON DELETE CASCADE,
+    max_workers_per_test INTEGER NOT NULL,
+    max_aggregate_rps INTEGER NOT NULL,
+    monthly_test_minutes INTEGER NOT NULL,
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+-- Usage is accounted from the time a test actually started running
+ALTER TABLE load_tests ADD COLUMN started_at TIMESTAMP WITH TIME ZONE;
+
+CREATE INDEX idx_load_tests_user_started_at ON load_tests(user_id, started_at);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP INDEX IF EXISTS idx_load_tests_user_started_at;
+ALTER TABLE load_tests DROP COLUMN IF EXISTS started_at;
+DROP TABLE IF EXISTS user_quotas;
+-- +goose StatementEnd
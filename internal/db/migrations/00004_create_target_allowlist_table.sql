@@ -0,0 +1,41 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE TABLE organizations (
+    id SERIAL PRIMARY KEY,
+    name VARCHAR(255) NOT NULL,
+    created_by INTEGER REFERENCES users(id) ON DELETE SET NULL,
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+ALTER TABLE users ADD COLUMN organization_id INTEGER REFERENCES organizations(id) ON DELETE SET NULL;
+
+-- Every existing user gets a personal organization
+INSERT INTO organizations (name, created_by)
+SELECT username, id FROM users;
+
+UPDATE users u SET organization_id = o.id
+FROM organizations o
+WHERE o.created_by = u.id;
+
+CREATE TABLE target_allowlist (
+    id SERIAL PRIMARY KEY,
+    organization_id INTEGER NOT NULL REFERENCES organizations(id) ON DELETE CASCADE,
+    kind VARCHAR(10) NOT NULL,
+    value VARCHAR(255) NOT NULL,
+    verification_token VARCHAR(64) NOT NULL,
+    verification_method VARCHAR(10),
+    verified_at TIMESTAMP WITH TIME ZONE,
+    created_by INTEGER REFERENCES users(id) ON DELETE SET NULL,
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    UNIQUE (organization_id, value)
+);
+
+CREATE INDEX idx_target_allowlist_organization_id ON target_allowlist(organization_id);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE IF EXISTS target_allowlist;
+ALTER TABLE users DROP COLUMN IF EXISTS organization_id;
+DROP TABLE IF EXISTS organizations;
+-- +goose StatementEnd
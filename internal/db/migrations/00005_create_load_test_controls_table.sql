@@ -0,0 +1,26 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE TABLE load_test_controls (
+    test_id VARCHAR(255) PRIMARY KEY REFERENCES load_tests(id) ON DELETE CASCADE,
+    requests_per_sec INTEGER NOT NULL,
+    max_concurrency INTEGER NOT NULL DEFAULT 0,
+    version INTEGER NOT NULL DEFAULT 1,
+    updated_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE TABLE load_test_annotations (
+    id SERIAL PRIMARY KEY,
+    test_id VARCHAR(255) NOT NULL REFERENCES load_tests(id) ON DELETE CASCADE,
+    type VARCHAR(50) NOT NULL,
+    message TEXT NOT NULL,
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX idx_load_test_annotations_test_id ON load_test_annotations(test_id, created_at);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE IF EXISTS load_test_annotations;
+DROP TABLE IF EXISTS load_test_controls;
+-- +goose StatementEnd
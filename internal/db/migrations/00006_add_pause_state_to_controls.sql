@@ -0,0 +1,13 @@
+-- +goose Up
+-- +goose StatementBegin
+ALTER TABLE load_test_controls ADD COLUMN state VARCHAR(20) NOT NULL DEFAULT 'running';
+ALTER TABLE load_test_controls ADD COLUMN paused_at TIMESTAMP WITH TIME ZONE;
+ALTER TABLE load_test_controls ADD COLUMN paused_seconds DOUBLE PRECISION NOT NULL DEFAULT 0;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+ALTER TABLE load_test_controls DROP COLUMN IF EXISTS paused_seconds;
+ALTER TABLE load_test_controls DROP COLUMN IF EXISTS paused_at;
+ALTER TABLE load_test_controls DROP COLUMN IF EXISTS state;
+-- +goose StatementEnd
@@ -0,0 +1,15 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE TABLE worker_metrics (
+    test_id VARCHAR(255) NOT NULL REFERENCES load_tests(id) ON DELETE CASCADE,
+    pod_name VARCHAR(255) NOT NULL,
+    metrics JSONB NOT NULL,
+    scraped_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    PRIMARY KEY (test_id, pod_name)
+);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE IF EXISTS worker_metrics;
+-- +goose StatementEnd
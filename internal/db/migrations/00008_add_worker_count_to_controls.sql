@@ -0,0 +1,14 @@
+-- +goose Up
+-- +goose StatementBegin
+ALTER TABLE load_test_controls ADD COLUMN worker_count INTEGER NOT NULL DEFAULT 1;
+
+UPDATE load_test_controls c
+SET worker_count = GREATEST((t.config->>'worker_count')::INTEGER, 1)
+FROM load_tests t
+WHERE t.id = c.test_id;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+ALTER TABLE load_test_controls DROP COLUMN IF EXISTS worker_count;
+-- +goose StatementEnd
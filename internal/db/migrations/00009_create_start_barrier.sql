@@ -0,0 +1,18 @@
+-- +goose Up
+-- +goose StatementBegin
+ALTER TABLE load_test_controls ADD COLUMN start_at TIMESTAMP WITH TIME ZONE;
+
+CREATE TABLE load_test_workers (
+    test_id VARCHAR(255) NOT NULL REFERENCES load_tests(id) ON DELETE CASCADE,
+    worker_index INTEGER NOT NULL,
+    pod_name VARCHAR(255) NOT NULL,
+    registered_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (test_id, worker_index)
+);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE IF EXISTS load_test_workers;
+ALTER TABLE load_test_controls DROP COLUMN IF EXISTS start_at;
+-- +goose StatementEnd
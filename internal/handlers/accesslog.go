@@ -0,0 +1,223 @@
+package handlers
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"strconv"
+
+	"github.com/Vinayak9769/loadagg/internal/auth"
+	"github.com/Vinayak9769/loadagg/internal/importer"
+	"github.com/Vinayak9769/loadagg/pkg/models"
+	"github.com/go-chi/chi/v5"
+)
+
+// maxAccessLogSize bounds uploaded access logs.
+const maxAccessLogSize = 64 << 20
+
+// maxSkippedReported bounds the unreplayable lines listed in an upload
+// response; the rest are only counted.
+const maxSkippedReported = 100
+
+type AccessLogHandler struct {
+	db *sql.DB
+}
+
+func NewAccessLogHandler(db *sql.DB) *AccessLogHandler {
+	return &AccessLogHandler{db: db}
+}
+
+func (h *AccessLogHandler) Routes() chi.Router {
+	r := chi.NewRouter()
+
+	r.Group(func(r chi.Router) {
+		r.Use(auth.JWTMiddleware)
+
+		r.Get("/", h.ListAccessLogs)
+		r.Post("/", h.UploadAccessLog)
+		r.Delete("/{id}", h.DeleteAccessLog)
+	})
+
+	return r
+}
+
+// List the user's access logs /api/v1/accesslogs
+func (h *AccessLogHandler) ListAccessLogs(w http.ResponseWriter, r *http.Request) {
+	query := `
+        SELECT id, user_id, name, format, entries, duration_ms, peak_rps, created_at
+        FROM access_logs
+        WHERE user_id = $1
+        ORDER BY created_at DESC
+    `
+	rows, err := h.db.Query(query, getUserID(r))
+	if err != nil {
+		http.Error(w, "Failed to retrieve access logs", http.StatusInternalServerError)
+		return
+	}
+	defer rows.Close()
+
+	logs := []models.AccessLog{}
+	for rows.Next() {
+		var l models.AccessLog
+		if err := rows.Scan(&l.ID, &l.UserID, &l.Name, &l.Format, &l.Entries, &l.DurationMS, &l.PeakRPS, &l.CreatedAt); err != nil {
+			continue
+		}
+		logs = append(logs, l)
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(logs)
+}
+
+// Upload an access log for replay /api/v1/accesslogs?name=<name>&format=combined|json
+// The body is the log file. Lines that cannot be replayed are counted and
+// the first of them listed in the response.
+func (h *AccessLogHandler) UploadAccessLog(w http.ResponseWriter, r *http.Request) {
+	name := r.URL.Query().Get("name")
+	if name == "" {
+		http.Error(w, "name is required", http.StatusBadRequest)
+		return
+	}
+	format := r.URL.Query().Get("format")
+	if format == "" {
+		format = models.AccessLogCombined
+	}
+
+	data, err := io.ReadAll(http.MaxBytesReader(w, r.Body, maxAccessLogSize))
+	if err != nil {
+		http.Error(w, fmt.Sprintf("Access log must be at most %d bytes", maxAccessLogSize), http.StatusRequestEntityTooLarge)
+		return
+	}
+
+	entries, skipped, err := importer.AccessLog(data, format)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	encoded, err := json.Marshal(entries)
+	if err != nil {
+		http.Error(w, "Failed to encode access log", http.StatusInternalServerError)
+		return
+	}
+
+	l := models.AccessLog{
+		UserID:       getUserID(r),
+		Name:         name,
+		Format:       format,
+		Entries:      len(entries),
+		DurationMS:   entries[len(entries)-1].OffsetMS,
+		PeakRPS:      importer.PeakRate(entries),
+		SkippedCount: len(skipped),
+		Skipped:      skipped[:min(len(skipped), maxSkippedReported)],
+	}
+	query := `
+        INSERT INTO access_logs (user_id, name, format, entries, duration_ms, peak_rps, data)
+        VALUES ($1, $2, $3, $4, $5, $6, $7)
+        RETURNING id, created_at
+    `
+	if err := h.db.QueryRow(query, l.UserID, l.Name, l.Format, l.Entries, l.DurationMS, l.PeakRPS, encoded).Scan(&l.ID, &l.CreatedAt); err != nil {
+		http.Error(w, "Failed to save access log", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(l)
+}
+
+// Delete an access log /api/v1/accesslogs/{id}
+func (h *AccessLogHandler) DeleteAccessLog(w http.ResponseWriter, r *http.Request) {
+	res, err := h.db.Exec("DELETE FROM access_logs WHERE id = $1 AND user_id = $2", chi.URLParam(r, "id"), getUserID(r))
+	if err != nil {
+		http.Error(w, "Failed to delete access log", http.StatusInternalServerError)
+		return
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		http.Error(w, "Access log not found", http.StatusNotFound)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]string{"message": "Access log deleted successfully"})
+}
+
+// Serve a worker its share of a replay /api/v1/loadtests/{id}/replay?index=<i>&count=<n>
+// Entries are dealt out in turn, so worker i gets every n-th entry
+// starting at the i-th.
+func (h *LoadTestHandler) GetWorkerReplay(w http.ResponseWriter, r *http.Request) {
+	testID := chi.URLParam(r, "id")
+
+	if r.Context().Value("worker_test_id") != testID {
+		http.Error(w, http.StatusText(http.StatusForbidden), http.StatusForbidden)
+		return
+	}
+
+	index, err := strconv.Atoi(r.URL.Query().Get("index"))
+	if err != nil || index < 0 {
+		http.Error(w, "index must be a non-negative integer", http.StatusBadRequest)
+		return
+	}
+	count, err := strconv.Atoi(r.URL.Query().Get("count"))
+	if err != nil || count <= 0 {
+		http.Error(w, "count must be a positive integer", http.StatusBadRequest)
+		return
+	}
+
+	query := `
+        SELECT l.data
+        FROM load_tests t
+        JOIN access_logs l ON l.id::text = t.config #>> '{replay,access_log_id}' AND l.user_id = t.user_id
+        WHERE t.id = $1
+    `
+	var data []byte
+	if err := h.db.QueryRow(query, testID).Scan(&data); err != nil {
+		http.Error(w, "Access log not found", http.StatusNotFound)
+		return
+	}
+	var entries []models.ReplayEntry
+	if err := json.Unmarshal(data, &entries); err != nil {
+		http.Error(w, "Failed to decode access log", http.StatusInternalServerError)
+		return
+	}
+
+	shard := []models.ReplayEntry{}
+	for i := index; i < len(entries); i += count {
+		shard = append(shard, entries[i])
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(shard)
+}
+
+// validateReplayConfig checks that the access log of a replay exists and
+// that requests_per_sec covers its busiest second at the chosen speed, so
+// the replay stays within the rate quota.
+func validateReplayConfig(db *sql.DB, userID string, config models.LoadTestConfig) error {
+	replay := config.Replay
+	if replay.AccessLogID == "" {
+		return fmt.Errorf("replay.access_log_id is required")
+	}
+	if replay.Speed < 0 {
+		return fmt.Errorf("replay.speed must not be negative")
+	}
+	if len(config.Steps) > 0 || len(config.Endpoints) > 0 {
+		return fmt.Errorf("replay cannot be combined with steps or endpoints")
+	}
+
+	var peak int
+	err := db.QueryRow("SELECT peak_rps FROM access_logs WHERE id = $1 AND user_id = $2", replay.AccessLogID, userID).Scan(&peak)
+	if err != nil {
+		return fmt.Errorf("access log %s not found", replay.AccessLogID)
+	}
+	speed := replay.Speed
+	if speed == 0 {
+		speed = 1
+	}
+	if needed := int(math.Ceil(float64(peak) * speed)); config.RequestsPerSec < needed {
+		return fmt.Errorf("requests_per_sec must be at least %d to cover the replay's peak rate at speed %g", needed, speed)
+	}
+	return nil
+}
@@ -0,0 +1,653 @@
+package handlers
+
+import (
+	"context"
+	"crypto/rand"
+	"database/sql"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/Vinayak9769/loadagg/internal/auth"
+	"github.com/Vinayak9769/loadagg/pkg/models"
+	"github.com/go-chi/chi/v5"
+)
+
+const (
+	verificationPath      = "/.well-known/loadtest-verification"
+	verificationTXTPrefix = "loadtest-verification="
+)
+
+// Address blocks that are never reachable as targets unless an organization
+// adds a CIDR range inside one of them to its allowlist.
+var privateBlocks = models.BlockedNetworks()
+
+// URL schemes a target may use; grpc and grpcs are gRPC over plaintext HTTP/2
+// and over TLS, dns is the resolver a dns test queries.
+var targetSchemes = map[string]bool{
+	"http": true, "https": true,
+	"grpc": true, "grpcs": true,
+	"ws": true, "wss": true,
+	"tcp": true, "udp": true, "dns": true,
+}
+
+type AllowlistHandler struct {
+	db         *sql.DB
+	httpClient *http.Client
+}
+
+func NewAllowlistHandler(db *sql.DB) *AllowlistHandler {
+	return &AllowlistHandler{
+		db: db,
+		httpClient: &http.Client{
+			Timeout:   10 * time.Second,
+			Transport: verificationTransport(),
+			CheckRedirect: func(req *http.Request, via []*http.Request) error {
+				return http.ErrUseLastResponse
+			},
+		},
+	}
+}
+
+// verificationTransport dials only public addresses, so a host whose DNS
+// answer changes after validation cannot point the verification fetch at the
+// API's own network.
+func verificationTransport() *http.Transport {
+	dialer := &net.Dialer{
+		Timeout: 5 * time.Second,
+		Control: refusePrivateAddress,
+	}
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	// a proxy would be dialed instead of the host
+	transport.Proxy = nil
+	transport.DialContext = dialer.DialContext
+	return transport
+}
+
+// refusePrivateAddress is called with the resolved address right before
+// each connection is made.
+func refusePrivateAddress(network, address string, _ syscall.RawConn) error {
+	host, _, err := net.SplitHostPort(address)
+	if err != nil {
+		return err
+	}
+	ip := net.ParseIP(host)
+	if ip == nil || ip.IsUnspecified() || isPrivateIP(ip) {
+		return fmt.Errorf("refusing to connect to private address %s", host)
+	}
+	return nil
+}
+
+func (h *AllowlistHandler) Routes() chi.Router {
+	r := chi.NewRouter()
+
+	r.Group(func(r chi.Router) {
+		r.Use(auth.JWTMiddleware)
+
+		r.Get("/", h.ListEntries)
+		r.Post("/", h.CreateEntry)
+		r.Post("/{id}/verify", h.VerifyEntry)
+		r.Delete("/{id}", h.DeleteEntry)
+	})
+
+	return r
+}
+
+type CreateAllowlistEntryRequest struct {
+	Value string `json:"value"`
+}
+
+type VerifyAllowlistEntryRequest struct {
+	Method string `json:"method"`
+}
+
+type AllowlistEntryResponse struct {
+	models.AllowlistEntry
+	HTTPVerificationURL string `json:"http_verification_url,omitempty"`
+	DNSTXTRecord        string `json:"dns_txt_record,omitempty"`
+}
+
+// List the organization's allowlist /api/v1/allowlist
+func (h *AllowlistHandler) ListEntries(w http.ResponseWriter, r *http.Request) {
+	orgID, err := getUserOrganizationID(h.db, getUserID(r))
+	if err != nil {
+		http.Error(w, "Organization not found", http.StatusNotFound)
+		return
+	}
+
+	entries, err := getAllowlistEntries(h.db, orgID)
+	if err != nil {
+		http.Error(w, "Failed to retrieve allowlist", http.StatusInternalServerError)
+		return
+	}
+
+	resp := make([]AllowlistEntryResponse, 0, len(entries))
+	for _, entry := range entries {
+		resp = append(resp, newAllowlistEntryResponse(entry))
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(resp)
+}
+
+// Add a host or CIDR range to the organization's allowlist /api/v1/allowlist
+// Hosts are created unverified and come back with the token to publish either
+// at /.well-known/loadtest-verification or as a DNS TXT record.
+// CIDR ranges are only accepted inside private address space.
+func (h *AllowlistHandler) CreateEntry(w http.ResponseWriter, r *http.Request) {
+	var req CreateAllowlistEntryRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	userID := getUserID(r)
+	orgID, err := getUserOrganizationID(h.db, userID)
+	if err != nil {
+		http.Error(w, "Organization not found", http.StatusNotFound)
+		return
+	}
+
+	entry, err := newAllowlistEntry(req.Value)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	entry.OrganizationID = orgID
+
+	query := `
+        INSERT INTO target_allowlist (organization_id, kind, value, verification_token, verified_at, created_by)
+        VALUES ($1, $2, $3, $4, $5, $6)
+        RETURNING id, created_at
+    `
+	err = h.db.QueryRow(query, orgID, entry.Kind, entry.Value, entry.VerificationToken,
+		entry.VerifiedAt, userID).Scan(&entry.ID, &entry.CreatedAt)
+	if err != nil {
+		http.Error(w, "Failed to save allowlist entry", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(newAllowlistEntryResponse(*entry))
+}
+
+// Verify ownership of an allowlisted host /api/v1/allowlist/{id}/verify
+// The method is either "http" (token served at /.well-known/loadtest-verification)
+// or "dns" (TXT record "loadtest-verification=<token>" on the host).
+func (h *AllowlistHandler) VerifyEntry(w http.ResponseWriter, r *http.Request) {
+	entryID := chi.URLParam(r, "id")
+
+	var req VerifyAllowlistEntryRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	orgID, err := getUserOrganizationID(h.db, getUserID(r))
+	if err != nil {
+		http.Error(w, "Organization not found", http.StatusNotFound)
+		return
+	}
+
+	entry, err := getAllowlistEntry(h.db, entryID, orgID)
+	if err != nil {
+		http.Error(w, "Allowlist entry not found", http.StatusNotFound)
+		return
+	}
+
+	if entry.Kind != "host" {
+		http.Error(w, "Only host entries require verification", http.StatusBadRequest)
+		return
+	}
+
+	switch req.Method {
+	case "http":
+		err = h.verifyHTTP(r.Context(), entry.Value, entry.VerificationToken)
+	case "dns":
+		err = verifyDNS(r.Context(), entry.Value, entry.VerificationToken)
+	default:
+		http.Error(w, "method must be one of: http, dns", http.StatusBadRequest)
+		return
+	}
+	if err != nil {
+		http.Error(w, fmt.Sprintf("Verification failed: %v", err), http.StatusUnprocessableEntity)
+		return
+	}
+
+	now := time.Now()
+	query := "UPDATE target_allowlist SET verified_at = $1, verification_method = $2 WHERE id = $3"
+	if _, err := h.db.Exec(query, now, req.Method, entry.ID); err != nil {
+		http.Error(w, "Failed to update allowlist entry", http.StatusInternalServerError)
+		return
+	}
+	entry.Verified = true
+	entry.VerifiedAt = &now
+	entry.VerificationMethod = req.Method
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(newAllowlistEntryResponse(*entry))
+}
+
+// Remove an entry from the organization's allowlist /api/v1/allowlist/{id}
+func (h *AllowlistHandler) DeleteEntry(w http.ResponseWriter, r *http.Request) {
+	entryID := chi.URLParam(r, "id")
+
+	orgID, err := getUserOrganizationID(h.db, getUserID(r))
+	if err != nil {
+		http.Error(w, "Organization not found", http.StatusNotFound)
+		return
+	}
+
+	res, err := h.db.Exec("DELETE FROM target_allowlist WHERE id = $1 AND organization_id = $2", entryID, orgID)
+	if err != nil {
+		http.Error(w, "Failed to delete allowlist entry", http.StatusInternalServerError)
+		return
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		http.Error(w, "Allowlist entry not found", http.StatusNotFound)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]string{"message": "Allowlist entry deleted successfully"})
+}
+
+func (h *AllowlistHandler) verifyHTTP(ctx context.Context, host, token string) error {
+	var lastErr error
+	for _, scheme := range []string{"https", "http"} {
+		verifyURL := (&url.URL{Scheme: scheme, Host: host, Path: verificationPath}).String()
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, verifyURL, nil)
+		if err != nil {
+			return err
+		}
+
+		resp, err := h.httpClient.Do(req)
+		if err != nil {
+			lastErr = err
+			continue
+		}
+		body, err := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		resp.Body.Close()
+		if err != nil {
+			lastErr = err
+			continue
+		}
+
+		if resp.StatusCode != http.StatusOK {
+			lastErr = fmt.Errorf("%s returned status %d", verifyURL, resp.StatusCode)
+			continue
+		}
+		if strings.TrimSpace(string(body)) != token {
+			lastErr = fmt.Errorf("%s does not contain the verification token", verifyURL)
+			continue
+		}
+		return nil
+	}
+	return lastErr
+}
+
+func verifyDNS(ctx context.Context, host, token string) error {
+	records, err := net.DefaultResolver.LookupTXT(ctx, host)
+	if err != nil {
+		return fmt.Errorf("TXT lookup for %s failed: %v", host, err)
+	}
+	for _, record := range records {
+		if strings.TrimSpace(record) == verificationTXTPrefix+token {
+			return nil
+		}
+	}
+	return fmt.Errorf("no TXT record %q found on %s", verificationTXTPrefix+token, host)
+}
+
+// checkTargetAllowed rejects targets the user's organization has not allowed.
+// Every address the target resolves to must either be public with a verified
+// host entry, or private and inside an allowlisted CIDR range. The target is
+// resolved the way workers will, honoring the host overrides and DNS server
+// of network.
+func checkTargetAllowed(db *sql.DB, userID, targetURL string, network *models.NetworkConfig) error {
+	u, err := url.Parse(targetURL)
+	if err != nil || !targetSchemes[u.Scheme] || u.Hostname() == "" {
+		return fmt.Errorf("target_url must be an absolute http, https, grpc, grpcs, ws, wss, tcp, udp or dns URL")
+	}
+	host := strings.ToLower(u.Hostname())
+
+	orgID, err := getUserOrganizationID(db, userID)
+	if err != nil {
+		return &policyError{"user does not belong to an organization"}
+	}
+	entries, err := getAllowlistEntries(db, orgID)
+	if err != nil {
+		return fmt.Errorf("failed to load target allowlist: %v", err)
+	}
+
+	return checkHostAllowed(entries, "target", host, network)
+}
+
+// checkHostAllowed checks the addresses workers will connect to for host.
+// Addresses that come from a host override or a custom DNS server are only
+// the user's word, so public ones must also be among the host's answers in
+// public DNS or be verified entries themselves; otherwise verifying one name
+// would allow sending load anywhere.
+func checkHostAllowed(entries []models.AllowlistEntry, what, host string, network *models.NetworkConfig) error {
+	ips, err := resolveHost(host, network)
+	if err != nil {
+		return fmt.Errorf("failed to resolve %s host %s: %v", what, host, err)
+	}
+
+	var publicIPs []net.IP
+	custom := net.ParseIP(host) == nil && network != nil &&
+		(hostOverride(network, host) != nil || network.DNSServer != "")
+	if custom {
+		// a name not in public DNS yet leaves only verified addresses
+		publicIPs, _ = resolveHost(host, nil)
+	}
+
+	needsVerifiedHost := false
+	for _, ip := range ips {
+		if isPrivateIP(ip) {
+			if !cidrAllowed(entries, ip) {
+				return &policyError{fmt.Sprintf("%s %s resolves to private address %s, which is not in your organization's allowlist", what, host, ip)}
+			}
+			continue
+		}
+		needsVerifiedHost = true
+		if custom && !containsIP(publicIPs, ip) && !hostVerified(entries, ip.String()) {
+			return &policyError{fmt.Sprintf("%s %s is sent to %s, which is neither among its public DNS answers nor a verified address", what, host, ip)}
+		}
+	}
+
+	if needsVerifiedHost && !hostVerified(entries, host) {
+		return &policyError{fmt.Sprintf("%s host %s has not been verified for your organization", what, host)}
+	}
+
+	return nil
+}
+
+// checkNetworkAllowed checks the proxy like a target, since all load goes
+// through it, and rejects DNS servers at private addresses outside the
+// allowlisted CIDR ranges, so they cannot be used to reach internal
+// services.
+func checkNetworkAllowed(db *sql.DB, userID string, network *models.NetworkConfig) error {
+	if network == nil || (network.Proxy == "" && network.DNSServer == "") {
+		return nil
+	}
+
+	orgID, err := getUserOrganizationID(db, userID)
+	if err != nil {
+		return &policyError{"user does not belong to an organization"}
+	}
+	entries, err := getAllowlistEntries(db, orgID)
+	if err != nil {
+		return fmt.Errorf("failed to load target allowlist: %v", err)
+	}
+
+	if network.Proxy != "" {
+		u, err := parseProxyURL(network.Proxy)
+		if err != nil {
+			return err
+		}
+		// workers reach the proxy through the overrides and DNS server
+		if err := checkHostAllowed(entries, "proxy", strings.ToLower(u.Hostname()), network); err != nil {
+			return err
+		}
+	}
+	if network.DNSServer != "" {
+		host, _, err := net.SplitHostPort(network.DNSServer)
+		if err != nil {
+			return fmt.Errorf("network.dns_server must be host:port")
+		}
+		ips, err := resolveHost(strings.ToLower(host), nil)
+		if err != nil {
+			return fmt.Errorf("failed to resolve dns server %s: %v", host, err)
+		}
+		for _, ip := range ips {
+			if isPrivateIP(ip) && !cidrAllowed(entries, ip) {
+				return &policyError{fmt.Sprintf("dns server %s resolves to private address %s, which is not in your organization's allowlist", host, ip)}
+			}
+		}
+	}
+	return nil
+}
+
+func newAllowlistEntry(value string) (*models.AllowlistEntry, error) {
+	value = strings.ToLower(strings.TrimSpace(value))
+	if value == "" {
+		return nil, fmt.Errorf("value is required")
+	}
+
+	if strings.Contains(value, "/") {
+		_, network, err := net.ParseCIDR(value)
+		if err != nil {
+			return nil, fmt.Errorf("invalid CIDR range: %v", err)
+		}
+		if !isPrivateNetwork(network) {
+			return nil, fmt.Errorf("CIDR ranges must lie within private address space; add public hosts individually")
+		}
+		now := time.Now()
+		return &models.AllowlistEntry{
+			Kind:       "cidr",
+			Value:      network.String(),
+			Verified:   true,
+			VerifiedAt: &now,
+		}, nil
+	}
+
+	if ip := net.ParseIP(value); ip != nil && isPrivateIP(ip) {
+		return nil, fmt.Errorf("private addresses must be allowed with a CIDR range")
+	}
+	if strings.ContainsAny(value, ":@?#") && net.ParseIP(value) == nil {
+		return nil, fmt.Errorf("value must be a host name, IP address or CIDR range")
+	}
+
+	token, err := generateVerificationToken()
+	if err != nil {
+		return nil, err
+	}
+	return &models.AllowlistEntry{
+		Kind:              "host",
+		Value:             value,
+		VerificationToken: token,
+	}, nil
+}
+
+func newAllowlistEntryResponse(entry models.AllowlistEntry) AllowlistEntryResponse {
+	resp := AllowlistEntryResponse{AllowlistEntry: entry}
+	if entry.Kind == "host" && !entry.Verified {
+		resp.HTTPVerificationURL = (&url.URL{Scheme: "http", Host: entry.Value, Path: verificationPath}).String()
+		resp.DNSTXTRecord = verificationTXTPrefix + entry.VerificationToken
+	}
+	return resp
+}
+
+func getUserOrganizationID(db *sql.DB, userID string) (string, error) {
+	var orgID sql.NullString
+	err := db.QueryRow("SELECT organization_id FROM users WHERE id = $1", userID).Scan(&orgID)
+	if err != nil {
+		return "", err
+	}
+	if !orgID.Valid {
+		return "", sql.ErrNoRows
+	}
+	return orgID.String, nil
+}
+
+func getAllowlistEntries(db *sql.DB, orgID string) ([]models.AllowlistEntry, error) {
+	query := `
+        SELECT id, organization_id, kind, value, verification_token, verification_method, verified_at, created_at
+        FROM target_allowlist
+        WHERE organization_id = $1
+        ORDER BY created_at
+    `
+	rows, err := db.Query(query, orgID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var entries []models.AllowlistEntry
+	for rows.Next() {
+		entry, err := scanAllowlistEntry(rows)
+		if err != nil {
+			continue
+		}
+		entries = append(entries, *entry)
+	}
+	return entries, nil
+}
+
+func getAllowlistEntry(db *sql.DB, entryID, orgID string) (*models.AllowlistEntry, error) {
+	query := `
+        SELECT id, organization_id, kind, value, verification_token, verification_method, verified_at, created_at
+        FROM target_allowlist
+        WHERE id = $1 AND organization_id = $2
+    `
+	return scanAllowlistEntry(db.QueryRow(query, entryID, orgID))
+}
+
+func scanAllowlistEntry(row interface{ Scan(...any) error }) (*models.AllowlistEntry, error) {
+	var entry models.AllowlistEntry
+	var method sql.NullString
+	var verifiedAt sql.NullTime
+
+	err := row.Scan(&entry.ID, &entry.OrganizationID, &entry.Kind, &entry.Value,
+		&entry.VerificationToken, &method, &verifiedAt, &entry.CreatedAt)
+	if err != nil {
+		return nil, err
+	}
+
+	entry.VerificationMethod = method.String
+	if verifiedAt.Valid {
+		entry.Verified = true
+		entry.VerifiedAt = &verifiedAt.Time
+	}
+	return &entry, nil
+}
+
+// resolveHost returns the addresses of host, taking the host overrides and
+// DNS server of network into account when it is not nil.
+func resolveHost(host string, network *models.NetworkConfig) ([]net.IP, error) {
+	if network != nil {
+		if ip := hostOverride(network, host); ip != nil {
+			return []net.IP{ip}, nil
+		}
+	}
+	if ip := net.ParseIP(host); ip != nil {
+		return []net.IP{ip}, nil
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	resolver := net.DefaultResolver
+	if network != nil && network.DNSServer != "" {
+		server := network.DNSServer
+		resolver = &net.Resolver{
+			PreferGo: true,
+			Dial: func(ctx context.Context, proto, _ string) (net.Conn, error) {
+				var d net.Dialer
+				return d.DialContext(ctx, proto, server)
+			},
+		}
+	}
+	addrs, err := resolver.LookupIPAddr(ctx, host)
+	if err != nil {
+		return nil, err
+	}
+	ips := make([]net.IP, 0, len(addrs))
+	for _, addr := range addrs {
+		ips = append(ips, addr.IP)
+	}
+	return ips, nil
+}
+
+// allowedNetworks returns the CIDR ranges of the user's organization, which
+// workers may connect to although they are private.
+func allowedNetworks(db *sql.DB, userID string) ([]string, error) {
+	orgID, err := getUserOrganizationID(db, userID)
+	if err != nil {
+		return nil, err
+	}
+	entries, err := getAllowlistEntries(db, orgID)
+	if err != nil {
+		return nil, err
+	}
+	var networks []string
+	for _, entry := range entries {
+		if entry.Kind == "cidr" {
+			networks = append(networks, entry.Value)
+		}
+	}
+	return networks, nil
+}
+
+// hostOverride returns the address network.hosts maps host to, if any.
+func hostOverride(network *models.NetworkConfig, host string) net.IP {
+	for name, addr := range network.Hosts {
+		if strings.EqualFold(name, host) {
+			return net.ParseIP(addr)
+		}
+	}
+	return nil
+}
+
+func containsIP(ips []net.IP, ip net.IP) bool {
+	for _, candidate := range ips {
+		if candidate.Equal(ip) {
+			return true
+		}
+	}
+	return false
+}
+
+func hostVerified(entries []models.AllowlistEntry, host string) bool {
+	for _, entry := range entries {
+		if entry.Kind == "host" && entry.Verified && entry.Value == host {
+			return true
+		}
+	}
+	return false
+}
+
+func cidrAllowed(entries []models.AllowlistEntry, ip net.IP) bool {
+	for _, entry := range entries {
+		if entry.Kind != "cidr" {
+			continue
+		}
+		_, network, err := net.ParseCIDR(entry.Value)
+		if err == nil && network.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
+
+func isPrivateIP(ip net.IP) bool {
+	return models.IsBlockedIP(ip)
+}
+
+func isPrivateNetwork(network *net.IPNet) bool {
+	ones, bits := network.Mask.Size()
+	for _, block := range privateBlocks {
+		blockOnes, blockBits := block.Mask.Size()
+		if bits == blockBits && ones >= blockOnes && block.Contains(network.IP) {
+			return true
+		}
+	}
+	return false
+}
+
+func generateVerificationToken() (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", fmt.Errorf("failed to generate verification token: %v", err)
+	}
+	return hex.EncodeToString(b), nil
+}
@@ -59,10 +59,28 @@ func (repo *SQLUserRepository) FindByEmail(email string) (*models.User, error) {
 	return &user, nil
 }
 
+// Create inserts the user together with a personal organization that owns
+// their target allowlist.
 func (repo *SQLUserRepository) Create(user *models.User) error {
-    err := repo.DB.QueryRow(`INSERT INTO users (username, email, password_hash) VALUES ($1, $2, $3) RETURNING id`, 
-        user.Username, user.Email, user.Password).Scan(&user.ID)
-    return err
+    tx, err := repo.DB.Begin()
+    if err != nil {
+        return err
+    }
+    defer tx.Rollback()
+
+    err = tx.QueryRow(`INSERT INTO organizations (name) VALUES ($1) RETURNING id`, user.Username).Scan(&user.OrganizationID)
+    if err != nil {
+        return err
+    }
+    err = tx.QueryRow(`INSERT INTO users (username, email, password_hash, organization_id) VALUES ($1, $2, $3, $4) RETURNING id`, 
+        user.Username, user.Email, user.Password, user.OrganizationID).Scan(&user.ID)
+    if err != nil {
+        return err
+    }
+    if _, err := tx.Exec(`UPDATE organizations SET created_by = $1 WHERE id = $2`, user.ID, user.OrganizationID); err != nil {
+        return err
+    }
+    return tx.Commit()
 }
 
 func (h *AuthHandler) Login(w http.ResponseWriter, r *http.Request) {
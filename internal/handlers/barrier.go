@@ -0,0 +1,122 @@
+package handlers
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/Vinayak9769/loadagg/internal/controller"
+	"github.com/Vinayak9769/loadagg/pkg/models"
+	"github.com/go-chi/chi/v5"
+)
+
+// startLeadTime gives every polling worker time to see the start instant
+// before it passes.
+const startLeadTime = 3 * time.Second
+
+type RegisterWorkerRequest struct {
+	WorkerIndex int    `json:"worker_index"`
+	PodName     string `json:"pod_name"`
+}
+
+// Register a worker at the start barrier /api/v1/loadtests/{id}/barrier
+// Workers call this repeatedly until start_at is set, then sleep until that
+// instant so every pod begins sending load at the same time. The start is
+// broadcast once all worker_count pods registered or start_timeout expired.
+func (h *LoadTestHandler) RegisterWorker(w http.ResponseWriter, r *http.Request) {
+	testID := chi.URLParam(r, "id")
+
+	if r.Context().Value("worker_test_id") != testID {
+		http.Error(w, http.StatusText(http.StatusForbidden), http.StatusForbidden)
+		return
+	}
+
+	var req RegisterWorkerRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+	if req.WorkerIndex < 0 {
+		http.Error(w, "worker_index must not be negative", http.StatusBadRequest)
+		return
+	}
+
+	query := `
+        INSERT INTO load_test_workers (test_id, worker_index, pod_name)
+        VALUES ($1, $2, $3)
+        ON CONFLICT (test_id, worker_index) DO UPDATE SET pod_name = EXCLUDED.pod_name
+    `
+	if _, err := h.db.Exec(query, testID, req.WorkerIndex, req.PodName); err != nil {
+		fmt.Printf("Failed to register worker %d for %s: %v\n", req.WorkerIndex, testID, err)
+		http.Error(w, "Failed to register worker", http.StatusInternalServerError)
+		return
+	}
+
+	barrier, err := h.getStartBarrier(testID)
+	if err != nil {
+		http.Error(w, "Start barrier not found", http.StatusNotFound)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(barrier)
+}
+
+// getStartBarrier returns the barrier state, fixing the start instant the
+// first time the barrier is found to be complete or past its deadline.
+func (h *LoadTestHandler) getStartBarrier(testID string) (*models.StartBarrier, error) {
+	query := `
+        SELECT c.worker_count, c.start_at, t.created_at, t.config,
+               (SELECT COUNT(*) FROM load_test_workers w WHERE w.test_id = c.test_id)
+        FROM load_test_controls c
+        JOIN load_tests t ON t.id = c.test_id
+        WHERE c.test_id = $1
+    `
+	barrier := &models.StartBarrier{TestID: testID}
+	var startAt sql.NullTime
+	var createdAt time.Time
+	var configJSON string
+
+	err := h.db.QueryRow(query, testID).Scan(
+		&barrier.Expected, &startAt, &createdAt, &configJSON, &barrier.Registered,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	var config models.LoadTestConfig
+	json.Unmarshal([]byte(configJSON), &config)
+	timeout := config.StartTimeout
+	if timeout <= 0 {
+		timeout = controller.DefaultStartTimeout
+	}
+	barrier.Deadline = createdAt.Add(time.Duration(timeout) * time.Second)
+
+	if !startAt.Valid && (barrier.Registered >= barrier.Expected || time.Now().After(barrier.Deadline)) {
+		proposed := time.Now().Add(startLeadTime).Truncate(time.Second)
+		res, err := h.db.Exec("UPDATE load_test_controls SET start_at = $1 WHERE test_id = $2 AND start_at IS NULL",
+			proposed, testID)
+		if err != nil {
+			return nil, err
+		}
+		// only the request that fixed the start instant annotates it
+		if n, _ := res.RowsAffected(); n == 1 {
+			message := fmt.Sprintf("Synchronized start with %d of %d workers ready", barrier.Registered, barrier.Expected)
+			if err := addAnnotation(h.db, testID, "start", message); err != nil {
+				fmt.Printf("Failed to annotate start of %s: %v\n", testID, err)
+			}
+		}
+
+		if err := h.db.QueryRow("SELECT start_at FROM load_test_controls WHERE test_id = $1", testID).Scan(&startAt); err != nil {
+			return nil, err
+		}
+	}
+
+	if startAt.Valid {
+		barrier.StartAt = &startAt.Time
+		barrier.StartAtUnix = startAt.Time.Unix()
+	}
+	return barrier, nil
+}
@@ -0,0 +1,547 @@
+package handlers
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/Vinayak9769/loadagg/internal/controller"
+	"github.com/Vinayak9769/loadagg/pkg/models"
+	"github.com/go-chi/chi/v5"
+)
+
+type UpdateRateRequest struct {
+	RequestsPerSec *int `json:"requests_per_sec,omitempty"`
+	MaxConcurrency *int `json:"max_concurrency,omitempty"`
+}
+
+// Adjust the rate of a running load test /api/v1/loadtests/{id}/rate
+// This endpoint changes the target RPS and/or VU count mid-run.
+// Workers pick the new values up from the control endpoint, and the change is
+// recorded as an annotation on the metrics timeline.
+func (h *LoadTestHandler) UpdateLoadTestRate(w http.ResponseWriter, r *http.Request) {
+	testID := chi.URLParam(r, "id")
+	userID := h.getUserIDFromContext(r)
+
+	var req UpdateRateRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+	if req.RequestsPerSec == nil && req.MaxConcurrency == nil {
+		http.Error(w, "requests_per_sec or max_concurrency is required", http.StatusBadRequest)
+		return
+	}
+	if req.RequestsPerSec != nil && *req.RequestsPerSec <= 0 {
+		http.Error(w, "requests_per_sec must be greater than 0", http.StatusBadRequest)
+		return
+	}
+	if req.MaxConcurrency != nil && *req.MaxConcurrency <= 0 {
+		http.Error(w, "max_concurrency must be greater than 0", http.StatusBadRequest)
+		return
+	}
+
+	test, err := h.getLoadTestFromDB(testID, userID)
+	if err != nil {
+		http.Error(w, "Load test not found", http.StatusNotFound)
+		return
+	}
+	if test.Status != "running" && test.Status != "paused" {
+		http.Error(w, "Load test is not running", http.StatusConflict)
+		return
+	}
+
+	err = h.updateLoadTestControl(testID, "rate_change", func(config *models.LoadTestConfig) (string, error) {
+		oldConfig := *config
+		var changes []string
+		if req.RequestsPerSec != nil && *req.RequestsPerSec != config.RequestsPerSec {
+			changes = append(changes, fmt.Sprintf("requests_per_sec %d -> %d", config.RequestsPerSec, *req.RequestsPerSec))
+			config.RequestsPerSec = *req.RequestsPerSec
+		}
+		if req.MaxConcurrency != nil && *req.MaxConcurrency != config.MaxConcurrency {
+			changes = append(changes, fmt.Sprintf("max_concurrency %d -> %d", config.MaxConcurrency, *req.MaxConcurrency))
+			config.MaxConcurrency = *req.MaxConcurrency
+		}
+		if len(changes) == 0 {
+			return "", nil
+		}
+
+		if config.RequestsPerSec < config.WorkerCount {
+			return "", &requestError{"requests_per_sec is the total rate and must be at least worker_count"}
+		}
+		if err := checkRateQuota(h.db, userID, oldConfig, *config); err != nil {
+			return "", err
+		}
+		return strings.Join(changes, ", "), nil
+	})
+	if err != nil {
+		status := controlErrorStatus(err)
+		if status != http.StatusInternalServerError {
+			http.Error(w, err.Error(), status)
+			return
+		}
+		fmt.Printf("Failed to update rate for %s: %v\n", testID, err)
+		http.Error(w, "Failed to update load test rate", status)
+		return
+	}
+
+	control, err := h.getLoadTestControl(testID)
+	if err != nil {
+		http.Error(w, "Failed to get load test control", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(control)
+}
+
+type ScaleWorkersRequest struct {
+	WorkerCount int `json:"worker_count"`
+}
+
+// Scale the worker pods of a running load test /api/v1/loadtests/{id}/workers
+// The total target rate is kept constant and redistributed across the new
+// worker set, which picks up its shares through the control endpoint.
+func (h *LoadTestHandler) ScaleLoadTestWorkers(w http.ResponseWriter, r *http.Request) {
+	testID := chi.URLParam(r, "id")
+	userID := h.getUserIDFromContext(r)
+
+	var req ScaleWorkersRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+	if req.WorkerCount <= 0 {
+		http.Error(w, "worker_count must be greater than 0", http.StatusBadRequest)
+		return
+	}
+
+	test, err := h.getLoadTestFromDB(testID, userID)
+	if err != nil {
+		http.Error(w, "Load test not found", http.StatusNotFound)
+		return
+	}
+	if test.Status != "running" && test.Status != "paused" {
+		http.Error(w, "Load test is not running", http.StatusConflict)
+		return
+	}
+
+	// the Job is patched while the control row is locked, so concurrent scale
+	// requests are applied one after the other
+	scaledFrom := 0
+	err = h.updateLoadTestControl(testID, "scale", func(config *models.LoadTestConfig) (string, error) {
+		if req.WorkerCount == config.WorkerCount {
+			return "", nil
+		}
+
+		quota, err := getUserQuota(h.db, userID)
+		if err != nil {
+			return "", fmt.Errorf("failed to check quota: %v", err)
+		}
+		if req.WorkerCount > quota.MaxWorkersPerTest {
+			return "", &policyError{fmt.Sprintf("worker_count %d exceeds quota of %d workers per test",
+				req.WorkerCount, quota.MaxWorkersPerTest)}
+		}
+		if config.RequestsPerSec < req.WorkerCount {
+			return "", &requestError{"worker_count cannot exceed the total requests_per_sec"}
+		}
+
+		if req.WorkerCount < config.WorkerCount {
+			// the pods removed by scaling down take their logs with them
+			h.recordWorkerMetrics(r.Context(), testID)
+		}
+
+		if err := h.controller.ScaleLoadTest(r.Context(), testID, req.WorkerCount); err != nil {
+			return "", err
+		}
+		scaledFrom = config.WorkerCount
+
+		message := fmt.Sprintf("worker_count %d -> %d, %d rps redistributed",
+			config.WorkerCount, req.WorkerCount, config.RequestsPerSec)
+		config.WorkerCount = req.WorkerCount
+		return message, nil
+	})
+	if err != nil {
+		status := controlErrorStatus(err)
+		if status != http.StatusInternalServerError {
+			http.Error(w, err.Error(), status)
+			return
+		}
+		fmt.Printf("Failed to scale load test %s: %v\n", testID, err)
+		if scaledFrom > 0 {
+			// put the Job back so its pod count matches the stored worker_count
+			if err := h.controller.ScaleLoadTest(context.Background(), testID, scaledFrom); err != nil {
+				fmt.Printf("Failed to roll back scaling of %s: %v\n", testID, err)
+			}
+		}
+		http.Error(w, "Failed to scale load test", status)
+		return
+	}
+
+	control, err := h.getLoadTestControl(testID)
+	if err != nil {
+		http.Error(w, "Failed to get load test control", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(control)
+}
+
+// Pause a running load test /api/v1/loadtests/{id}/pause
+// Workers stop sending requests but their pods keep running, so the test can
+// be resumed later. Paused time is excluded from elapsed time and RPS.
+func (h *LoadTestHandler) PauseLoadTest(w http.ResponseWriter, r *http.Request) {
+	h.setPauseState(w, r, "running", "paused")
+}
+
+// Resume a paused load test /api/v1/loadtests/{id}/resume
+func (h *LoadTestHandler) ResumeLoadTest(w http.ResponseWriter, r *http.Request) {
+	h.setPauseState(w, r, "paused", "running")
+}
+
+func (h *LoadTestHandler) setPauseState(w http.ResponseWriter, r *http.Request, from, to string) {
+	testID := chi.URLParam(r, "id")
+	userID := h.getUserIDFromContext(r)
+
+	test, err := h.getLoadTestFromDB(testID, userID)
+	if err != nil {
+		http.Error(w, "Load test not found", http.StatusNotFound)
+		return
+	}
+	if test.Status != from {
+		http.Error(w, fmt.Sprintf("Load test is %s, expected %s", test.Status, from), http.StatusConflict)
+		return
+	}
+
+	if to == "running" {
+		if err := checkResumeQuota(h.db, userID); err != nil {
+			status := http.StatusInternalServerError
+			if _, ok := err.(*policyError); ok {
+				status = http.StatusForbidden
+			}
+			http.Error(w, err.Error(), status)
+			return
+		}
+	}
+
+	if err := h.updatePauseState(testID, to); err != nil {
+		fmt.Printf("Failed to set %s state for %s: %v\n", to, testID, err)
+		http.Error(w, "Failed to update load test state", http.StatusInternalServerError)
+		return
+	}
+
+	control, err := h.getLoadTestControl(testID)
+	if err != nil {
+		http.Error(w, "Failed to get load test control", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(control)
+}
+
+// Control state for worker pods /api/v1/loadtests/{id}/control
+// Workers poll this endpoint with their WORKER_TOKEN and apply any change
+// whose version is newer than the one they are running with.
+func (h *LoadTestHandler) GetWorkerControl(w http.ResponseWriter, r *http.Request) {
+	testID := chi.URLParam(r, "id")
+
+	if r.Context().Value("worker_test_id") != testID {
+		http.Error(w, http.StatusText(http.StatusForbidden), http.StatusForbidden)
+		return
+	}
+
+	control, err := h.getLoadTestControl(testID)
+	if err != nil {
+		http.Error(w, "Load test control not found", http.StatusNotFound)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(control)
+}
+
+func saveLoadTestControl(db sqlExecer, test *models.LoadTest) error {
+	query := `
+        INSERT INTO load_test_controls (test_id, requests_per_sec, worker_count, max_concurrency)
+        VALUES ($1, $2, $3, $4)
+    `
+	_, err := db.Exec(query, test.ID, test.Config.RequestsPerSec, test.Config.WorkerCount, test.Config.MaxConcurrency)
+	return err
+}
+
+func (h *LoadTestHandler) getLoadTestControl(testID string) (*models.LoadTestControl, error) {
+	query := `
+        SELECT test_id, requests_per_sec, worker_count, max_concurrency, state, paused_at, paused_seconds,
+               start_at, version, updated_at
+        FROM load_test_controls
+        WHERE test_id = $1
+    `
+	var control models.LoadTestControl
+	var pausedAt, startAt sql.NullTime
+	err := h.db.QueryRow(query, testID).Scan(
+		&control.TestID, &control.RequestsPerSec, &control.WorkerCount, &control.MaxConcurrency,
+		&control.State, &pausedAt, &control.PausedSeconds,
+		&startAt, &control.Version, &control.UpdatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	if pausedAt.Valid {
+		control.PausedAt = &pausedAt.Time
+	}
+	if startAt.Valid {
+		control.StartAt = &startAt.Time
+	}
+	control.WorkerRates = controller.SplitRate(control.RequestsPerSec, control.WorkerCount)
+	control.WorkerConcurrency = controller.SplitRate(control.MaxConcurrency, control.WorkerCount)
+	return &control, nil
+}
+
+// updatePauseState moves the test between running and paused, folding the
+// length of a finished pause into paused_seconds.
+func (h *LoadTestHandler) updatePauseState(testID, state string) error {
+	tx, err := h.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	var query string
+	if state == "paused" {
+		query = `
+            UPDATE load_test_controls
+            SET state = 'paused', paused_at = CURRENT_TIMESTAMP,
+                version = version + 1, updated_at = CURRENT_TIMESTAMP
+            WHERE test_id = $1
+        `
+	} else {
+		query = `
+            UPDATE load_test_controls
+            SET state = 'running',
+                paused_seconds = paused_seconds + COALESCE(EXTRACT(EPOCH FROM (CURRENT_TIMESTAMP - paused_at)), 0),
+                paused_at = NULL,
+                version = version + 1, updated_at = CURRENT_TIMESTAMP
+            WHERE test_id = $1
+        `
+	}
+	res, err := tx.Exec(query, testID)
+	if err != nil {
+		return err
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return sql.ErrNoRows
+	}
+
+	if _, err := tx.Exec("UPDATE load_tests SET status = $1 WHERE id = $2", state, testID); err != nil {
+		return err
+	}
+
+	annotationType, message := "pause", "Load test paused"
+	if state == "running" {
+		annotationType, message = "resume", "Load test resumed"
+	}
+	if err := addAnnotation(tx, testID, annotationType, message); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// getMetricsTiming reports the synchronized start instant and how long the
+// test has spent paused, including a pause that is still in progress or that
+// lasted until the test ended.
+func (h *LoadTestHandler) getMetricsTiming(testID string) controller.MetricsTiming {
+	var timing controller.MetricsTiming
+
+	query := `
+        SELECT c.start_at,
+               c.paused_seconds + COALESCE(EXTRACT(EPOCH FROM (COALESCE(t.completed_at, NOW()) - c.paused_at)), 0)
+        FROM load_test_controls c
+        JOIN load_tests t ON t.id = c.test_id
+        WHERE c.test_id = $1
+    `
+	var startAt sql.NullTime
+	var paused float64
+	if err := h.db.QueryRow(query, testID).Scan(&startAt, &paused); err != nil {
+		return timing
+	}
+
+	if startAt.Valid {
+		timing.StartedAt = &startAt.Time
+	}
+	timing.Paused = time.Duration(paused * float64(time.Second))
+	return timing
+}
+
+// recordWorkerMetrics stores the metrics each worker pod of the test last
+// logged, so workers keep counting once their pods are gone.
+func (h *LoadTestHandler) recordWorkerMetrics(ctx context.Context, testID string) {
+	snapshots, err := h.controller.ScrapeWorkers(ctx, testID)
+	if err != nil {
+		fmt.Printf("Failed to scrape worker metrics of %s: %v\n", testID, err)
+		return
+	}
+
+	query := `
+        INSERT INTO worker_metrics (test_id, pod_name, metrics, scraped_at)
+        VALUES ($1, $2, $3, $4)
+        ON CONFLICT (test_id, pod_name) DO UPDATE
+        SET metrics = EXCLUDED.metrics, scraped_at = EXCLUDED.scraped_at
+    `
+	for _, snapshot := range snapshots {
+		metricsJSON, err := json.Marshal(snapshot.Metrics)
+		if err != nil {
+			continue
+		}
+		if _, err := h.db.Exec(query, testID, snapshot.PodName, string(metricsJSON), snapshot.ScrapedAt); err != nil {
+			fmt.Printf("Failed to store metrics of pod %s: %v\n", snapshot.PodName, err)
+		}
+	}
+}
+
+// getWorkerHistory returns the stored metrics of the test's workers.
+func (h *LoadTestHandler) getWorkerHistory(testID string) []controller.WorkerSnapshot {
+	rows, err := h.db.Query("SELECT pod_name, metrics, scraped_at FROM worker_metrics WHERE test_id = $1", testID)
+	if err != nil {
+		fmt.Printf("Error getting worker metrics for %s: %v\n", testID, err)
+		return nil
+	}
+	defer rows.Close()
+
+	var history []controller.WorkerSnapshot
+	for rows.Next() {
+		var snapshot controller.WorkerSnapshot
+		var metricsJSON string
+		if err := rows.Scan(&snapshot.PodName, &metricsJSON, &snapshot.ScrapedAt); err != nil {
+			continue
+		}
+		if err := json.Unmarshal([]byte(metricsJSON), &snapshot.Metrics); err != nil {
+			continue
+		}
+		history = append(history, snapshot)
+	}
+	return history
+}
+
+// updateLoadTestControl locks the stored config, lets change modify it and, if
+// change returns an annotation message, stores the result, bumps the control
+// version and annotates the change in the same transaction. Concurrent updates
+// therefore always apply to the latest config instead of overwriting it.
+func (h *LoadTestHandler) updateLoadTestControl(testID, annotationType string, change func(config *models.LoadTestConfig) (string, error)) error {
+	tx, err := h.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	var storedJSON string
+	if err := tx.QueryRow("SELECT config FROM load_tests WHERE id = $1 FOR UPDATE", testID).Scan(&storedJSON); err != nil {
+		return err
+	}
+	var config models.LoadTestConfig
+	if err := json.Unmarshal([]byte(storedJSON), &config); err != nil {
+		return err
+	}
+
+	message, err := change(&config)
+	if err != nil || message == "" {
+		return err
+	}
+
+	configJSON, err := json.Marshal(config)
+	if err != nil {
+		return err
+	}
+
+	if _, err := tx.Exec("UPDATE load_tests SET config = $1 WHERE id = $2", string(configJSON), testID); err != nil {
+		return err
+	}
+
+	query := `
+        INSERT INTO load_test_controls (test_id, requests_per_sec, worker_count, max_concurrency)
+        VALUES ($1, $2, $3, $4)
+        ON CONFLICT (test_id) DO UPDATE
+        SET requests_per_sec = EXCLUDED.requests_per_sec,
+            worker_count = EXCLUDED.worker_count,
+            max_concurrency = EXCLUDED.max_concurrency,
+            version = load_test_controls.version + 1,
+            updated_at = CURRENT_TIMESTAMP
+    `
+	if _, err := tx.Exec(query, testID, config.RequestsPerSec, config.WorkerCount, config.MaxConcurrency); err != nil {
+		return err
+	}
+
+	if err := addAnnotation(tx, testID, annotationType, message); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// requestError is returned by a control change that the request makes invalid
+// against the current config, so the handler can answer 400.
+type requestError struct {
+	msg string
+}
+
+func (e *requestError) Error() string {
+	return e.msg
+}
+
+// controlErrorStatus maps an error from updateLoadTestControl to a response code.
+func controlErrorStatus(err error) int {
+	switch err.(type) {
+	case *requestError:
+		return http.StatusBadRequest
+	case *policyError:
+		return http.StatusForbidden
+	}
+	return http.StatusInternalServerError
+}
+
+type sqlExecer interface {
+	Exec(query string, args ...any) (sql.Result, error)
+}
+
+// sqlQueryer is satisfied by both *sql.DB and *sql.Tx.
+type sqlQueryer interface {
+	QueryRow(query string, args ...any) *sql.Row
+	Query(query string, args ...any) (*sql.Rows, error)
+}
+
+func addAnnotation(db sqlExecer, testID, annotationType, message string) error {
+	query := "INSERT INTO load_test_annotations (test_id, type, message) VALUES ($1, $2, $3)"
+	_, err := db.Exec(query, testID, annotationType, message)
+	return err
+}
+
+func (h *LoadTestHandler) getAnnotations(testID string) []models.MetricsAnnotation {
+	query := `
+        SELECT created_at, type, message
+        FROM load_test_annotations
+        WHERE test_id = $1
+        ORDER BY created_at
+    `
+	rows, err := h.db.Query(query, testID)
+	if err != nil {
+		fmt.Printf("Error getting annotations for %s: %v\n", testID, err)
+		return nil
+	}
+	defer rows.Close()
+
+	var annotations []models.MetricsAnnotation
+	for rows.Next() {
+		var a models.MetricsAnnotation
+		if err := rows.Scan(&a.Timestamp, &a.Type, &a.Message); err != nil {
+			continue
+		}
+		annotations = append(annotations, a)
+	}
+	return annotations
+}
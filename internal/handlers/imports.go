@@ -0,0 +1,169 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+
+	"github.com/Vinayak9769/loadagg/internal/auth"
+	"github.com/Vinayak9769/loadagg/internal/importer"
+	"github.com/Vinayak9769/loadagg/pkg/models"
+	"github.com/go-chi/chi/v5"
+)
+
+// maxImportSize bounds uploaded recordings and specs.
+const maxImportSize = 32 << 20
+
+// ImportHandler drafts load tests from existing recordings and specs. Drafts
+// are returned for review; nothing is stored until they are posted to
+// /api/v1/loadtests.
+type ImportHandler struct{}
+
+func NewImportHandler() *ImportHandler {
+	return &ImportHandler{}
+}
+
+func (h *ImportHandler) Routes() chi.Router {
+	r := chi.NewRouter()
+
+	r.Group(func(r chi.Router) {
+		r.Use(auth.JWTMiddleware)
+
+		r.Post("/har", h.ImportHAR)
+		r.Post("/openapi", h.ImportOpenAPI)
+		r.Post("/curl", h.ImportCurl)
+	})
+
+	return r
+}
+
+// Draft a test from a HAR recording /api/v1/imports/har?host=<host>&static=include
+// The body is the HAR file. host may be repeated or comma separated to keep
+// only those hosts; static assets are dropped unless static=include.
+func (h *ImportHandler) ImportHAR(w http.ResponseWriter, r *http.Request) {
+	data, ok := readImport(w, r)
+	if !ok {
+		return
+	}
+
+	var hosts []string
+	for _, value := range r.URL.Query()["host"] {
+		for _, host := range strings.Split(value, ",") {
+			if host = strings.TrimSpace(host); host != "" {
+				hosts = append(hosts, host)
+			}
+		}
+	}
+
+	result, err := importer.HAR(data, importer.HAROptions{
+		Hosts:         hosts,
+		IncludeStatic: r.URL.Query().Get("static") == "include",
+	})
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(result)
+}
+
+type OpenAPIImportRequest struct {
+	// Spec is the OpenAPI 3 document, in JSON or YAML.
+	Spec    string `json:"spec"`
+	BaseURL string `json:"base_url,omitempty"`
+	// Operations picks operations by id with their weight; all when empty.
+	Operations []importer.OperationSelection `json:"operations,omitempty"`
+	Feeder     *models.Feeder                `json:"feeder,omitempty"`
+}
+
+type OpenAPIImportResponse struct {
+	*models.ImportResult
+	// Operations lists everything in the spec, to choose the mix from.
+	Operations []importer.Operation `json:"operations"`
+}
+
+// Draft an endpoint mix from an OpenAPI spec /api/v1/imports/openapi
+func (h *ImportHandler) ImportOpenAPI(w http.ResponseWriter, r *http.Request) {
+	data, ok := readImport(w, r)
+	if !ok {
+		return
+	}
+
+	var req OpenAPIImportRequest
+	if err := json.Unmarshal(data, &req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+	if req.Spec == "" {
+		http.Error(w, "spec is required", http.StatusBadRequest)
+		return
+	}
+	if err := validateFeeder(req.Feeder); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	result, operations, err := importer.OpenAPI([]byte(req.Spec), importer.OpenAPIOptions{
+		BaseURL: req.BaseURL,
+		Select:  req.Operations,
+		Feeder:  req.Feeder,
+	})
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(OpenAPIImportResponse{ImportResult: result, Operations: operations})
+}
+
+type CurlImportRequest struct {
+	Command string `json:"command"`
+	// Files holds the contents of files the command reads with @file.
+	Files map[string]string `json:"files,omitempty"`
+}
+
+// Draft a test from a curl command line /api/v1/imports/curl
+func (h *ImportHandler) ImportCurl(w http.ResponseWriter, r *http.Request) {
+	data, ok := readImport(w, r)
+	if !ok {
+		return
+	}
+
+	var req CurlImportRequest
+	if err := json.Unmarshal(data, &req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+	if strings.TrimSpace(req.Command) == "" {
+		http.Error(w, "command is required", http.StatusBadRequest)
+		return
+	}
+
+	result, err := importer.Curl(req.Command, req.Files)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(result)
+}
+
+// readImport reads an uploaded source, answering the request itself when
+// that fails.
+func readImport(w http.ResponseWriter, r *http.Request) ([]byte, bool) {
+	data, err := io.ReadAll(http.MaxBytesReader(w, r.Body, maxImportSize))
+	if err != nil {
+		http.Error(w, fmt.Sprintf("Upload must be at most %d bytes", maxImportSize), http.StatusRequestEntityTooLarge)
+		return nil, false
+	}
+	if len(data) == 0 {
+		http.Error(w, "Request body is empty", http.StatusBadRequest)
+		return nil, false
+	}
+	return data, true
+}
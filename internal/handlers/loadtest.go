@@ -5,11 +5,15 @@ import (
 	"database/sql"
 	"encoding/json"
 	"fmt"
+	"net"
 	"net/http"
+	"net/url"
+	"strings"
 	"time"
 
 	"github.com/Vinayak9769/loadagg/internal/auth"
 	"github.com/Vinayak9769/loadagg/internal/controller"
+	"github.com/Vinayak9769/loadagg/internal/secrets"
 	"github.com/Vinayak9769/loadagg/pkg/models"
 	"github.com/go-chi/chi/v5"
 	"github.com/golang-jwt/jwt/v5"
@@ -18,12 +22,14 @@ import (
 type LoadTestHandler struct {
 	db         *sql.DB
 	controller *controller.LoadTestController
+	sealer     *secrets.Sealer // nil when the server has no secrets key
 }
 
-func NewLoadTestHandler(db *sql.DB, controller *controller.LoadTestController) *LoadTestHandler {
+func NewLoadTestHandler(db *sql.DB, controller *controller.LoadTestController, sealer *secrets.Sealer) *LoadTestHandler {
 	handler := &LoadTestHandler{
 		db:         db,
 		controller: controller,
+		sealer:     sealer,
 	}
 	go handler.startJobMonitor()
 	return handler
@@ -43,6 +49,19 @@ func (h *LoadTestHandler) Routes() chi.Router {
 		r.Delete("/{id}", h.StopLoadTest)
 		r.Post("/{id}/stop", h.StopLoadTest)
 		r.Post("/cleanup", h.CleanupJobs)
+		r.Patch("/{id}/rate", h.UpdateLoadTestRate)
+		r.Patch("/{id}/workers", h.ScaleLoadTestWorkers)
+		r.Post("/{id}/pause", h.PauseLoadTest)
+		r.Post("/{id}/resume", h.ResumeLoadTest)
+	})
+	// worker pods authenticate with their test-scoped token
+	r.Group(func(r chi.Router) {
+		r.Use(auth.WorkerMiddleware)
+
+		r.Get("/{id}/control", h.GetWorkerControl)
+		r.Post("/{id}/barrier", h.RegisterWorker)
+		r.Get("/{id}/protoset", h.GetWorkerProtoset)
+		r.Get("/{id}/replay", h.GetWorkerReplay)
 	})
 	//seperate from auth headers
 	r.Get("/{id}/metrics/stream", h.StreamMetrics)
@@ -62,13 +81,17 @@ func (h *LoadTestHandler) CreateLoadTest(w http.ResponseWriter, r *http.Request)
 		return
 	}
 
-	if err := h.validateCreateRequest(&req); err != nil {
-		http.Error(w, err.Error(), http.StatusBadRequest)
+	userID := h.getUserIDFromContext(r)
+
+	if err := h.validateCreateRequest(&req, userID); err != nil {
+		status := http.StatusBadRequest
+		if _, ok := err.(*policyError); ok {
+			status = http.StatusForbidden
+		}
+		http.Error(w, err.Error(), status)
 		return
 	}
 
-	userID := h.getUserIDFromContext(r)
-
 	test := &models.LoadTest{
 		ID:        generateTestID(),
 		Name:      req.Name,
@@ -79,12 +102,32 @@ func (h *LoadTestHandler) CreateLoadTest(w http.ResponseWriter, r *http.Request)
 		CreatedAt: time.Now(),
 	}
 
-	if err := h.saveLoadTestToDB(test); err != nil {
+	if err := h.saveLoadTest(test); err != nil {
+		if _, ok := err.(*policyError); ok {
+			http.Error(w, err.Error(), http.StatusForbidden)
+			return
+		}
 		http.Error(w, "Failed to save load test", http.StatusInternalServerError)
 		return
 	}
 
-	if err := h.controller.StartLoadTest(r.Context(), test); err != nil {
+	secretValues, err := openSecrets(h.db, h.sealer, userID, secrets.ConfigReferences(test.Config))
+	if err != nil {
+		fmt.Printf("Failed to open secrets of load test %s: %v\n", test.ID, err)
+		h.updateLoadTestStatus(test.ID, "failed")
+		http.Error(w, "Failed to start load test", http.StatusInternalServerError)
+		return
+	}
+
+	networks, err := allowedNetworks(h.db, userID)
+	if err != nil {
+		fmt.Printf("Failed to load allowlist of load test %s: %v\n", test.ID, err)
+		h.updateLoadTestStatus(test.ID, "failed")
+		http.Error(w, "Failed to start load test", http.StatusInternalServerError)
+		return
+	}
+
+	if err := h.controller.StartLoadTest(r.Context(), test, secretValues, networks); err != nil {
 		fmt.Printf("Failed to start load test: %v\n", err)
 		h.updateLoadTestStatus(test.ID, "failed")
 		http.Error(w, "Failed to start load test", http.StatusInternalServerError)
@@ -92,6 +135,7 @@ func (h *LoadTestHandler) CreateLoadTest(w http.ResponseWriter, r *http.Request)
 	}
 
 	h.updateLoadTestStatus(test.ID, "running")
+	h.setStartTime(test.ID)
 
 	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(http.StatusCreated)
@@ -150,6 +194,14 @@ func (h *LoadTestHandler) GetLoadTestStatus(w http.ResponseWriter, r *http.Reque
 		return
 	}
 
+	if control, err := h.getLoadTestControl(testID); err == nil {
+		status.TargetRPS = control.RequestsPerSec
+		status.SynchronizedStart = control.StartAt
+		for i, rate := range control.WorkerRates {
+			status.WorkerTargets = append(status.WorkerTargets, models.WorkerTarget{Index: i, RequestsPerSec: rate})
+		}
+	}
+
 	w.Header().Set("Content-Type", "application/json")
 	json.NewEncoder(w).Encode(status)
 }
@@ -171,6 +223,7 @@ func (h *LoadTestHandler) StopLoadTest(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 	h.updateLoadTestStatus(testID, "stopped")
+	h.setCompletionTime(testID)
 
 	w.Header().Set("Content-Type", "application/json")
 	json.NewEncoder(w).Encode(map[string]string{"message": "Load test stopped successfully"})
@@ -210,11 +263,12 @@ func (h *LoadTestHandler) GetLoadTestMetrics(w http.ResponseWriter, r *http.Requ
 		return
 	}
 
-	metrics, err := h.controller.GetLoadTestMetrics(r.Context(), testID)
+	metrics, err := h.controller.GetLoadTestMetrics(r.Context(), testID, h.getMetricsTiming(testID), h.getWorkerHistory(testID))
 	if err != nil {
 		http.Error(w, "Failed to get load test metrics", http.StatusInternalServerError)
 		return
 	}
+	metrics.Annotations = h.getAnnotations(testID)
 
 	w.Header().Set("Content-Type", "application/json")
 	json.NewEncoder(w).Encode(metrics)
@@ -253,7 +307,11 @@ func (h *LoadTestHandler) StreamMetrics(w http.ResponseWriter, r *http.Request)
     w.Header().Set("Connection", "keep-alive")
     w.Header().Set("X-Accel-Buffering", "no") // Disable proxy buffering
 
-    metricsChan, err := h.controller.StreamLoadTestMetrics(r.Context(), testID)
+    metricsChan, err := h.controller.StreamLoadTestMetrics(r.Context(), testID, func() controller.MetricsTiming {
+        return h.getMetricsTiming(testID)
+    }, func() []controller.WorkerSnapshot {
+        return h.getWorkerHistory(testID)
+    })
     if err != nil {
         http.Error(w, "Failed to start metrics stream", http.StatusInternalServerError)
         return
@@ -271,6 +329,7 @@ func (h *LoadTestHandler) StreamMetrics(w http.ResponseWriter, r *http.Request)
             if !ok {
                 return // closed
             }
+            metrics.Annotations = h.getAnnotations(testID)
 
             data, err := json.Marshal(metrics)
             if err != nil {
@@ -369,7 +428,7 @@ func (h *LoadTestHandler) getUserIDFromContext(r *http.Request) string {
 	return claims["ID"].(string)
 }
 
-func (h *LoadTestHandler) validateCreateRequest(req *CreateLoadTestRequest) error {
+func (h *LoadTestHandler) validateCreateRequest(req *CreateLoadTestRequest, userID string) error {
 	if req.Name == "" {
 		return fmt.Errorf("name is required")
 	}
@@ -382,21 +441,471 @@ func (h *LoadTestHandler) validateCreateRequest(req *CreateLoadTestRequest) erro
 	if req.Config.RequestsPerSec <= 0 {
 		return fmt.Errorf("requests_per_sec must be greater than 0")
 	}
+	if req.Config.RequestsPerSec < req.Config.WorkerCount {
+		return fmt.Errorf("requests_per_sec is the total rate and must be at least worker_count")
+	}
+	if req.Config.Duration <= 0 {
+		return fmt.Errorf("duration must be greater than 0")
+	}
+	if err := validateTarget(h.db, userID, req); err != nil {
+		return err
+	}
+	if err := validateNetworkConfig(req.Config); err != nil {
+		return err
+	}
+	if err := checkTargetAllowed(h.db, userID, req.TargetURL, req.Config.Network); err != nil {
+		return err
+	}
+	checked := map[string]bool{}
+	for _, step := range append(req.Config.Steps, req.Config.Endpoints...) {
+		u, _ := url.Parse(step.URL)
+		if checked[u.Host] {
+			continue
+		}
+		checked[u.Host] = true
+		if err := checkTargetAllowed(h.db, userID, step.URL, req.Config.Network); err != nil {
+			return err
+		}
+	}
+	if err := checkNetworkAllowed(h.db, userID, req.Config.Network); err != nil {
+		return err
+	}
+	if err := validateTLSConfig(req.Config.TLS); err != nil {
+		return err
+	}
+	if err := checkSecretsExist(h.db, h.sealer, userID, secrets.ConfigReferences(req.Config)); err != nil {
+		return err
+	}
+	return checkTLSSecrets(h.db, h.sealer, userID, req.Config.TLS)
+}
+
+// validateTarget checks the target type specific part of the config.
+func validateTarget(db *sql.DB, userID string, req *CreateLoadTestRequest) error {
+	u, err := url.Parse(req.TargetURL)
+	if err != nil {
+		return fmt.Errorf("invalid target_url: %v", err)
+	}
+
+	if len(req.Config.Steps) > 0 && req.Config.TargetType != "" && req.Config.TargetType != models.TargetHTTP {
+		return fmt.Errorf("steps are only supported for http targets")
+	}
+	if len(req.Config.Endpoints) > 0 && req.Config.TargetType != "" && req.Config.TargetType != models.TargetHTTP {
+		return fmt.Errorf("endpoints are only supported for http targets")
+	}
+	if err := validateFeeder(req.Config.Feeder); err != nil {
+		return err
+	}
+	if err := validateVUs(req.Config); err != nil {
+		return err
+	}
+	if cookies := req.Config.Cookies; cookies != nil {
+		if req.Config.TargetType != "" && req.Config.TargetType != models.TargetHTTP {
+			return fmt.Errorf("cookies are only supported for http targets")
+		}
+		for i, c := range cookies.Initial {
+			if c.Name == "" || strings.ContainsAny(c.Name, "=; \t") {
+				return fmt.Errorf("cookies.initial[%d] needs a name without '=', ';' or spaces", i)
+			}
+		}
+	}
+	if req.Config.Replay != nil {
+		if req.Config.TargetType != "" && req.Config.TargetType != models.TargetHTTP {
+			return fmt.Errorf("replay is only supported for http targets")
+		}
+		if err := validateReplayConfig(db, userID, req.Config); err != nil {
+			return err
+		}
+	}
+
+	switch req.Config.TargetType {
+	case "", models.TargetHTTP:
+		if u.Scheme != "http" && u.Scheme != "https" {
+			return fmt.Errorf("http targets need an http:// or https:// target_url")
+		}
+		if err := validateSteps(req.Config.Steps); err != nil {
+			return err
+		}
+		if err := validateEndpoints(req.Config.Endpoints, len(req.Config.Steps) > 0); err != nil {
+			return err
+		}
+		// steps and endpoints are sent with the same protocol
+		for _, specs := range [][]models.RequestSpec{req.Config.Steps, req.Config.Endpoints} {
+			for _, spec := range specs {
+				if err := validateProtocol(req.Config.Protocol, spec.URL); err != nil {
+					return err
+				}
+			}
+		}
+		return validateProtocol(req.Config.Protocol, req.TargetURL)
+	case models.TargetGRPC:
+		if u.Scheme != "grpc" && u.Scheme != "grpcs" {
+			return fmt.Errorf("grpc targets need a grpc:// (plaintext) or grpcs:// (TLS) target_url")
+		}
+		return validateGRPCConfig(db, userID, req.Config.GRPC)
+	case models.TargetWebSocket:
+		if u.Scheme != "ws" && u.Scheme != "wss" {
+			return fmt.Errorf("websocket targets need a ws:// or wss:// target_url")
+		}
+		if req.Config.WebSocket == nil || len(req.Config.WebSocket.Messages) == 0 {
+			return fmt.Errorf("websocket.messages must contain at least one message")
+		}
+		return nil
+	case models.TargetTCP, models.TargetUDP:
+		if u.Scheme != req.Config.TargetType || u.Port() == "" {
+			return fmt.Errorf("%s targets need a %s://host:port target_url", req.Config.TargetType, req.Config.TargetType)
+		}
+		return validateSocketConfig(req.Config.TargetType, req.Config.Socket)
+	case models.TargetDNS:
+		if u.Scheme != "dns" {
+			return fmt.Errorf("dns targets need a dns://resolver[:port] target_url")
+		}
+		return validateDNSConfig(req.Config.DNS)
+	case models.TargetSSE, models.TargetLongPoll:
+		if u.Scheme != "http" && u.Scheme != "https" {
+			return fmt.Errorf("%s targets need an http:// or https:// target_url", req.Config.TargetType)
+		}
+		if stream := req.Config.Stream; stream != nil && (stream.ReconnectDelayMS < 0 || stream.PollTimeout < 0) {
+			return fmt.Errorf("stream.reconnect_delay_ms and stream.poll_timeout must not be negative")
+		}
+		return validateProtocol(req.Config.Protocol, req.TargetURL)
+	default:
+		return fmt.Errorf("target_type must be one of http, grpc, websocket, tcp, udp, dns, sse, long_poll")
+	}
+}
+
+// maxSteps bounds multi-step tests, whose steps reach the workers in an
+// environment variable.
+const maxSteps = 500
+
+// validateSteps checks the requests of a multi-step http test.
+func validateSteps(steps []models.RequestSpec) error {
+	if len(steps) > maxSteps {
+		return fmt.Errorf("steps may contain at most %d requests", maxSteps)
+	}
+	for i, step := range steps {
+		if step.Method == "" {
+			return fmt.Errorf("steps[%d].method is required", i)
+		}
+		u, err := url.Parse(step.URL)
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			return fmt.Errorf("steps[%d].url must be an absolute http or https URL", i)
+		}
+		if step.ThinkTimeMS < 0 {
+			return fmt.Errorf("steps[%d].think_time_ms must not be negative", i)
+		}
+	}
+	return nil
+}
+
+// validateEndpoints checks the weighted requests of an endpoint mix.
+func validateEndpoints(endpoints []models.RequestSpec, hasSteps bool) error {
+	if len(endpoints) == 0 {
+		return nil
+	}
+	if hasSteps {
+		return fmt.Errorf("steps and endpoints are mutually exclusive")
+	}
+	if len(endpoints) > maxSteps {
+		return fmt.Errorf("endpoints may contain at most %d requests", maxSteps)
+	}
+	total := 0
+	names := make(map[string]bool)
+	for i, endpoint := range endpoints {
+		if endpoint.Method == "" {
+			return fmt.Errorf("endpoints[%d].method is required", i)
+		}
+		// metrics are reported per name
+		if endpoint.Name != "" {
+			if names[endpoint.Name] {
+				return fmt.Errorf("endpoints[%d].name %q is used twice", i, endpoint.Name)
+			}
+			names[endpoint.Name] = true
+		}
+		u, err := url.Parse(endpoint.URL)
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			return fmt.Errorf("endpoints[%d].url must be an absolute http or https URL", i)
+		}
+		if endpoint.Weight < 0 {
+			return fmt.Errorf("endpoints[%d].weight must not be negative", i)
+		}
+		total += endpoint.Weight
+	}
+	if total == 0 {
+		return fmt.Errorf("at least one endpoint needs a weight greater than 0")
+	}
 	return nil
 }
 
+// maxFeederBytes keeps a feeder within what fits into a worker's
+// environment.
+const maxFeederBytes = 96 << 10
+
+// validateFeeder checks that every feeder row has a value for each column.
+func validateFeeder(feeder *models.Feeder) error {
+	if feeder == nil {
+		return nil
+	}
+	if len(feeder.Columns) == 0 || len(feeder.Rows) == 0 {
+		return fmt.Errorf("feeder needs columns and at least one row")
+	}
+	size := 0
+	for i, row := range feeder.Rows {
+		if len(row) != len(feeder.Columns) {
+			return fmt.Errorf("feeder.rows[%d] has %d values for %d columns", i, len(row), len(feeder.Columns))
+		}
+		for _, value := range row {
+			size += len(value) + 4
+		}
+	}
+	if size > maxFeederBytes {
+		return fmt.Errorf("feeder may hold at most %d KiB of data", maxFeederBytes>>10)
+	}
+	return nil
+}
+
+// validateVUs checks the executor and the think time and pacing of its VUs.
+func validateVUs(config models.LoadTestConfig) error {
+	switch config.Executor {
+	case "", models.ExecutorArrivalRate:
+	case models.ExecutorVUs:
+		if config.Replay != nil {
+			return fmt.Errorf("replay runs on its own schedule and cannot use the vus executor")
+		}
+		if config.TargetType == models.TargetSSE || config.TargetType == models.TargetLongPoll {
+			return fmt.Errorf("%s targets hold one subscription per VU and cannot use the vus executor", config.TargetType)
+		}
+	default:
+		return fmt.Errorf("executor must be one of arrival_rate, vus")
+	}
+	if config.PacingMS < 0 {
+		return fmt.Errorf("pacing_ms must not be negative")
+	}
+
+	t := config.ThinkTime
+	if t == nil {
+		return nil
+	}
+	if t.MS < 0 || t.MinMS < 0 || t.MaxMS < 0 || t.StdDevMS < 0 {
+		return fmt.Errorf("think_time values must not be negative")
+	}
+	if t.MaxMS > 0 && t.MaxMS < t.MinMS {
+		return fmt.Errorf("think_time.max_ms must be at least min_ms")
+	}
+	switch t.Distribution {
+	case "", models.ThinkFixed, models.ThinkExponential:
+	case models.ThinkNormal:
+		if t.StdDevMS == 0 {
+			return fmt.Errorf("a normal think_time needs stddev_ms")
+		}
+	case models.ThinkUniform:
+		if t.MaxMS == 0 {
+			return fmt.Errorf("a uniform think_time needs max_ms")
+		}
+	default:
+		return fmt.Errorf("think_time.distribution must be one of fixed, uniform, normal, exponential")
+	}
+	return nil
+}
+
+// validateSocketConfig checks the payload of a tcp or udp test and that it
+// only asks for the response handling its transport supports.
+func validateSocketConfig(targetType string, config *models.SocketConfig) error {
+	if config == nil || config.Payload == "" {
+		return fmt.Errorf("socket.payload is required for %s targets", targetType)
+	}
+	if _, _, err := config.Decode(); err != nil {
+		return err
+	}
+	if config.ResponseBytes < 0 {
+		return fmt.Errorf("socket.response_bytes must not be negative")
+	}
+	if config.Delimiter != "" && config.ResponseBytes > 0 {
+		return fmt.Errorf("socket.delimiter and socket.response_bytes are mutually exclusive")
+	}
+	if targetType == models.TargetUDP && (config.Delimiter != "" || config.ResponseBytes > 0) {
+		return fmt.Errorf("udp targets read one datagram, use socket.await_response instead")
+	}
+	if targetType == models.TargetTCP && config.AwaitResponse {
+		return fmt.Errorf("tcp targets need socket.delimiter or socket.response_bytes to read a response")
+	}
+	return nil
+}
+
+// validateDNSConfig checks the query of a dns test.
+func validateDNSConfig(config *models.DNSConfig) error {
+	if config == nil || config.Name == "" {
+		return fmt.Errorf("dns.name is required for dns targets")
+	}
+	if _, ok := models.DNSRecordTypes[strings.ToUpper(config.Type)]; config.Type != "" && !ok {
+		return fmt.Errorf("unsupported dns.type %q", config.Type)
+	}
+	if config.Transport != "" && config.Transport != "udp" && config.Transport != "tcp" {
+		return fmt.Errorf("dns.transport must be udp or tcp")
+	}
+	return nil
+}
+
+// validateProtocol checks that the requested protocol can be spoken to the
+// target: h2c only exists without TLS, h2 and h3 only with it.
+func validateProtocol(protocol, targetURL string) error {
+	u, err := url.Parse(targetURL)
+	if err != nil {
+		return fmt.Errorf("invalid target_url: %v", err)
+	}
+	switch protocol {
+	case "", "http1.1":
+		return nil
+	case "h2":
+		if u.Scheme != "https" {
+			return fmt.Errorf("protocol h2 requires an https:// target_url, use h2c for http://")
+		}
+		return nil
+	case "h2c":
+		if u.Scheme != "http" {
+			return fmt.Errorf("protocol h2c requires an http:// target_url")
+		}
+		return nil
+	case "h3":
+		if u.Scheme != "https" {
+			return fmt.Errorf("protocol h3 requires an https:// target_url")
+		}
+		return nil
+	default:
+		return fmt.Errorf("protocol must be one of http1.1, h2, h2c, h3")
+	}
+}
+
+// validateTLSConfig checks the TLS options and that a client certificate
+// comes with its key.
+func validateTLSConfig(config *models.TLSConfig) error {
+	if config == nil {
+		return nil
+	}
+	switch config.MinVersion {
+	case "", "1.0", "1.1", "1.2", "1.3":
+	default:
+		return fmt.Errorf("tls.min_version must be one of 1.0, 1.1, 1.2, 1.3")
+	}
+	if (config.ClientCertSecret == "") != (config.ClientKeySecret == "") {
+		return fmt.Errorf("tls.client_cert_secret and tls.client_key_secret must be set together")
+	}
+	for _, name := range config.SecretNames() {
+		if !secrets.ValidName(name) {
+			return fmt.Errorf("tls references the invalid secret name %q", name)
+		}
+	}
+	return nil
+}
+
+// validateNetworkConfig checks the host overrides, proxy and DNS settings,
+// and that the proxy is only used where workers can send through one.
+func validateNetworkConfig(config models.LoadTestConfig) error {
+	network := config.Network
+	if network == nil {
+		return nil
+	}
+	for host, addr := range network.Hosts {
+		if host == "" {
+			return fmt.Errorf("network.hosts must not contain an empty host name")
+		}
+		if net.ParseIP(addr) == nil {
+			return fmt.Errorf("network.hosts maps %s to %q, which is not an IP address", host, addr)
+		}
+	}
+	if network.Proxy != "" {
+		if _, err := parseProxyURL(network.Proxy); err != nil {
+			return err
+		}
+		switch config.TargetType {
+		case models.TargetGRPC, models.TargetTCP, models.TargetUDP, models.TargetDNS:
+			return fmt.Errorf("network.proxy is not supported for %s targets", config.TargetType)
+		}
+		if config.Protocol == "h3" {
+			return fmt.Errorf("network.proxy is not supported with protocol h3")
+		}
+	}
+	if network.DNSServer != "" {
+		host, port, err := net.SplitHostPort(network.DNSServer)
+		if err != nil || host == "" || port == "" {
+			return fmt.Errorf("network.dns_server must be host:port")
+		}
+	}
+	if network.DNSCacheTTL < 0 {
+		return fmt.Errorf("network.dns_cache_ttl must not be negative")
+	}
+	return nil
+}
+
+// parseProxyURL parses a proxy URL, standing in for the secrets it may
+// reference so that their values are never needed or echoed back.
+func parseProxyURL(proxy string) (*url.URL, error) {
+	placeholders := map[string]string{}
+	for _, name := range secrets.References(proxy) {
+		placeholders[name] = "secret"
+	}
+	u, err := url.Parse(secrets.Expand(proxy, placeholders))
+	if err != nil {
+		return nil, fmt.Errorf("invalid network.proxy: %v", err)
+	}
+	switch u.Scheme {
+	case "http", "https", "socks5", "socks5h":
+	default:
+		return nil, fmt.Errorf("network.proxy must be an http, https, socks5 or socks5h URL")
+	}
+	if u.Hostname() == "" {
+		return nil, fmt.Errorf("network.proxy must include a host")
+	}
+	return u, nil
+}
+
+// policyError is returned by validateCreateRequest when a request is well formed
+// but violates the user's quota or target allowlist, so the handler can answer
+// 403 instead of 400.
+type policyError struct {
+	msg string
+}
+
+func (e *policyError) Error() string {
+	return e.msg
+}
+
 func generateTestID() string {
 	return fmt.Sprintf("test-%d", time.Now().UnixNano())
 }
 
-func (h *LoadTestHandler) saveLoadTestToDB(test *models.LoadTest) error {
+// saveLoadTest checks the user's quota and saves test in one transaction
+// holding a lock on the user's row, so that concurrent creates cannot all
+// pass the check before any of them is saved.
+func (h *LoadTestHandler) saveLoadTest(test *models.LoadTest) error {
+	tx, err := h.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.Exec("SELECT 1 FROM users WHERE id = $1 FOR UPDATE", test.UserID); err != nil {
+		return err
+	}
+	if err := checkQuota(tx, test.UserID, test.Config); err != nil {
+		return err
+	}
+	if err := saveLoadTestToDB(tx, test); err != nil {
+		return err
+	}
+	if err := saveLoadTestControl(tx, test); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+func saveLoadTestToDB(db sqlExecer, test *models.LoadTest) error {
 	query := `
         INSERT INTO load_tests (id, name, user_id, target_url, config, status, created_at)
         VALUES ($1, $2, $3, $4, $5, $6, $7)
     `
 	configJSON, _ := json.Marshal(test.Config)
 
-	_, err := h.db.Exec(query, test.ID, test.Name, test.UserID, test.TargetURL,
+	_, err := db.Exec(query, test.ID, test.Name, test.UserID, test.TargetURL,
 		string(configJSON), test.Status, test.CreatedAt)
 	return err
 }
@@ -503,6 +1012,8 @@ func (h *LoadTestHandler) updateRunningJobStatuses() {
 	}
 
 	for _, testID := range runningTests {
+		h.recordWorkerMetrics(context.Background(), testID)
+
 		status, err := h.controller.GetLoadTestStatus(context.Background(), testID)
 		if err != nil {
 			fmt.Printf("Job %s not found in Kubernetes, marking as completed\n", testID)
@@ -529,7 +1040,7 @@ func (h *LoadTestHandler) updateRunningJobStatuses() {
 }
 
 func (h *LoadTestHandler) getRunningTests() ([]string, error) {
-	query := "SELECT id FROM load_tests WHERE status = 'running'"
+	query := "SELECT id FROM load_tests WHERE status IN ('running', 'paused')"
 	rows, err := h.db.Query(query)
 	if err != nil {
 		return nil, err
@@ -548,8 +1059,18 @@ func (h *LoadTestHandler) getRunningTests() ([]string, error) {
 	return testIDs, nil
 }
 
+func (h *LoadTestHandler) setStartTime(testID string) {
+	query := "UPDATE load_tests SET started_at = CURRENT_TIMESTAMP WHERE id = $1"
+	_, err := h.db.Exec(query, testID)
+	if err != nil {
+		fmt.Printf("Error setting start time for %s: %v\n", testID, err)
+	}
+}
+
 func (h *LoadTestHandler) setCompletionTime(testID string) {
-	query := "UPDATE load_tests SET completed_at = CURRENT_TIMESTAMP WHERE id = $1"
+	// a test that already ended keeps its completion time, which usage is
+	// charged by
+	query := "UPDATE load_tests SET completed_at = CURRENT_TIMESTAMP WHERE id = $1 AND completed_at IS NULL"
 	_, err := h.db.Exec(query, testID)
 	if err != nil {
 		fmt.Printf("Error setting completion time for %s: %v\n", testID, err)
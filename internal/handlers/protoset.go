@@ -0,0 +1,193 @@
+package handlers
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+
+	"github.com/Vinayak9769/loadagg/internal/auth"
+	"github.com/Vinayak9769/loadagg/internal/protoset"
+	"github.com/Vinayak9769/loadagg/pkg/models"
+	"github.com/go-chi/chi/v5"
+)
+
+// maxProtosetSize bounds uploaded descriptor sets.
+const maxProtosetSize = 4 << 20
+
+type ProtosetHandler struct {
+	db *sql.DB
+}
+
+func NewProtosetHandler(db *sql.DB) *ProtosetHandler {
+	return &ProtosetHandler{db: db}
+}
+
+func (h *ProtosetHandler) Routes() chi.Router {
+	r := chi.NewRouter()
+
+	r.Group(func(r chi.Router) {
+		r.Use(auth.JWTMiddleware)
+
+		r.Get("/", h.ListProtosets)
+		r.Post("/", h.UploadProtoset)
+		r.Delete("/{id}", h.DeleteProtoset)
+	})
+
+	return r
+}
+
+// List the user's descriptor sets /api/v1/protosets
+func (h *ProtosetHandler) ListProtosets(w http.ResponseWriter, r *http.Request) {
+	query := `
+        SELECT id, user_id, name, data, created_at
+        FROM protosets
+        WHERE user_id = $1
+        ORDER BY created_at DESC
+    `
+	rows, err := h.db.Query(query, getUserID(r))
+	if err != nil {
+		http.Error(w, "Failed to retrieve protosets", http.StatusInternalServerError)
+		return
+	}
+	defer rows.Close()
+
+	protosets := []models.Protoset{}
+	for rows.Next() {
+		var p models.Protoset
+		var data []byte
+		if err := rows.Scan(&p.ID, &p.UserID, &p.Name, &data, &p.CreatedAt); err != nil {
+			continue
+		}
+		if files, err := protoset.Parse(data); err == nil {
+			p.Services = protoset.Services(files)
+		}
+		protosets = append(protosets, p)
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(protosets)
+}
+
+// Upload a descriptor set /api/v1/protosets?name=<name>
+// The body is the binary FileDescriptorSet written by
+// protoc --include_imports --descriptor_set_out.
+func (h *ProtosetHandler) UploadProtoset(w http.ResponseWriter, r *http.Request) {
+	name := r.URL.Query().Get("name")
+	if name == "" {
+		http.Error(w, "name is required", http.StatusBadRequest)
+		return
+	}
+
+	data, err := io.ReadAll(http.MaxBytesReader(w, r.Body, maxProtosetSize))
+	if err != nil {
+		http.Error(w, fmt.Sprintf("Descriptor set must be at most %d bytes", maxProtosetSize), http.StatusRequestEntityTooLarge)
+		return
+	}
+
+	files, err := protoset.Parse(data)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	p := models.Protoset{
+		UserID:   getUserID(r),
+		Name:     name,
+		Services: protoset.Services(files),
+	}
+	query := `
+        INSERT INTO protosets (user_id, name, data)
+        VALUES ($1, $2, $3)
+        RETURNING id, created_at
+    `
+	if err := h.db.QueryRow(query, p.UserID, p.Name, data).Scan(&p.ID, &p.CreatedAt); err != nil {
+		http.Error(w, "Failed to save protoset", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(p)
+}
+
+// Delete a descriptor set /api/v1/protosets/{id}
+func (h *ProtosetHandler) DeleteProtoset(w http.ResponseWriter, r *http.Request) {
+	res, err := h.db.Exec("DELETE FROM protosets WHERE id = $1 AND user_id = $2", chi.URLParam(r, "id"), getUserID(r))
+	if err != nil {
+		http.Error(w, "Failed to delete protoset", http.StatusInternalServerError)
+		return
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		http.Error(w, "Protoset not found", http.StatusNotFound)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]string{"message": "Protoset deleted successfully"})
+}
+
+// Serve the descriptor set of a gRPC test to its workers /api/v1/loadtests/{id}/protoset
+func (h *LoadTestHandler) GetWorkerProtoset(w http.ResponseWriter, r *http.Request) {
+	testID := chi.URLParam(r, "id")
+
+	if r.Context().Value("worker_test_id") != testID {
+		http.Error(w, http.StatusText(http.StatusForbidden), http.StatusForbidden)
+		return
+	}
+
+	query := `
+        SELECT p.data
+        FROM load_tests t
+        JOIN protosets p ON p.id::text = t.config #>> '{grpc,protoset_id}' AND p.user_id = t.user_id
+        WHERE t.id = $1
+    `
+	var data []byte
+	if err := h.db.QueryRow(query, testID).Scan(&data); err != nil {
+		http.Error(w, "Protoset not found", http.StatusNotFound)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/octet-stream")
+	w.Write(data)
+}
+
+// getProtosetData returns a descriptor set owned by the user.
+func getProtosetData(db *sql.DB, protosetID, userID string) ([]byte, error) {
+	var data []byte
+	err := db.QueryRow("SELECT data FROM protosets WHERE id = $1 AND user_id = $2", protosetID, userID).Scan(&data)
+	return data, err
+}
+
+// validateGRPCConfig checks a gRPC test's call against its descriptor set.
+// With reflection only, the method is resolved by the workers at start.
+func validateGRPCConfig(db *sql.DB, userID string, config *models.GRPCConfig) error {
+	if config == nil || config.Service == "" || config.Method == "" {
+		return fmt.Errorf("grpc.service and grpc.method are required for grpc targets")
+	}
+	if len(config.Message) > 0 && !json.Valid(config.Message) {
+		return fmt.Errorf("grpc.message must be a JSON object")
+	}
+	if config.ProtosetID == "" {
+		if !config.Reflection {
+			return fmt.Errorf("grpc targets need either grpc.protoset_id or grpc.reflection")
+		}
+		return nil
+	}
+
+	data, err := getProtosetData(db, config.ProtosetID, userID)
+	if err != nil {
+		return fmt.Errorf("protoset %s not found", config.ProtosetID)
+	}
+	files, err := protoset.Parse(data)
+	if err != nil {
+		return err
+	}
+	md, err := protoset.FindMethod(files, config.Service, config.Method)
+	if err != nil {
+		return err
+	}
+	_, err = protoset.NewRequest(md, config.Message)
+	return err
+}
@@ -0,0 +1,214 @@
+package handlers
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"net/http"
+
+	"github.com/Vinayak9769/loadagg/internal/auth"
+	"github.com/Vinayak9769/loadagg/internal/secrets"
+	"github.com/Vinayak9769/loadagg/pkg/models"
+	"github.com/go-chi/chi/v5"
+)
+
+// maxSecretSize bounds secret values; certificate chains fit comfortably.
+const maxSecretSize = 64 << 10
+
+// SecretHandler stores user secrets. Values go in but never come back out
+// of the API; only workers of the tests referencing them see them.
+type SecretHandler struct {
+	db     *sql.DB
+	sealer *secrets.Sealer // nil when the server has no master key
+}
+
+func NewSecretHandler(db *sql.DB, sealer *secrets.Sealer) *SecretHandler {
+	return &SecretHandler{db: db, sealer: sealer}
+}
+
+func (h *SecretHandler) Routes() chi.Router {
+	r := chi.NewRouter()
+
+	r.Group(func(r chi.Router) {
+		r.Use(auth.JWTMiddleware)
+
+		r.Get("/", h.ListSecrets)
+		r.Put("/{name}", h.PutSecret)
+		r.Delete("/{name}", h.DeleteSecret)
+	})
+
+	return r
+}
+
+type PutSecretRequest struct {
+	Value string `json:"value"`
+}
+
+// List the names of the user's secrets /api/v1/secrets
+func (h *SecretHandler) ListSecrets(w http.ResponseWriter, r *http.Request) {
+	query := `
+        SELECT name, user_id, created_at, updated_at
+        FROM secrets
+        WHERE user_id = $1
+        ORDER BY name
+    `
+	rows, err := h.db.Query(query, getUserID(r))
+	if err != nil {
+		http.Error(w, "Failed to retrieve secrets", http.StatusInternalServerError)
+		return
+	}
+	defer rows.Close()
+
+	list := []models.Secret{}
+	for rows.Next() {
+		var s models.Secret
+		if err := rows.Scan(&s.Name, &s.UserID, &s.CreatedAt, &s.UpdatedAt); err != nil {
+			continue
+		}
+		list = append(list, s)
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(list)
+}
+
+// Create or replace a secret /api/v1/secrets/{name}
+// The body is {"value": "..."}; the response only confirms the name.
+func (h *SecretHandler) PutSecret(w http.ResponseWriter, r *http.Request) {
+	if h.sealer == nil {
+		http.Error(w, "Secrets are not configured on this server", http.StatusServiceUnavailable)
+		return
+	}
+	name := chi.URLParam(r, "name")
+	if !secrets.ValidName(name) {
+		http.Error(w, "name must be 1 to 63 letters, digits, '.', '_' or '-'", http.StatusBadRequest)
+		return
+	}
+
+	var req PutSecretRequest
+	if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, 2*maxSecretSize)).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+	if req.Value == "" {
+		http.Error(w, "value is required", http.StatusBadRequest)
+		return
+	}
+	if len(req.Value) > maxSecretSize {
+		http.Error(w, fmt.Sprintf("value must be at most %d bytes", maxSecretSize), http.StatusRequestEntityTooLarge)
+		return
+	}
+
+	s := models.Secret{UserID: getUserID(r), Name: name}
+	sealed, err := h.sealer.Seal([]byte(req.Value), secretContext(s.UserID, name))
+	if err != nil {
+		http.Error(w, "Failed to encrypt secret", http.StatusInternalServerError)
+		return
+	}
+	query := `
+        INSERT INTO secrets (user_id, name, key_id, wrapped_key, ciphertext)
+        VALUES ($1, $2, $3, $4, $5)
+        ON CONFLICT (user_id, name) DO UPDATE
+        SET key_id = EXCLUDED.key_id, wrapped_key = EXCLUDED.wrapped_key,
+            ciphertext = EXCLUDED.ciphertext, updated_at = CURRENT_TIMESTAMP
+        RETURNING created_at, updated_at
+    `
+	if err := h.db.QueryRow(query, s.UserID, s.Name, sealed.KeyID, sealed.WrappedKey, sealed.Ciphertext).Scan(&s.CreatedAt, &s.UpdatedAt); err != nil {
+		http.Error(w, "Failed to save secret", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(s)
+}
+
+// Delete a secret /api/v1/secrets/{name}
+// Tests already running keep the value they were started with.
+func (h *SecretHandler) DeleteSecret(w http.ResponseWriter, r *http.Request) {
+	res, err := h.db.Exec("DELETE FROM secrets WHERE name = $1 AND user_id = $2", chi.URLParam(r, "name"), getUserID(r))
+	if err != nil {
+		http.Error(w, "Failed to delete secret", http.StatusInternalServerError)
+		return
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		http.Error(w, "Secret not found", http.StatusNotFound)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]string{"message": "Secret deleted successfully"})
+}
+
+// secretContext binds a sealed value to its owner and name.
+func secretContext(userID, name string) []byte {
+	return []byte(userID + "/" + name)
+}
+
+// checkSecretsExist fails unless the user has every named secret.
+func checkSecretsExist(db *sql.DB, sealer *secrets.Sealer, userID string, names []string) error {
+	if len(names) == 0 {
+		return nil
+	}
+	if sealer == nil {
+		return fmt.Errorf("secrets are not configured on this server")
+	}
+	for _, name := range names {
+		var exists bool
+		err := db.QueryRow("SELECT EXISTS (SELECT 1 FROM secrets WHERE user_id = $1 AND name = $2)", userID, name).Scan(&exists)
+		if err != nil {
+			return fmt.Errorf("failed to look up secret %q", name)
+		}
+		if !exists {
+			return fmt.Errorf("secret %q does not exist", name)
+		}
+	}
+	return nil
+}
+
+// openSecrets decrypts the named secrets of a user.
+func openSecrets(db *sql.DB, sealer *secrets.Sealer, userID string, names []string) (map[string][]byte, error) {
+	if len(names) == 0 {
+		return nil, nil
+	}
+	if sealer == nil {
+		return nil, fmt.Errorf("secrets are not configured on this server")
+	}
+	values := make(map[string][]byte, len(names))
+	for _, name := range names {
+		var sealed secrets.Sealed
+		query := "SELECT key_id, wrapped_key, ciphertext FROM secrets WHERE user_id = $1 AND name = $2"
+		if err := db.QueryRow(query, userID, name).Scan(&sealed.KeyID, &sealed.WrappedKey, &sealed.Ciphertext); err != nil {
+			return nil, fmt.Errorf("secret %q: %v", name, err)
+		}
+		value, err := sealer.Open(&sealed, secretContext(userID, name))
+		if err != nil {
+			return nil, fmt.Errorf("secret %q: %v", name, err)
+		}
+		values[name] = value
+	}
+	return values, nil
+}
+
+// checkTLSSecrets fails unless the secrets the TLS settings reference hold
+// a matching certificate and key and a PEM CA bundle, so that a bad upload
+// is reported before any worker starts.
+func checkTLSSecrets(db *sql.DB, sealer *secrets.Sealer, userID string, config *models.TLSConfig) error {
+	if config == nil {
+		return nil
+	}
+	values, err := openSecrets(db, sealer, userID, config.SecretNames())
+	if err != nil {
+		return err
+	}
+	if config.ClientCertSecret != "" {
+		if _, err := tls.X509KeyPair(values[config.ClientCertSecret], values[config.ClientKeySecret]); err != nil {
+			return fmt.Errorf("tls client certificate and key do not form a pair: %v", err)
+		}
+	}
+	if config.CASecret != "" && !x509.NewCertPool().AppendCertsFromPEM(values[config.CASecret]) {
+		return fmt.Errorf("secret %q holds no PEM certificates", config.CASecret)
+	}
+	return nil
+}
@@ -0,0 +1,228 @@
+package handlers
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"math"
+	"net/http"
+	"time"
+
+	"github.com/Vinayak9769/loadagg/internal/auth"
+	"github.com/Vinayak9769/loadagg/pkg/models"
+	"github.com/go-chi/chi/v5"
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// Quotas applied to users without a row in user_quotas
+const (
+	defaultMaxWorkersPerTest  = 10
+	defaultMaxAggregateRPS    = 1000
+	defaultMonthlyTestMinutes = 600
+)
+
+type UserHandler struct {
+	db *sql.DB
+}
+
+func NewUserHandler(db *sql.DB) *UserHandler {
+	return &UserHandler{db: db}
+}
+
+func (h *UserHandler) Routes() chi.Router {
+	r := chi.NewRouter()
+
+	r.Group(func(r chi.Router) {
+		r.Use(auth.JWTMiddleware)
+
+		r.Get("/usage", h.GetUsage)
+	})
+
+	return r
+}
+
+// Get quota and usage for the authenticated user /api/v1/me/usage
+// This endpoint returns the user's quota together with the test-minutes
+// consumed in the current calendar month and the RPS of running tests.
+func (h *UserHandler) GetUsage(w http.ResponseWriter, r *http.Request) {
+	userID := getUserID(r)
+
+	usage, err := getUserUsage(h.db, userID)
+	if err != nil {
+		fmt.Printf("Failed to get usage for user %s: %v\n", userID, err)
+		http.Error(w, "Failed to get usage", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(usage)
+}
+
+func getUserID(r *http.Request) string {
+	claims := r.Context().Value("claims").(jwt.MapClaims)
+	return claims["ID"].(string)
+}
+
+func getUserQuota(db sqlQueryer, userID string) (*models.UserQuota, error) {
+	quota := &models.UserQuota{
+		UserID:             userID,
+		MaxWorkersPerTest:  defaultMaxWorkersPerTest,
+		MaxAggregateRPS:    defaultMaxAggregateRPS,
+		MonthlyTestMinutes: defaultMonthlyTestMinutes,
+	}
+
+	query := `
+        SELECT max_workers_per_test, max_aggregate_rps, monthly_test_minutes
+        FROM user_quotas
+        WHERE user_id = $1
+    `
+	err := db.QueryRow(query, userID).Scan(
+		&quota.MaxWorkersPerTest, &quota.MaxAggregateRPS, &quota.MonthlyTestMinutes,
+	)
+	if err != nil && err != sql.ErrNoRows {
+		return nil, err
+	}
+
+	return quota, nil
+}
+
+func getUserUsage(db sqlQueryer, userID string) (*models.UserUsage, error) {
+	quota, err := getUserQuota(db, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now().UTC()
+	periodStart := time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, time.UTC)
+	periodEnd := periodStart.AddDate(0, 1, 0)
+
+	// Tests still running are charged up to now
+	var usedSeconds float64
+	query := `
+        SELECT COALESCE(SUM(EXTRACT(EPOCH FROM (COALESCE(completed_at, NOW()) - started_at))), 0)
+        FROM load_tests
+        WHERE user_id = $1 AND started_at >= $2
+    `
+	if err := db.QueryRow(query, userID, periodStart).Scan(&usedSeconds); err != nil {
+		return nil, err
+	}
+
+	// paused tests keep their rate reserved so resuming cannot exceed the
+	// quota, and tests still starting already hold theirs. Active tests also
+	// reserve the run time they have left; time spent paused pushes the end
+	// of the test back, so it is charged on top of the configured duration.
+	query = `
+        SELECT t.config,
+               COALESCE(EXTRACT(EPOCH FROM (NOW() - t.started_at)), 0),
+               COALESCE(c.paused_seconds, 0) + COALESCE(EXTRACT(EPOCH FROM (NOW() - c.paused_at)), 0),
+               t.started_at IS NULL OR t.started_at >= $2
+        FROM load_tests t
+        LEFT JOIN load_test_controls c ON c.test_id = t.id
+        WHERE t.user_id = $1 AND t.status IN ('pending', 'running', 'paused')
+    `
+	rows, err := db.Query(query, userID, periodStart)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var runningTests, runningRPS int
+	var reservedSeconds float64
+	for rows.Next() {
+		var configJSON string
+		var elapsed, paused float64
+		var inPeriod bool
+		if err := rows.Scan(&configJSON, &elapsed, &paused, &inPeriod); err != nil {
+			continue
+		}
+		var config models.LoadTestConfig
+		json.Unmarshal([]byte(configJSON), &config)
+		runningTests++
+		runningRPS += aggregateRPS(config)
+		if inPeriod {
+			reservedSeconds += math.Max(0, float64(config.Duration)-(elapsed-paused))
+		}
+	}
+
+	usedMinutes := usedSeconds / 60
+	reservedMinutes := reservedSeconds / 60
+
+	return &models.UserUsage{
+		Quota:                *quota,
+		PeriodStart:          periodStart,
+		PeriodEnd:            periodEnd,
+		TestMinutesUsed:      math.Round(usedMinutes*100) / 100,
+		TestMinutesReserved:  math.Round(reservedMinutes*100) / 100,
+		TestMinutesRemaining: math.Max(0, math.Round((float64(quota.MonthlyTestMinutes)-usedMinutes-reservedMinutes)*100)/100),
+		RunningTests:         runningTests,
+		RunningRPS:           runningRPS,
+		RemainingRPS:         max(0, quota.MaxAggregateRPS-runningRPS),
+	}, nil
+}
+
+// checkQuota verifies that starting a test with the given config keeps the user
+// within their worker, aggregate RPS and monthly test-minute limits.
+func checkQuota(db sqlQueryer, userID string, config models.LoadTestConfig) error {
+	usage, err := getUserUsage(db, userID)
+	if err != nil {
+		return fmt.Errorf("failed to check quota: %v", err)
+	}
+	quota := usage.Quota
+
+	if config.WorkerCount > quota.MaxWorkersPerTest {
+		return &policyError{fmt.Sprintf("worker_count %d exceeds quota of %d workers per test",
+			config.WorkerCount, quota.MaxWorkersPerTest)}
+	}
+
+	if rps := aggregateRPS(config); usage.RunningRPS+rps > quota.MaxAggregateRPS {
+		return &policyError{fmt.Sprintf("aggregate rps %d exceeds quota (%d of %d rps already in use)",
+			rps, usage.RunningRPS, quota.MaxAggregateRPS)}
+	}
+
+	requestedMinutes := float64(config.Duration) / 60
+	if requestedMinutes > usage.TestMinutesRemaining {
+		return &policyError{fmt.Sprintf("test duration of %.2f minutes exceeds remaining monthly budget of %.2f minutes",
+			requestedMinutes, usage.TestMinutesRemaining)}
+	}
+
+	return nil
+}
+
+// checkResumeQuota verifies that the time a test spent paused has not pushed
+// the user's used and reserved test minutes past their monthly budget.
+func checkResumeQuota(db sqlQueryer, userID string) error {
+	usage, err := getUserUsage(db, userID)
+	if err != nil {
+		return fmt.Errorf("failed to check quota: %v", err)
+	}
+
+	if committed := usage.TestMinutesUsed + usage.TestMinutesReserved; committed > float64(usage.Quota.MonthlyTestMinutes) {
+		return &policyError{fmt.Sprintf("resuming would use %.2f test minutes, exceeding monthly budget of %d minutes",
+			committed, usage.Quota.MonthlyTestMinutes)}
+	}
+
+	return nil
+}
+
+// checkRateQuota verifies that changing a running test from oldConfig to
+// newConfig keeps the user within their aggregate RPS limit.
+func checkRateQuota(db *sql.DB, userID string, oldConfig, newConfig models.LoadTestConfig) error {
+	usage, err := getUserUsage(db, userID)
+	if err != nil {
+		return fmt.Errorf("failed to check quota: %v", err)
+	}
+
+	otherRPS := usage.RunningRPS - aggregateRPS(oldConfig)
+	if rps := aggregateRPS(newConfig); otherRPS+rps > usage.Quota.MaxAggregateRPS {
+		return &policyError{fmt.Sprintf("aggregate rps %d exceeds quota (%d of %d rps already in use)",
+			rps, otherRPS, usage.Quota.MaxAggregateRPS)}
+	}
+
+	return nil
+}
+
+// aggregateRPS is the total request rate a test sends across all workers.
+// requests_per_sec is already the total; the controller splits it per worker.
+func aggregateRPS(config models.LoadTestConfig) int {
+	return config.RequestsPerSec
+}
@@ -0,0 +1,229 @@
+package importer
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Vinayak9769/loadagg/pkg/models"
+)
+
+// combinedLine matches the common and combined log formats of nginx and
+// Apache up to the request line and status.
+var combinedLine = regexp.MustCompile(`^\S+ \S+ \S+ \[([^\]]+)\] "((?:[^"\\]|\\.)*)" (\d{3}|-)`)
+
+// combinedTime is the layout of $time_local and Apache's %t.
+const combinedTime = "02/Jan/2006:15:04:05 -0700"
+
+// replayMethods are the methods a replay sends; CONNECT and unknown ones
+// are reported as unreplayable.
+var replayMethods = map[string]bool{
+	"GET": true, "HEAD": true, "POST": true, "PUT": true, "PATCH": true,
+	"DELETE": true, "OPTIONS": true,
+}
+
+// AccessLog parses an access log into replay entries ordered by time, and
+// lists the lines it cannot replay. Combined logs only have whole seconds,
+// so requests logged in the same second are spread evenly over it.
+func AccessLog(data []byte, format string) ([]models.ReplayEntry, []models.ImportSkipped, error) {
+	type timed struct {
+		at    time.Time
+		entry models.ReplayEntry
+	}
+	var (
+		lines   []timed
+		skipped []models.ImportSkipped
+	)
+
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	scanner.Buffer(make([]byte, 64<<10), 1<<20)
+	for n := 1; scanner.Scan(); n++ {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" {
+			continue
+		}
+
+		var (
+			at     time.Time
+			method string
+			target string
+			err    error
+		)
+		switch format {
+		case models.AccessLogCombined:
+			at, method, target, err = parseCombinedLine(line)
+		case models.AccessLogJSON:
+			at, method, target, err = parseJSONLine(line)
+		default:
+			return nil, nil, fmt.Errorf("format must be %s or %s", models.AccessLogCombined, models.AccessLogJSON)
+		}
+		if err == nil {
+			target, err = replayPath(method, target)
+		}
+		if err != nil {
+			skipped = append(skipped, models.ImportSkipped{Item: fmt.Sprintf("line %d", n), Reason: err.Error()})
+			continue
+		}
+		lines = append(lines, timed{at: at, entry: models.ReplayEntry{Method: method, Path: target}})
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, nil, fmt.Errorf("reading access log: %v", err)
+	}
+	if len(lines) == 0 {
+		return nil, skipped, fmt.Errorf("access log has no replayable requests")
+	}
+
+	sort.SliceStable(lines, func(i, j int) bool { return lines[i].at.Before(lines[j].at) })
+
+	start := lines[0].at
+	entries := make([]models.ReplayEntry, len(lines))
+	for i := 0; i < len(lines); {
+		// the run of requests sharing this timestamp
+		j := i + 1
+		for j < len(lines) && lines[j].at.Equal(lines[i].at) {
+			j++
+		}
+		offset := lines[i].at.Sub(start).Milliseconds()
+		for k := i; k < j; k++ {
+			entries[k] = lines[k].entry
+			entries[k].OffsetMS = offset
+			if format == models.AccessLogCombined {
+				entries[k].OffsetMS += int64(k-i) * 1000 / int64(j-i)
+			}
+		}
+		i = j
+	}
+	return entries, skipped, nil
+}
+
+// PeakRate returns the most requests the entries send in any one second.
+func PeakRate(entries []models.ReplayEntry) int {
+	peak := 0
+	for i, j := 0, 0; j < len(entries); j++ {
+		for entries[j].OffsetMS-entries[i].OffsetMS >= 1000 {
+			i++
+		}
+		peak = max(peak, j-i+1)
+	}
+	return peak
+}
+
+func parseCombinedLine(line string) (time.Time, string, string, error) {
+	m := combinedLine.FindStringSubmatch(line)
+	if m == nil {
+		return time.Time{}, "", "", fmt.Errorf("not in combined or common log format")
+	}
+	at, err := time.Parse(combinedTime, m[1])
+	if err != nil {
+		return time.Time{}, "", "", fmt.Errorf("invalid timestamp %q", m[1])
+	}
+	method, target, err := splitRequestLine(m[2])
+	return at, method, target, err
+}
+
+// splitRequestLine splits "GET /path HTTP/1.1" into method and target.
+func splitRequestLine(request string) (string, string, error) {
+	parts := strings.Fields(request)
+	if len(parts) < 2 || len(parts) > 3 {
+		return "", "", fmt.Errorf("malformed request line %q", truncate(request, 64))
+	}
+	return parts[0], parts[1], nil
+}
+
+// parseJSONLine reads a JSON log line, accepting the field names of common
+// nginx log_format definitions as well as generic ones.
+func parseJSONLine(line string) (time.Time, string, string, error) {
+	var fields map[string]any
+	if err := json.Unmarshal([]byte(line), &fields); err != nil {
+		return time.Time{}, "", "", fmt.Errorf("not a JSON object")
+	}
+	str := func(names ...string) string {
+		for _, name := range names {
+			if v, ok := fields[name].(string); ok && v != "" {
+				return v
+			}
+		}
+		return ""
+	}
+
+	at, err := jsonLogTime(fields)
+	if err != nil {
+		return time.Time{}, "", "", err
+	}
+
+	method := str("method", "request_method")
+	target := str("uri", "request_uri", "path", "url")
+	if method == "" || target == "" {
+		request := str("request")
+		if request == "" {
+			return time.Time{}, "", "", fmt.Errorf("no method and path or request field")
+		}
+		if method, target, err = splitRequestLine(request); err != nil {
+			return time.Time{}, "", "", err
+		}
+	} else if !strings.Contains(target, "?") {
+		// nginx logs $uri without the query string, which is in $args
+		if args := str("args", "query_string", "query"); args != "" {
+			target += "?" + strings.TrimPrefix(args, "?")
+		}
+	}
+	return at, method, target, nil
+}
+
+func jsonLogTime(fields map[string]any) (time.Time, error) {
+	for _, name := range []string{"time_iso8601", "timestamp", "time", "@timestamp", "time_local", "msec"} {
+		switch v := fields[name].(type) {
+		case float64:
+			// epoch seconds, with milliseconds in the fraction as in $msec
+			return time.UnixMilli(int64(v * 1000)), nil
+		case string:
+			if v == "" {
+				continue
+			}
+			for _, layout := range []string{time.RFC3339Nano, combinedTime} {
+				if at, err := time.Parse(layout, v); err == nil {
+					return at, nil
+				}
+			}
+			if seconds, err := strconv.ParseFloat(v, 64); err == nil {
+				return time.UnixMilli(int64(seconds * 1000)), nil
+			}
+			return time.Time{}, fmt.Errorf("invalid timestamp %q", truncate(v, 64))
+		}
+	}
+	return time.Time{}, fmt.Errorf("no timestamp field")
+}
+
+// replayPath checks that a logged request can be sent again and returns
+// its path and query; the host of absolute targets is replaced by the
+// test's target.
+func replayPath(method, target string) (string, error) {
+	if !replayMethods[method] {
+		return "", fmt.Errorf("method %q cannot be replayed", truncate(method, 16))
+	}
+	if target == "*" {
+		return "", fmt.Errorf("%s * cannot be replayed", method)
+	}
+	u, err := url.ParseRequestURI(target)
+	if err != nil {
+		return "", fmt.Errorf("invalid request target %q", truncate(target, 64))
+	}
+	if u.IsAbs() && u.Scheme != "http" && u.Scheme != "https" {
+		return "", fmt.Errorf("%s URLs cannot be replayed", u.Scheme)
+	}
+	return u.RequestURI(), nil
+}
+
+func truncate(s string, n int) string {
+	if len(s) <= n {
+		return s
+	}
+	return s[:n] + "..."
+}
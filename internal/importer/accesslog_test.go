@@ -0,0 +1,131 @@
+package importer
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/Vinayak9769/loadagg/pkg/models"
+)
+
+func TestAccessLog(t *testing.T) {
+	tests := []struct {
+		name    string
+		format  string
+		log     string
+		entries []models.ReplayEntry
+		skipped []models.ImportSkipped
+	}{
+		{
+			name:   "combined",
+			format: models.AccessLogCombined,
+			log: `10.0.0.1 - - [01/May/2024:10:00:00 +0000] "GET /a?x=1 HTTP/1.1" 200 12 "-" "curl/8.0"
+10.0.0.1 - - [01/May/2024:10:00:00 +0000] "POST /b HTTP/1.1" 201 0
+
+10.0.0.1 - alice [01/May/2024:10:00:02 +0000] "GET http://old.test/c HTTP/1.1" 200 1
+10.0.0.1 - - [01/May/2024:12:00:00 +0200] "HEAD / HTTP/1.0" 200 -
+garbage
+10.0.0.1 - - [01/May/2024:10:00:03 +0000] "CONNECT host.test:443 HTTP/1.1" 200 -
+10.0.0.1 - - [01/May/2024:10:00:03 +0000] "\x16\x03\x01" 400 -
+10.0.0.1 - - [01/May/2024:10:00:03 +0000] "OPTIONS * HTTP/1.1" 200 -
+10.0.0.1 - - [31/Feb/2024:10:00:03 +0000] "GET / HTTP/1.1" 200 -`,
+			// requests logged in the same second are spread over it
+			entries: []models.ReplayEntry{
+				{OffsetMS: 0, Method: "GET", Path: "/a?x=1"},
+				{OffsetMS: 333, Method: "POST", Path: "/b"},
+				{OffsetMS: 666, Method: "HEAD", Path: "/"},
+				{OffsetMS: 2000, Method: "GET", Path: "/c"},
+			},
+			skipped: []models.ImportSkipped{
+				{Item: "line 6", Reason: "not in combined or common log format"},
+				{Item: "line 7", Reason: `method "CONNECT" cannot be replayed`},
+				{Item: "line 8", Reason: `malformed request line "\\x16\\x03\\x01"`},
+				{Item: "line 9", Reason: "OPTIONS * cannot be replayed"},
+				{Item: "line 10", Reason: `invalid timestamp "31/Feb/2024:10:00:03 +0000"`},
+			},
+		},
+		{
+			name:   "json",
+			format: models.AccessLogJSON,
+			log: `{"time_iso8601": "2024-05-01T10:00:00.250Z", "request_method": "GET", "uri": "/a", "args": "x=1"}
+{"timestamp": "01/May/2024:10:00:01 +0000", "method": "DELETE", "path": "/c?y=2", "args": "z=3"}
+{"msec": 1714557600.5, "request": "POST /b HTTP/2.0"}
+{"msec": "1714557600.500", "method": "PUT", "url": "https://old.test/d"}
+{"time": "yesterday", "method": "GET", "uri": "/"}
+{"method": "GET", "uri": "/"}
+[1, 2]
+{"time": "2024-05-01T10:00:00Z", "method": "GET"}
+{"time": "2024-05-01T10:00:00Z", "method": "TRACE", "uri": "/"}`,
+			entries: []models.ReplayEntry{
+				{OffsetMS: 0, Method: "GET", Path: "/a?x=1"},
+				{OffsetMS: 250, Method: "POST", Path: "/b"},
+				{OffsetMS: 250, Method: "PUT", Path: "/d"},
+				{OffsetMS: 750, Method: "DELETE", Path: "/c?y=2"},
+			},
+			skipped: []models.ImportSkipped{
+				{Item: "line 5", Reason: `invalid timestamp "yesterday"`},
+				{Item: "line 6", Reason: "no timestamp field"},
+				{Item: "line 7", Reason: "not a JSON object"},
+				{Item: "line 8", Reason: "no method and path or request field"},
+				{Item: "line 9", Reason: `method "TRACE" cannot be replayed`},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			entries, skipped, err := AccessLog([]byte(tt.log), tt.format)
+			if err != nil {
+				t.Fatalf("AccessLog error: %v", err)
+			}
+			if !reflect.DeepEqual(entries, tt.entries) {
+				t.Errorf("entries = %+v, want %+v", entries, tt.entries)
+			}
+			if !reflect.DeepEqual(skipped, tt.skipped) {
+				t.Errorf("skipped = %+v, want %+v", skipped, tt.skipped)
+			}
+		})
+	}
+}
+
+func TestAccessLogErrors(t *testing.T) {
+	tests := []struct {
+		name   string
+		format string
+		log    string
+	}{
+		{"unknown format", "w3c", `10.0.0.1 - - [01/May/2024:10:00:00 +0000] "GET / HTTP/1.1" 200 1`},
+		{"empty", models.AccessLogCombined, "\n\n"},
+		{"nothing replayable", models.AccessLogJSON, `{"method": "GET", "uri": "/"}`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, _, err := AccessLog([]byte(tt.log), tt.format); err == nil {
+				t.Error("AccessLog succeeded, want an error")
+			}
+		})
+	}
+}
+
+func TestPeakRate(t *testing.T) {
+	tests := []struct {
+		name    string
+		offsets []int64
+		want    int
+	}{
+		{"empty", nil, 0},
+		{"single", []int64{0}, 1},
+		{"one per second", []int64{0, 1000, 2000}, 1},
+		{"burst inside a second", []int64{0, 100, 999, 1000, 5000}, 3},
+		{"window slides", []int64{0, 900, 1100, 1800, 1950, 2050}, 4},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			entries := make([]models.ReplayEntry, len(tt.offsets))
+			for i, offset := range tt.offsets {
+				entries[i].OffsetMS = offset
+			}
+			if got := PeakRate(entries); got != tt.want {
+				t.Errorf("PeakRate(%v) = %d, want %d", tt.offsets, got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,433 @@
+package importer
+
+import (
+	"encoding/base64"
+	"fmt"
+	"net"
+	"net/url"
+	"path"
+	"strings"
+
+	"github.com/Vinayak9769/loadagg/pkg/models"
+)
+
+// curlOutputFlags only change what curl prints or saves, not the request,
+// so they are dropped without a note. The value says if the flag takes an
+// argument.
+var curlOutputFlags = map[string]bool{
+	"-s": false, "--silent": false, "-S": false, "--show-error": false,
+	"-v": false, "--verbose": false, "-i": false, "--include": false,
+	"-f": false, "--fail": false, "--fail-with-body": false,
+	"-#": false, "--progress-bar": false, "--no-progress-meter": false,
+	"-N": false, "--no-buffer": false,
+	"-o": true, "--output": true, "-O": false, "--remote-name": false,
+	"-w": true, "--write-out": true, "-D": true, "--dump-header": true,
+	"-c": true, "--cookie-jar": true, "--trace": true, "--trace-ascii": true,
+	"--stderr": true,
+}
+
+// curlUnsupportedFlags change the request in ways a test cannot reproduce.
+// They are reported; the value says if the flag takes an argument.
+var curlUnsupportedFlags = map[string]bool{
+	"-F": true, "--form": true, "--form-string": true,
+	"-T": true, "--upload-file": true,
+	"-U": true, "--proxy-user": true,
+	"-E": true, "--cert": true, "--key": true, "--cacert": true, "--capath": true,
+	"--connect-to": true, "--interface": true,
+	"-r": true, "--range": true, "-m": true, "--max-time": true,
+	"--connect-timeout": true, "--retry": true, "--limit-rate": true,
+	"-K": true, "--config": true, "--oauth2-bearer": false,
+	"--digest": false, "--ntlm": false, "--negotiate": false, "--anyauth": false,
+	"-0": false, "--http1.0": false,
+	// the worker records redirects instead of following them
+	"-L": false, "--location": false,
+}
+
+// curlShortValueFlags are the short flags that take an argument, which may
+// be attached as in -XPOST.
+const curlShortValueFlags = "XHdubAeowDcFTxUErmK"
+
+// Curl drafts a single-request test from a curl command line. files holds
+// the contents of the files it references with @file, by name; flags that
+// cannot be reproduced, and files that were not given, are reported as
+// skipped.
+func Curl(command string, files map[string]string) (*models.ImportResult, error) {
+	args, err := splitShell(command)
+	if err != nil {
+		return nil, err
+	}
+	if len(args) == 0 || path.Base(args[0]) != "curl" {
+		return nil, fmt.Errorf("command must start with curl")
+	}
+
+	result := &models.ImportResult{Config: draftConfig()}
+	skip := func(item, reason string) {
+		result.Skipped = append(result.Skipped, models.ImportSkipped{Item: item, Reason: reason})
+	}
+	network := func() *models.NetworkConfig {
+		if result.Config.Network == nil {
+			result.Config.Network = &models.NetworkConfig{}
+		}
+		return result.Config.Network
+	}
+	readFile := func(flag, name string) (string, bool) {
+		if name == "-" {
+			skip(flag+" @-", "reading from stdin is not supported")
+			return "", false
+		}
+		content, ok := files[name]
+		if !ok {
+			skip(flag+" @"+name, "file not uploaded")
+		}
+		return content, ok
+	}
+
+	var (
+		target, method string
+		basicAuth      string
+		data           []string
+		get, head      bool
+		jsonData       bool
+		headers        = make(map[string]string)
+	)
+	for i := 1; i < len(args); i++ {
+		arg := args[i]
+		if !strings.HasPrefix(arg, "-") || arg == "-" {
+			if target == "" {
+				target = arg
+			} else {
+				skip(arg, "only one URL per test")
+			}
+			continue
+		}
+
+		flag, value, hasValue := arg, "", false
+		if !strings.HasPrefix(arg, "--") && len(arg) > 2 {
+			if strings.ContainsRune(curlShortValueFlags, rune(arg[1])) {
+				flag, value, hasValue = arg[:2], arg[2:], true
+			} else {
+				// combined flags such as -sSk; a flag that takes an
+				// argument ends the group, as in -sXPOST
+				var rest []string
+				for j, c := range arg[1:] {
+					if strings.ContainsRune(curlShortValueFlags, c) {
+						rest = append(rest, "-"+arg[1+j:])
+						break
+					}
+					rest = append(rest, "-"+string(c))
+				}
+				args = append(args[:i+1], append(rest, args[i+1:]...)...)
+				continue
+			}
+		}
+		takesValue := strings.ContainsRune(curlShortValueFlags, rune(flag[1])) && !strings.HasPrefix(flag, "--")
+		switch flag {
+		case "--request", "--header", "--data", "--data-raw", "--data-ascii", "--data-binary",
+			"--data-urlencode", "--json", "--user", "--cookie", "--user-agent", "--referer", "--url",
+			"--proxy", "--resolve":
+			takesValue = true
+		}
+		if known, ok := curlOutputFlags[flag]; ok {
+			takesValue = known
+		}
+		if known, ok := curlUnsupportedFlags[flag]; ok {
+			takesValue = known
+		}
+		if takesValue && !hasValue {
+			if i+1 >= len(args) {
+				return nil, fmt.Errorf("%s needs an argument", flag)
+			}
+			i++
+			value = args[i]
+		}
+
+		switch flag {
+		case "-X", "--request":
+			method = strings.ToUpper(value)
+		case "-H", "--header":
+			name, headerValue, ok := strings.Cut(value, ":")
+			if !ok {
+				// "Name;" sends the header without a value
+				if name, ok = strings.CutSuffix(value, ";"); !ok {
+					skip(flag+" "+value, "not a header")
+					continue
+				}
+			}
+			name = strings.TrimSpace(name)
+			headerValue = strings.TrimSpace(headerValue)
+			switch {
+			case strings.EqualFold(name, "Host"):
+				skip(flag+" "+value, "overriding the Host header is not supported")
+			case ok && headerValue == "":
+				// "Name:" removes a header curl would send, which the
+				// worker does not send either
+			case connectionHeaders[strings.ToLower(name)]:
+			default:
+				addHeader(headers, name, headerValue)
+			}
+		case "-d", "--data", "--data-ascii":
+			if name, ok := strings.CutPrefix(value, "@"); ok {
+				content, ok := readFile(flag, name)
+				if !ok {
+					continue
+				}
+				value = strings.NewReplacer("\r", "", "\n", "").Replace(content)
+			}
+			data = append(data, value)
+		case "--data-raw":
+			data = append(data, value)
+		case "--data-binary", "--json":
+			if name, ok := strings.CutPrefix(value, "@"); ok {
+				if value, ok = readFile(flag, name); !ok {
+					continue
+				}
+			}
+			data = append(data, value)
+			jsonData = jsonData || flag == "--json"
+		case "--data-urlencode":
+			encoded, ok := curlURLEncode(value, func(name string) (string, bool) { return readFile(flag, name) })
+			if ok {
+				data = append(data, encoded)
+			}
+		case "-u", "--user":
+			if !strings.Contains(value, ":") {
+				value += ":"
+			}
+			basicAuth = "Basic " + base64.StdEncoding.EncodeToString([]byte(value))
+		case "-b", "--cookie":
+			if !strings.Contains(value, "=") {
+				skip(flag+" "+value, "cookie files are not supported")
+				continue
+			}
+			addHeader(headers, "Cookie", value)
+		case "-A", "--user-agent":
+			headers["User-Agent"] = value
+		case "-e", "--referer":
+			headers["Referer"] = value
+		case "--compressed":
+			if !hasHeader(headers, "Accept-Encoding") {
+				headers["Accept-Encoding"] = "gzip, deflate, br"
+			}
+		case "-k", "--insecure":
+			result.Config.TLS = &models.TLSConfig{InsecureSkipVerify: true}
+		case "-G", "--get":
+			get = true
+		case "-I", "--head":
+			head = true
+		case "--url":
+			target = value
+		case "-x", "--proxy":
+			if !strings.Contains(value, "://") {
+				// like curl, assume an http proxy
+				value = "http://" + value
+			}
+			network().Proxy = value
+		case "--resolve":
+			// [+]host:port:addr[,addr]...; the override applies to every
+			// port and only the first address is used
+			host, rest, _ := strings.Cut(strings.TrimPrefix(value, "+"), ":")
+			_, addrs, ok := strings.Cut(rest, ":")
+			addr, _, _ := strings.Cut(addrs, ",")
+			addr = strings.Trim(addr, "[]")
+			if !ok || host == "" || host == "*" || net.ParseIP(addr) == nil {
+				skip(flag+" "+value, "not a host:port:address override")
+				continue
+			}
+			n := network()
+			if n.Hosts == nil {
+				n.Hosts = make(map[string]string)
+			}
+			n.Hosts[host] = addr
+		case "--http1.1":
+			result.Config.Protocol = "http1.1"
+		case "--http2", "--http2-prior-knowledge":
+			result.Config.Protocol = "h2"
+		case "--http3", "--http3-only":
+			result.Config.Protocol = "h3"
+		default:
+			if _, ok := curlOutputFlags[flag]; ok {
+				continue
+			}
+			if takesValue {
+				skip(flag+" "+value, "unsupported flag")
+			} else {
+				skip(flag, "unsupported flag")
+			}
+		}
+	}
+
+	if target == "" {
+		return nil, fmt.Errorf("command has no URL")
+	}
+	if !strings.Contains(target, "://") {
+		// like curl, assume http
+		target = "http://" + target
+	}
+	u, err := url.Parse(target)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return nil, fmt.Errorf("%q is not an http or https URL", target)
+	}
+	if u.Scheme == "http" && result.Config.Protocol == "h2" {
+		result.Config.Protocol = "h2c"
+	}
+
+	body := strings.Join(data, "&")
+	switch {
+	case head:
+		result.Config.HTTPMethod = "HEAD"
+	case get && body != "":
+		if u.RawQuery != "" {
+			u.RawQuery += "&"
+		}
+		u.RawQuery += body
+		body = ""
+		result.Config.HTTPMethod = "GET"
+	case len(data) > 0:
+		result.Config.HTTPMethod = "POST"
+	}
+	if method != "" {
+		result.Config.HTTPMethod = method
+	}
+
+	if len(data) > 0 && body != "" {
+		result.Config.Body = body
+		if jsonData {
+			if !hasHeader(headers, "Content-Type") {
+				headers["Content-Type"] = "application/json"
+			}
+			if !hasHeader(headers, "Accept") {
+				headers["Accept"] = "application/json"
+			}
+		} else if !hasHeader(headers, "Content-Type") {
+			headers["Content-Type"] = "application/x-www-form-urlencoded"
+		}
+	}
+	// an Authorization header overrides -u, as in curl
+	if basicAuth != "" && !hasHeader(headers, "Authorization") {
+		headers["Authorization"] = basicAuth
+	}
+	if len(headers) > 0 {
+		result.Config.Headers = headers
+	}
+
+	result.TargetURL = u.String()
+	result.Name = result.Config.HTTPMethod + " " + u.Host + u.Path
+	return result, nil
+}
+
+// curlURLEncode encodes a --data-urlencode argument the way curl does:
+// "content", "=content", "name=content", "@file" or "name@file".
+func curlURLEncode(value string, readFile func(string) (string, bool)) (string, bool) {
+	name, content := "", value
+	if i := strings.IndexAny(value, "=@"); i >= 0 {
+		name, content = value[:i], value[i+1:]
+		if value[i] == '@' {
+			var ok bool
+			if content, ok = readFile(content); !ok {
+				return "", false
+			}
+		}
+	}
+	encoded := url.QueryEscape(content)
+	if name != "" {
+		encoded = name + "=" + encoded
+	}
+	return encoded, true
+}
+
+// splitShell splits a command line into arguments the way a POSIX shell
+// would, including $'...' strings and backslash line continuations.
+func splitShell(command string) ([]string, error) {
+	var (
+		args    []string
+		current strings.Builder
+		inArg   bool
+	)
+	runes := []rune(command)
+	for i := 0; i < len(runes); i++ {
+		c := runes[i]
+		switch {
+		case c == '\\':
+			i++
+			if i >= len(runes) {
+				break
+			}
+			if runes[i] == '\n' {
+				continue
+			}
+			if runes[i] == '\r' && i+1 < len(runes) && runes[i+1] == '\n' {
+				i++
+				continue
+			}
+			current.WriteRune(runes[i])
+			inArg = true
+		case c == '\'':
+			end := indexRune(runes, i+1, '\'')
+			if end < 0 {
+				return nil, fmt.Errorf("unterminated ' quote")
+			}
+			current.WriteString(string(runes[i+1 : end]))
+			i, inArg = end, true
+		case c == '$' && i+1 < len(runes) && runes[i+1] == '\'':
+			j := i + 2
+			for ; j < len(runes) && runes[j] != '\''; j++ {
+				if runes[j] != '\\' || j+1 >= len(runes) {
+					current.WriteRune(runes[j])
+					continue
+				}
+				j++
+				switch runes[j] {
+				case 'n':
+					current.WriteRune('\n')
+				case 't':
+					current.WriteRune('\t')
+				case 'r':
+					current.WriteRune('\r')
+				default:
+					current.WriteRune(runes[j])
+				}
+			}
+			if j >= len(runes) {
+				return nil, fmt.Errorf("unterminated $' quote")
+			}
+			i, inArg = j, true
+		case c == '"':
+			j := i + 1
+			for ; j < len(runes) && runes[j] != '"'; j++ {
+				if runes[j] == '\\' && j+1 < len(runes) && strings.ContainsRune("\"\\$`\n", runes[j+1]) {
+					j++
+					if runes[j] == '\n' {
+						continue
+					}
+				}
+				current.WriteRune(runes[j])
+			}
+			if j >= len(runes) {
+				return nil, fmt.Errorf("unterminated \" quote")
+			}
+			i, inArg = j, true
+		case c == ' ' || c == '\t' || c == '\n' || c == '\r':
+			if inArg {
+				args = append(args, current.String())
+				current.Reset()
+				inArg = false
+			}
+		default:
+			current.WriteRune(c)
+			inArg = true
+		}
+	}
+	if inArg {
+		args = append(args, current.String())
+	}
+	return args, nil
+}
+
+func indexRune(runes []rune, from int, r rune) int {
+	for i := from; i < len(runes); i++ {
+		if runes[i] == r {
+			return i
+		}
+	}
+	return -1
+}
@@ -0,0 +1,283 @@
+package importer
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestSplitShell(t *testing.T) {
+	tests := []struct {
+		name    string
+		command string
+		want    []string
+	}{
+		{"plain words", "curl -s https://a.test", []string{"curl", "-s", "https://a.test"}},
+		{"runs of blanks", "curl \t -s\n\nhttps://a.test ", []string{"curl", "-s", "https://a.test"}},
+		{"single quotes are literal", `curl -H 'X-A: "b" \n $c'`, []string{"curl", "-H", `X-A: "b" \n $c`}},
+		{"double quote escapes", `curl -d "a\"b\\c\$d\e"`, []string{"curl", "-d", `a"b\c$d\e`}},
+		{"ansi-c quotes", `curl -d $'a\nb\tc\'d\\e'`, []string{"curl", "-d", "a\nb\tc'd\\e"}},
+		{"adjacent quotes join", `curl a'b'"c"$'d'e`, []string{"curl", "abcde"}},
+		{"empty quotes", `curl -d ''`, []string{"curl", "-d", ""}},
+		{"backslash escapes a blank", `curl a\ b`, []string{"curl", "a b"}},
+		{"line continuations", "curl \\\n  -s \\\r\n  https://a.test", []string{"curl", "-s", "https://a.test"}},
+		{"continuation inside double quotes", "curl -d \"a\\\nb\"", []string{"curl", "-d", "ab"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := splitShell(tt.command)
+			if err != nil {
+				t.Fatalf("splitShell(%q) error: %v", tt.command, err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("splitShell(%q) = %q, want %q", tt.command, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSplitShellUnterminated(t *testing.T) {
+	for _, command := range []string{`curl 'a`, `curl "a`, `curl $'a`, `curl "a\"`} {
+		if _, err := splitShell(command); err == nil {
+			t.Errorf("splitShell(%q) succeeded, want an error", command)
+		}
+	}
+}
+
+func TestCurl(t *testing.T) {
+	tests := []struct {
+		name     string
+		command  string
+		files    map[string]string
+		method   string
+		url      string
+		body     string
+		headers  map[string]string
+		protocol string
+		hosts    map[string]string
+		insecure bool
+		skipped  []string
+	}{
+		{
+			name:    "plain get",
+			command: "curl https://api.test/users",
+			method:  "GET",
+			url:     "https://api.test/users",
+		},
+		{
+			name:    "url without scheme",
+			command: "curl api.test/users",
+			method:  "GET",
+			url:     "http://api.test/users",
+		},
+		{
+			name:    "data is posted as a form",
+			command: "curl https://api.test/login -d user=a -d pass=b",
+			method:  "POST",
+			url:     "https://api.test/login",
+			body:    "user=a&pass=b",
+			headers: map[string]string{"Content-Type": "application/x-www-form-urlencoded"},
+		},
+		{
+			name:     "combined short flags",
+			command:  "curl -sSk https://api.test/",
+			method:   "GET",
+			url:      "https://api.test/",
+			insecure: true,
+		},
+		{
+			name:     "combined short flags ending in a value",
+			command:  "curl -sSkXPUT https://api.test/items/1",
+			method:   "PUT",
+			url:      "https://api.test/items/1",
+			insecure: true,
+		},
+		{
+			name:    "combined short flags with a separate value",
+			command: "curl -sX DELETE https://api.test/items/1",
+			method:  "DELETE",
+			url:     "https://api.test/items/1",
+		},
+		{
+			name:    "ansi-c quoted header and body",
+			command: `curl https://api.test/notes -H $'X-Note: a\tb' --data-raw $'line1\nline2'`,
+			method:  "POST",
+			url:     "https://api.test/notes",
+			body:    "line1\nline2",
+			headers: map[string]string{
+				"X-Note":       "a\tb",
+				"Content-Type": "application/x-www-form-urlencoded",
+			},
+		},
+		{
+			name:    "json",
+			command: `curl --json '{"a":1}' https://api.test/items`,
+			method:  "POST",
+			url:     "https://api.test/items",
+			body:    `{"a":1}`,
+			headers: map[string]string{"Content-Type": "application/json", "Accept": "application/json"},
+		},
+		{
+			name:    "data-urlencode forms",
+			command: `curl https://api.test/search --data-urlencode 'q=a b&c' --data-urlencode '=x/y' --data-urlencode 'msg@msg.txt'`,
+			files:   map[string]string{"msg.txt": "hi there"},
+			method:  "POST",
+			url:     "https://api.test/search",
+			body:    "q=a+b%26c&x%2Fy&msg=hi+there",
+			headers: map[string]string{"Content-Type": "application/x-www-form-urlencoded"},
+		},
+		{
+			name:    "get moves data to the query string",
+			command: `curl -G https://api.test/search?page=2 --data-urlencode 'q=a b' -d limit=5`,
+			method:  "GET",
+			url:     "https://api.test/search?page=2&q=a+b&limit=5",
+		},
+		{
+			name:    "data from a file",
+			command: "curl https://api.test/items --data-binary @item.json -H 'Content-Type: application/json'",
+			files:   map[string]string{"item.json": "{\n\"a\": 1\n}"},
+			method:  "POST",
+			url:     "https://api.test/items",
+			body:    "{\n\"a\": 1\n}",
+			headers: map[string]string{"Content-Type": "application/json"},
+		},
+		{
+			name:    "missing file",
+			command: "curl https://api.test/items -d @item.json",
+			method:  "GET",
+			url:     "https://api.test/items",
+			skipped: []string{"-d @item.json"},
+		},
+		{
+			name:    "basic auth",
+			command: "curl -u alice:secret https://api.test/me",
+			method:  "GET",
+			url:     "https://api.test/me",
+			headers: map[string]string{"Authorization": "Basic YWxpY2U6c2VjcmV0"},
+		},
+		{
+			name:    "explicit authorization wins over -u",
+			command: "curl -u alice:secret -H 'Authorization: Bearer t' https://api.test/me",
+			method:  "GET",
+			url:     "https://api.test/me",
+			headers: map[string]string{"Authorization": "Bearer t"},
+		},
+		{
+			name:    "repeated cookies",
+			command: "curl -b a=1 --cookie b=2 https://api.test/",
+			method:  "GET",
+			url:     "https://api.test/",
+			headers: map[string]string{"Cookie": "a=1; b=2"},
+		},
+		{
+			name:    "resolve",
+			command: "curl --resolve api.test:443:10.0.0.5,10.0.0.6 https://api.test/",
+			method:  "GET",
+			url:     "https://api.test/",
+			hosts:   map[string]string{"api.test": "10.0.0.5"},
+		},
+		{
+			name:    "resolve ipv6",
+			command: "curl --resolve +api.test:443:[2001:db8::1] https://api.test/",
+			method:  "GET",
+			url:     "https://api.test/",
+			hosts:   map[string]string{"api.test": "2001:db8::1"},
+		},
+		{
+			name:    "resolve without an address",
+			command: "curl --resolve api.test:443:backend https://api.test/",
+			method:  "GET",
+			url:     "https://api.test/",
+			skipped: []string{"--resolve api.test:443:backend"},
+		},
+		{
+			name:     "http2 over cleartext",
+			command:  "curl --http2 http://api.test/",
+			method:   "GET",
+			url:      "http://api.test/",
+			protocol: "h2c",
+		},
+		{
+			name:    "unsupported and output flags",
+			command: "curl -L -o out.txt --max-time 5 -F file=@a.png https://api.test/",
+			method:  "GET",
+			url:     "https://api.test/",
+			skipped: []string{"-L", "--max-time 5", "-F file=@a.png"},
+		},
+		{
+			name:    "host header and connection headers",
+			command: "curl -H 'Host: other.test' -H 'Connection: close' -H 'Accept:' https://api.test/",
+			method:  "GET",
+			url:     "https://api.test/",
+			skipped: []string{"-H Host: other.test"},
+		},
+		{
+			name:    "second url",
+			command: "curl https://api.test/a https://api.test/b",
+			method:  "GET",
+			url:     "https://api.test/a",
+			skipped: []string{"https://api.test/b"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := Curl(tt.command, tt.files)
+			if err != nil {
+				t.Fatalf("Curl(%q) error: %v", tt.command, err)
+			}
+			if result.Config.HTTPMethod != tt.method {
+				t.Errorf("method = %q, want %q", result.Config.HTTPMethod, tt.method)
+			}
+			if result.TargetURL != tt.url {
+				t.Errorf("url = %q, want %q", result.TargetURL, tt.url)
+			}
+			if result.Config.Body != tt.body {
+				t.Errorf("body = %q, want %q", result.Config.Body, tt.body)
+			}
+			if !reflect.DeepEqual(result.Config.Headers, tt.headers) {
+				t.Errorf("headers = %v, want %v", result.Config.Headers, tt.headers)
+			}
+			if result.Config.Protocol != tt.protocol {
+				t.Errorf("protocol = %q, want %q", result.Config.Protocol, tt.protocol)
+			}
+			var hosts map[string]string
+			if result.Config.Network != nil {
+				hosts = result.Config.Network.Hosts
+			}
+			if !reflect.DeepEqual(hosts, tt.hosts) {
+				t.Errorf("hosts = %v, want %v", hosts, tt.hosts)
+			}
+			insecure := result.Config.TLS != nil && result.Config.TLS.InsecureSkipVerify
+			if insecure != tt.insecure {
+				t.Errorf("insecure = %v, want %v", insecure, tt.insecure)
+			}
+			var skipped []string
+			for _, s := range result.Skipped {
+				skipped = append(skipped, s.Item)
+			}
+			if !reflect.DeepEqual(skipped, tt.skipped) {
+				t.Errorf("skipped = %q, want %q", skipped, tt.skipped)
+			}
+		})
+	}
+}
+
+func TestCurlErrors(t *testing.T) {
+	tests := []struct {
+		name    string
+		command string
+	}{
+		{"not curl", "wget https://api.test/"},
+		{"empty", "   "},
+		{"no url", "curl -s"},
+		{"missing argument", "curl https://api.test/ -H"},
+		{"unterminated quote", "curl 'https://api.test/"},
+		{"not http", "curl ftp://api.test/file"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := Curl(tt.command, nil); err == nil {
+				t.Errorf("Curl(%q) succeeded, want an error", tt.command)
+			}
+		})
+	}
+}
@@ -0,0 +1,248 @@
+// Package importer drafts load tests from recordings and specs that already
+// exist elsewhere, such as HAR files.
+package importer
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"path"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/Vinayak9769/loadagg/pkg/models"
+)
+
+// HAROptions filter the entries of a HAR file.
+type HAROptions struct {
+	// Hosts to keep; empty keeps every host. "*.example.com" matches the
+	// subdomains of example.com.
+	Hosts         []string
+	IncludeStatic bool
+}
+
+type harFile struct {
+	Log struct {
+		Pages []struct {
+			Title string `json:"title"`
+		} `json:"pages"`
+		Entries []harEntry `json:"entries"`
+	} `json:"log"`
+}
+
+type harEntry struct {
+	StartedDateTime time.Time `json:"startedDateTime"`
+	Time            float64   `json:"time"` // milliseconds
+	ResourceType    string    `json:"_resourceType"`
+	Request         struct {
+		Method   string         `json:"method"`
+		URL      string         `json:"url"`
+		Headers  []harNameValue `json:"headers"`
+		PostData *struct {
+			MimeType string         `json:"mimeType"`
+			Text     string         `json:"text"`
+			Params   []harNameValue `json:"params"`
+		} `json:"postData"`
+	} `json:"request"`
+	Response struct {
+		Content struct {
+			MimeType string `json:"mimeType"`
+		} `json:"content"`
+	} `json:"response"`
+}
+
+type harNameValue struct {
+	Name  string `json:"name"`
+	Value string `json:"value"`
+}
+
+// Headers that describe the recorded connection rather than the request;
+// the worker's transport sets its own.
+var connectionHeaders = map[string]bool{
+	"host":              true,
+	"content-length":    true,
+	"connection":        true,
+	"keep-alive":        true,
+	"proxy-connection":  true,
+	"transfer-encoding": true,
+	"upgrade":           true,
+	"te":                true,
+}
+
+var staticExtensions = map[string]bool{
+	".js": true, ".mjs": true, ".css": true, ".map": true,
+	".png": true, ".jpg": true, ".jpeg": true, ".gif": true, ".svg": true, ".ico": true, ".webp": true, ".avif": true,
+	".woff": true, ".woff2": true, ".ttf": true, ".otf": true, ".eot": true,
+	".mp4": true, ".webm": true, ".mp3": true,
+}
+
+var staticResourceTypes = map[string]bool{
+	"script": true, "stylesheet": true, "image": true, "font": true, "media": true, "manifest": true,
+}
+
+// HAR turns the entries of a HAR file into the steps of one iteration, in
+// recorded order. The pause between the end of a kept request and the start
+// of the next becomes that step's think time.
+func HAR(data []byte, opts HAROptions) (*models.ImportResult, error) {
+	var har harFile
+	if err := json.Unmarshal(data, &har); err != nil {
+		return nil, fmt.Errorf("invalid HAR file: %v", err)
+	}
+	if len(har.Log.Entries) == 0 {
+		return nil, fmt.Errorf("HAR file has no entries")
+	}
+
+	entries := har.Log.Entries
+	sort.SliceStable(entries, func(i, j int) bool {
+		return entries[i].StartedDateTime.Before(entries[j].StartedDateTime)
+	})
+
+	result := &models.ImportResult{
+		Name:   "HAR import",
+		Config: draftConfig(),
+		Hosts:  make(map[string]int),
+	}
+	if len(har.Log.Pages) > 0 && har.Log.Pages[0].Title != "" {
+		result.Name = har.Log.Pages[0].Title
+	}
+
+	var lastEnd time.Time
+	for _, entry := range entries {
+		u, err := url.Parse(entry.Request.URL)
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") {
+			result.Skipped = append(result.Skipped, models.ImportSkipped{Item: entry.Request.URL, Reason: "not an http or https URL"})
+			continue
+		}
+		result.Hosts[u.Hostname()]++
+
+		if !matchHost(opts.Hosts, u.Hostname()) {
+			continue
+		}
+		if !opts.IncludeStatic && isStatic(entry, u) {
+			result.Skipped = append(result.Skipped, models.ImportSkipped{Item: entry.Request.URL, Reason: "static asset"})
+			continue
+		}
+
+		step := harStep(entry)
+		if !lastEnd.IsZero() {
+			step.ThinkTimeMS = max(0, int(entry.StartedDateTime.Sub(lastEnd).Milliseconds()))
+		}
+		lastEnd = entry.StartedDateTime.Add(time.Duration(entry.Time * float64(time.Millisecond)))
+		result.Config.Steps = append(result.Config.Steps, step)
+	}
+
+	if len(result.Config.Steps) == 0 {
+		return nil, fmt.Errorf("no requests left after filtering")
+	}
+	first := result.Config.Steps[0]
+	result.TargetURL = first.URL
+	result.Config.HTTPMethod = first.Method
+	return result, nil
+}
+
+func harStep(entry harEntry) models.RequestSpec {
+	req := entry.Request
+	step := models.RequestSpec{
+		Method:  strings.ToUpper(req.Method),
+		URL:     req.URL,
+		Headers: make(map[string]string),
+	}
+	if u, err := url.Parse(req.URL); err == nil {
+		step.Name = step.Method + " " + u.Path
+	}
+
+	for _, h := range req.Headers {
+		name := h.Name
+		if strings.HasPrefix(name, ":") || connectionHeaders[strings.ToLower(name)] {
+			continue
+		}
+		addHeader(step.Headers, name, h.Value)
+	}
+
+	if post := req.PostData; post != nil {
+		step.Body = post.Text
+		if step.Body == "" && len(post.Params) > 0 {
+			form := url.Values{}
+			for _, p := range post.Params {
+				form.Add(p.Name, p.Value)
+			}
+			step.Body = form.Encode()
+		}
+		if post.MimeType != "" && !hasHeader(step.Headers, "Content-Type") {
+			step.Headers["Content-Type"] = post.MimeType
+		}
+	}
+	if len(step.Headers) == 0 {
+		step.Headers = nil
+	}
+	return step
+}
+
+// addHeader merges repeated headers the way HTTP allows, cookies with "; ".
+func addHeader(headers map[string]string, name, value string) {
+	for existing := range headers {
+		if strings.EqualFold(existing, name) {
+			sep := ", "
+			if strings.EqualFold(name, "Cookie") {
+				sep = "; "
+			}
+			headers[existing] += sep + value
+			return
+		}
+	}
+	headers[name] = value
+}
+
+func hasHeader(headers map[string]string, name string) bool {
+	for existing := range headers {
+		if strings.EqualFold(existing, name) {
+			return true
+		}
+	}
+	return false
+}
+
+func matchHost(patterns []string, host string) bool {
+	if len(patterns) == 0 {
+		return true
+	}
+	host = strings.ToLower(host)
+	for _, pattern := range patterns {
+		pattern = strings.ToLower(strings.TrimSpace(pattern))
+		if suffix, ok := strings.CutPrefix(pattern, "*."); ok {
+			if strings.HasSuffix(host, "."+suffix) {
+				return true
+			}
+		} else if host == pattern {
+			return true
+		}
+	}
+	return false
+}
+
+// isStatic tells assets a browser fetches on its own apart from the
+// requests a user's actions cause.
+func isStatic(entry harEntry, u *url.URL) bool {
+	if staticResourceTypes[entry.ResourceType] {
+		return true
+	}
+	if staticExtensions[strings.ToLower(path.Ext(u.Path))] {
+		return true
+	}
+	mime := entry.Response.Content.MimeType
+	return strings.HasPrefix(mime, "image/") || strings.HasPrefix(mime, "font/") ||
+		strings.HasPrefix(mime, "text/css") || strings.Contains(mime, "javascript")
+}
+
+// draftConfig is the starting point of imported tests: a single worker at
+// a low rate, to be raised once the draft has been reviewed.
+func draftConfig() models.LoadTestConfig {
+	return models.LoadTestConfig{
+		Duration:       120,
+		RequestsPerSec: 1,
+		WorkerCount:    1,
+		HTTPMethod:     "GET",
+		TargetType:     models.TargetHTTP,
+	}
+}
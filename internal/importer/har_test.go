@@ -0,0 +1,210 @@
+package importer
+
+import (
+	"reflect"
+	"testing"
+)
+
+// testHAR is out of recorded order on purpose; the import sorts by start time.
+const testHAR = `{
+  "log": {
+    "pages": [{"title": "Checkout"}],
+    "entries": [
+      {
+        "startedDateTime": "2024-05-01T10:00:00.000Z", "time": 100,
+        "request": {
+          "method": "get", "url": "https://app.test/",
+          "headers": [
+            {"name": ":authority", "value": "app.test"},
+            {"name": "Cookie", "value": "a=1"},
+            {"name": "cookie", "value": "b=2"},
+            {"name": "Connection", "value": "keep-alive"},
+            {"name": "Accept", "value": "text/html"}
+          ]
+        },
+        "response": {"content": {"mimeType": "text/html"}}
+      },
+      {
+        "startedDateTime": "2024-05-01T10:00:00.400Z", "time": 200,
+        "request": {
+          "method": "POST", "url": "https://api.app.test/login",
+          "postData": {
+            "mimeType": "application/x-www-form-urlencoded",
+            "params": [{"name": "user", "value": "a"}, {"name": "pass", "value": "b c"}]
+          }
+        },
+        "response": {"content": {"mimeType": "application/json"}}
+      },
+      {
+        "startedDateTime": "2024-05-01T10:00:00.150Z", "time": 50,
+        "request": {"method": "GET", "url": "https://app.test/static/app.js"},
+        "response": {"content": {"mimeType": "text/plain"}}
+      },
+      {
+        "startedDateTime": "2024-05-01T10:00:00.300Z", "time": 20, "_resourceType": "image",
+        "request": {"method": "GET", "url": "https://cdn.other.test/logo"},
+        "response": {"content": {"mimeType": "application/octet-stream"}}
+      },
+      {
+        "startedDateTime": "2024-05-01T10:00:01.000Z", "time": 10,
+        "request": {
+          "method": "PUT", "url": "https://app.test/api/cart",
+          "postData": {"mimeType": "application/json", "text": "{\"id\":1}"}
+        },
+        "response": {"content": {"mimeType": "application/json"}}
+      },
+      {
+        "startedDateTime": "2024-05-01T10:00:02.000Z", "time": 10,
+        "request": {"method": "GET", "url": "wss://app.test/socket"},
+        "response": {"content": {"mimeType": ""}}
+      }
+    ]
+  }
+}`
+
+func TestHAR(t *testing.T) {
+	type step struct {
+		method string
+		url    string
+		think  int
+	}
+	tests := []struct {
+		name    string
+		opts    HAROptions
+		steps   []step
+		skipped []string
+	}{
+		{
+			name: "static assets dropped",
+			steps: []step{
+				{"GET", "https://app.test/", 0},
+				{"POST", "https://api.app.test/login", 300},
+				{"PUT", "https://app.test/api/cart", 400},
+			},
+			skipped: []string{"https://app.test/static/app.js", "https://cdn.other.test/logo", "wss://app.test/socket"},
+		},
+		{
+			name: "static assets kept",
+			opts: HAROptions{IncludeStatic: true},
+			steps: []step{
+				{"GET", "https://app.test/", 0},
+				{"GET", "https://app.test/static/app.js", 50},
+				{"GET", "https://cdn.other.test/logo", 100},
+				{"POST", "https://api.app.test/login", 80},
+				{"PUT", "https://app.test/api/cart", 400},
+			},
+			skipped: []string{"wss://app.test/socket"},
+		},
+		{
+			name: "exact host",
+			opts: HAROptions{Hosts: []string{"APP.test"}},
+			steps: []step{
+				{"GET", "https://app.test/", 0},
+				{"PUT", "https://app.test/api/cart", 900},
+			},
+			skipped: []string{"https://app.test/static/app.js", "wss://app.test/socket"},
+		},
+		{
+			name: "subdomain wildcard",
+			opts: HAROptions{Hosts: []string{"*.app.test"}},
+			steps: []step{
+				{"POST", "https://api.app.test/login", 0},
+			},
+			skipped: []string{"wss://app.test/socket"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := HAR([]byte(testHAR), tt.opts)
+			if err != nil {
+				t.Fatalf("HAR error: %v", err)
+			}
+			var steps []step
+			for _, s := range result.Config.Steps {
+				steps = append(steps, step{s.Method, s.URL, s.ThinkTimeMS})
+			}
+			if !reflect.DeepEqual(steps, tt.steps) {
+				t.Errorf("steps = %v, want %v", steps, tt.steps)
+			}
+			var skipped []string
+			for _, s := range result.Skipped {
+				skipped = append(skipped, s.Item)
+			}
+			if !reflect.DeepEqual(skipped, tt.skipped) {
+				t.Errorf("skipped = %q, want %q", skipped, tt.skipped)
+			}
+			if result.TargetURL != tt.steps[0].url || result.Config.HTTPMethod != tt.steps[0].method {
+				t.Errorf("target = %s %s, want the first step", result.Config.HTTPMethod, result.TargetURL)
+			}
+		})
+	}
+}
+
+func TestHARRequests(t *testing.T) {
+	result, err := HAR([]byte(testHAR), HAROptions{})
+	if err != nil {
+		t.Fatalf("HAR error: %v", err)
+	}
+	if result.Name != "Checkout" {
+		t.Errorf("name = %q, want the page title", result.Name)
+	}
+	wantHosts := map[string]int{"app.test": 3, "api.app.test": 1, "cdn.other.test": 1}
+	if !reflect.DeepEqual(result.Hosts, wantHosts) {
+		t.Errorf("hosts = %v, want %v", result.Hosts, wantHosts)
+	}
+
+	tests := []struct {
+		name    string
+		body    string
+		headers map[string]string
+	}{
+		{
+			name:    "GET /",
+			headers: map[string]string{"Cookie": "a=1; b=2", "Accept": "text/html"},
+		},
+		{
+			name:    "POST /login",
+			body:    "pass=b+c&user=a",
+			headers: map[string]string{"Content-Type": "application/x-www-form-urlencoded"},
+		},
+		{
+			name:    "PUT /api/cart",
+			body:    `{"id":1}`,
+			headers: map[string]string{"Content-Type": "application/json"},
+		},
+	}
+	if len(result.Config.Steps) != len(tests) {
+		t.Fatalf("got %d steps, want %d", len(result.Config.Steps), len(tests))
+	}
+	for i, tt := range tests {
+		s := result.Config.Steps[i]
+		if s.Name != tt.name {
+			t.Errorf("step %d name = %q, want %q", i, s.Name, tt.name)
+		}
+		if s.Body != tt.body {
+			t.Errorf("%s body = %q, want %q", tt.name, s.Body, tt.body)
+		}
+		if !reflect.DeepEqual(s.Headers, tt.headers) {
+			t.Errorf("%s headers = %v, want %v", tt.name, s.Headers, tt.headers)
+		}
+	}
+}
+
+func TestHARErrors(t *testing.T) {
+	tests := []struct {
+		name string
+		data string
+		opts HAROptions
+	}{
+		{"not json", "<html>", HAROptions{}},
+		{"no entries", `{"log": {"entries": []}}`, HAROptions{}},
+		{"everything filtered", testHAR, HAROptions{Hosts: []string{"none.test"}}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := HAR([]byte(tt.data), tt.opts); err == nil {
+				t.Error("HAR succeeded, want an error")
+			}
+		})
+	}
+}
@@ -0,0 +1,610 @@
+package importer
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"slices"
+	"sort"
+	"strings"
+
+	"github.com/Vinayak9769/loadagg/pkg/models"
+	"sigs.k8s.io/yaml"
+)
+
+// maxRefDepth stops following $refs of recursive schemas.
+const maxRefDepth = 8
+
+// methodOrder lists the operations of a path item in the order they are
+// offered.
+var methodOrder = []string{"get", "put", "post", "delete", "options", "head", "patch", "trace"}
+
+// Operation is an operation of an OpenAPI spec that can be selected for a
+// test.
+type Operation struct {
+	ID          string `json:"id"` // operationId, or "METHOD /path" without one
+	Method      string `json:"method"`
+	Path        string `json:"path"`
+	Summary     string `json:"summary,omitempty"`
+	OperationID string `json:"operation_id,omitempty"`
+}
+
+// OperationSelection picks an operation by its ID and gives its share of
+// the traffic.
+type OperationSelection struct {
+	ID     string `json:"id"`
+	Weight int    `json:"weight"`
+}
+
+// OpenAPIOptions choose what an OpenAPI import drafts.
+type OpenAPIOptions struct {
+	// BaseURL overrides the first server of the spec.
+	BaseURL string
+	// Select lists the operations to test; empty selects all with weight 1.
+	Select []OperationSelection
+	// Feeder columns named like a parameter or body property provide its
+	// values instead of the spec's examples.
+	Feeder *models.Feeder
+}
+
+type openAPIDoc struct {
+	OpenAPI string `json:"openapi"`
+	Info    struct {
+		Title string `json:"title"`
+	} `json:"info"`
+	Servers []struct {
+		URL       string `json:"url"`
+		Variables map[string]struct {
+			Default string `json:"default"`
+		} `json:"variables"`
+	} `json:"servers"`
+	Paths      map[string]map[string]json.RawMessage `json:"paths"`
+	Components struct {
+		Schemas       map[string]*openAPISchema      `json:"schemas"`
+		Parameters    map[string]*openAPIParameter   `json:"parameters"`
+		RequestBodies map[string]*openAPIRequestBody `json:"requestBodies"`
+		Examples      map[string]*openAPIExample     `json:"examples"`
+	} `json:"components"`
+}
+
+type openAPIOperation struct {
+	OperationID string              `json:"operationId"`
+	Summary     string              `json:"summary"`
+	Parameters  []*openAPIParameter `json:"parameters"`
+	RequestBody *openAPIRequestBody `json:"requestBody"`
+}
+
+type openAPIParameter struct {
+	Ref      string                     `json:"$ref"`
+	Name     string                     `json:"name"`
+	In       string                     `json:"in"`
+	Required bool                       `json:"required"`
+	Example  any                        `json:"example"`
+	Examples map[string]*openAPIExample `json:"examples"`
+	Schema   *openAPISchema             `json:"schema"`
+}
+
+type openAPIRequestBody struct {
+	Ref     string                       `json:"$ref"`
+	Content map[string]*openAPIMediaType `json:"content"`
+}
+
+type openAPIMediaType struct {
+	Example  any                        `json:"example"`
+	Examples map[string]*openAPIExample `json:"examples"`
+	Schema   *openAPISchema             `json:"schema"`
+}
+
+type openAPIExample struct {
+	Ref   string `json:"$ref"`
+	Value any    `json:"value"`
+}
+
+type openAPISchema struct {
+	Ref        string                    `json:"$ref"`
+	Type       schemaType                `json:"type"`
+	Format     string                    `json:"format"`
+	Example    any                       `json:"example"`
+	Examples   []any                     `json:"examples"`
+	Default    any                       `json:"default"`
+	Enum       []any                     `json:"enum"`
+	Properties map[string]*openAPISchema `json:"properties"`
+	Items      *openAPISchema            `json:"items"`
+	AllOf      []*openAPISchema          `json:"allOf"`
+	OneOf      []*openAPISchema          `json:"oneOf"`
+	AnyOf      []*openAPISchema          `json:"anyOf"`
+}
+
+// schemaType is a schema's type, given as a string in OpenAPI 3.0 and as a
+// list that may include "null" in 3.1.
+type schemaType string
+
+func (t *schemaType) UnmarshalJSON(data []byte) error {
+	var single string
+	if json.Unmarshal(data, &single) == nil {
+		*t = schemaType(single)
+		return nil
+	}
+	var list []string
+	if err := json.Unmarshal(data, &list); err != nil {
+		return err
+	}
+	for _, s := range list {
+		if s != "null" {
+			*t = schemaType(s)
+			break
+		}
+	}
+	return nil
+}
+
+// openAPIImport carries the state of drafting one spec.
+type openAPIImport struct {
+	doc      *openAPIDoc
+	feeder   map[string]bool
+	warnings []string
+}
+
+// OpenAPI lists the operations of an OpenAPI 3 spec, in JSON or YAML, and
+// drafts a test that spreads its load over the selected ones by weight.
+// Parameters and bodies come from feeder columns, the spec's examples or,
+// failing both, values generated from their schemas.
+func OpenAPI(spec []byte, opts OpenAPIOptions) (*models.ImportResult, []Operation, error) {
+	data, err := yaml.YAMLToJSON(spec)
+	if err != nil {
+		return nil, nil, fmt.Errorf("invalid OpenAPI spec: %v", err)
+	}
+	var doc openAPIDoc
+	if err := json.Unmarshal(data, &doc); err != nil {
+		return nil, nil, fmt.Errorf("invalid OpenAPI spec: %v", err)
+	}
+	if !strings.HasPrefix(doc.OpenAPI, "3.") {
+		return nil, nil, fmt.Errorf("only OpenAPI 3 specs are supported")
+	}
+
+	imp := &openAPIImport{doc: &doc, feeder: make(map[string]bool)}
+	if opts.Feeder != nil {
+		for _, column := range opts.Feeder.Columns {
+			imp.feeder[column] = true
+		}
+	}
+
+	operations, parsed, err := imp.operations()
+	if err != nil {
+		return nil, nil, err
+	}
+	if len(operations) == 0 {
+		return nil, nil, fmt.Errorf("spec has no operations")
+	}
+
+	base, err := imp.baseURL(opts.BaseURL)
+	if err != nil {
+		return nil, operations, err
+	}
+
+	selection := opts.Select
+	if len(selection) == 0 {
+		for _, op := range operations {
+			selection = append(selection, OperationSelection{ID: op.ID, Weight: 1})
+		}
+	}
+
+	result := &models.ImportResult{
+		Name:      doc.Info.Title,
+		TargetURL: base,
+		Config:    draftConfig(),
+	}
+	if result.Name == "" {
+		result.Name = "OpenAPI import"
+	}
+	for _, sel := range selection {
+		i := findOperation(operations, sel.ID)
+		if i < 0 {
+			return nil, operations, fmt.Errorf("operation %q not found in the spec", sel.ID)
+		}
+		endpoint := imp.endpoint(base, operations[i], parsed[i])
+		endpoint.Weight = max(1, sel.Weight)
+		result.Config.Endpoints = append(result.Config.Endpoints, endpoint)
+	}
+	result.Config.HTTPMethod = result.Config.Endpoints[0].Method
+	result.Config.Feeder = opts.Feeder
+	result.Warnings = imp.warnings
+	return result, operations, nil
+}
+
+func findOperation(operations []Operation, id string) int {
+	for i, op := range operations {
+		if op.ID == id || op.OperationID == id {
+			return i
+		}
+	}
+	return -1
+}
+
+// operations returns the spec's operations sorted by path, with the
+// parameters of their path items merged in.
+func (imp *openAPIImport) operations() ([]Operation, []*openAPIOperation, error) {
+	paths := make([]string, 0, len(imp.doc.Paths))
+	for p := range imp.doc.Paths {
+		paths = append(paths, p)
+	}
+	sort.Strings(paths)
+
+	var operations []Operation
+	var parsed []*openAPIOperation
+	for _, p := range paths {
+		item := imp.doc.Paths[p]
+		var shared []*openAPIParameter
+		if raw, ok := item["parameters"]; ok {
+			if err := json.Unmarshal(raw, &shared); err != nil {
+				return nil, nil, fmt.Errorf("invalid parameters of %s: %v", p, err)
+			}
+		}
+
+		for _, method := range methodOrder {
+			raw, ok := item[method]
+			if !ok {
+				continue
+			}
+			var op openAPIOperation
+			if err := json.Unmarshal(raw, &op); err != nil {
+				return nil, nil, fmt.Errorf("invalid operation %s %s: %v", strings.ToUpper(method), p, err)
+			}
+			op.Parameters = mergeParameters(imp, shared, op.Parameters)
+
+			id := strings.ToUpper(method) + " " + p
+			operations = append(operations, Operation{
+				ID:          id,
+				Method:      strings.ToUpper(method),
+				Path:        p,
+				Summary:     op.Summary,
+				OperationID: op.OperationID,
+			})
+			parsed = append(parsed, &op)
+		}
+	}
+	return operations, parsed, nil
+}
+
+// mergeParameters resolves parameter refs; operation parameters override
+// path item ones with the same name and location.
+func mergeParameters(imp *openAPIImport, shared, own []*openAPIParameter) []*openAPIParameter {
+	var merged []*openAPIParameter
+	index := make(map[string]int)
+	for _, list := range [][]*openAPIParameter{shared, own} {
+		for _, p := range list {
+			p = imp.parameter(p)
+			if p == nil {
+				continue
+			}
+			key := p.In + ":" + p.Name
+			if i, ok := index[key]; ok {
+				merged[i] = p
+				continue
+			}
+			index[key] = len(merged)
+			merged = append(merged, p)
+		}
+	}
+	return merged
+}
+
+func (imp *openAPIImport) baseURL(override string) (string, error) {
+	base := override
+	if base == "" && len(imp.doc.Servers) > 0 {
+		server := imp.doc.Servers[0]
+		base = server.URL
+		for name, variable := range server.Variables {
+			base = strings.ReplaceAll(base, "{"+name+"}", variable.Default)
+		}
+	}
+	u, err := url.Parse(base)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return "", fmt.Errorf("the spec has no absolute http or https server URL, pass base_url")
+	}
+	return strings.TrimSuffix(base, "/"), nil
+}
+
+// endpoint builds the request of an operation.
+func (imp *openAPIImport) endpoint(base string, op Operation, spec *openAPIOperation) models.RequestSpec {
+	endpoint := models.RequestSpec{
+		Name:   op.Method + " " + op.Path,
+		Method: op.Method,
+	}
+
+	path := op.Path
+	var query []string
+	for _, p := range spec.Parameters {
+		value, ok := imp.parameterValue(op, p)
+		if !ok {
+			continue
+		}
+		switch p.In {
+		case "path":
+			path = strings.ReplaceAll(path, "{"+p.Name+"}", escapeUnlessTemplate(value, url.PathEscape))
+		case "query":
+			query = append(query, url.QueryEscape(p.Name)+"="+escapeUnlessTemplate(value, url.QueryEscape))
+		case "header":
+			if endpoint.Headers == nil {
+				endpoint.Headers = make(map[string]string)
+			}
+			endpoint.Headers[p.Name] = value
+		}
+	}
+	endpoint.URL = base + path
+	if len(query) > 0 {
+		endpoint.URL += "?" + strings.Join(query, "&")
+	}
+
+	if body := imp.requestBody(spec.RequestBody); body != nil {
+		contentType, payload, ok := imp.bodyPayload(op, body)
+		if ok {
+			if endpoint.Headers == nil {
+				endpoint.Headers = make(map[string]string)
+			}
+			endpoint.Headers["Content-Type"] = contentType
+			endpoint.Body = payload
+		}
+	}
+	return endpoint
+}
+
+// escapeUnlessTemplate leaves feeder references for the worker to fill in.
+func escapeUnlessTemplate(value string, escape func(string) string) string {
+	if strings.HasPrefix(value, "{{") && strings.HasSuffix(value, "}}") {
+		return value
+	}
+	return escape(value)
+}
+
+// parameterValue picks the value of a parameter. Optional parameters are
+// only sent when the spec or the feeder gives a value for them.
+func (imp *openAPIImport) parameterValue(op Operation, p *openAPIParameter) (string, bool) {
+	if p.In == "cookie" {
+		return "", false
+	}
+	if imp.feeder[p.Name] {
+		return "{{" + p.Name + "}}", true
+	}
+	if value, ok := imp.exampleOf(p.Example, p.Examples); ok {
+		return scalarString(value), true
+	}
+	schema := imp.schema(p.Schema, 0)
+	if schema != nil {
+		if value, ok := schemaExample(schema); ok {
+			return scalarString(value), true
+		}
+	}
+	if !p.Required {
+		return "", false
+	}
+	imp.warnings = append(imp.warnings, fmt.Sprintf("%s: no example for %s parameter %q, using a generated value", op.ID, p.In, p.Name))
+	return scalarString(imp.generate(p.Schema, nil)), true
+}
+
+// bodyPayload renders the request body as JSON or as a form, preferring
+// JSON when the operation accepts both.
+func (imp *openAPIImport) bodyPayload(op Operation, body *openAPIRequestBody) (string, string, bool) {
+	contentTypes := make([]string, 0, len(body.Content))
+	for contentType := range body.Content {
+		contentTypes = append(contentTypes, contentType)
+	}
+	sort.Strings(contentTypes)
+
+	pick := ""
+	for _, contentType := range contentTypes {
+		if contentType == "application/json" || strings.HasSuffix(contentType, "+json") {
+			pick = contentType
+			break
+		}
+		if contentType == "application/x-www-form-urlencoded" && pick == "" {
+			pick = contentType
+		}
+	}
+	if pick == "" {
+		imp.warnings = append(imp.warnings, fmt.Sprintf("%s: no JSON or form request body, sending none", op.ID))
+		return "", "", false
+	}
+
+	media := body.Content[pick]
+	value, ok := imp.exampleOf(media.Example, media.Examples)
+	if !ok {
+		schema := imp.schema(media.Schema, 0)
+		if value, ok = schemaExample(schema); !ok {
+			value = imp.generate(media.Schema, nil)
+		}
+	}
+
+	if pick == "application/x-www-form-urlencoded" {
+		object, _ := value.(map[string]any)
+		names := make([]string, 0, len(object))
+		for name := range object {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		fields := make([]string, len(names))
+		for i, name := range names {
+			fields[i] = url.QueryEscape(name) + "=" + escapeUnlessTemplate(scalarString(object[name]), url.QueryEscape)
+		}
+		return pick, strings.Join(fields, "&"), true
+	}
+	payload, err := json.Marshal(value)
+	if err != nil {
+		return "", "", false
+	}
+	return pick, string(payload), true
+}
+
+// exampleOf returns an inline example or the first named one.
+func (imp *openAPIImport) exampleOf(example any, examples map[string]*openAPIExample) (any, bool) {
+	if example != nil {
+		return example, true
+	}
+	names := make([]string, 0, len(examples))
+	for name := range examples {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		if ex := imp.example(examples[name]); ex != nil && ex.Value != nil {
+			return ex.Value, true
+		}
+	}
+	return nil, false
+}
+
+// schemaExample returns a value the schema itself suggests.
+func schemaExample(schema *openAPISchema) (any, bool) {
+	switch {
+	case schema == nil:
+		return nil, false
+	case schema.Example != nil:
+		return schema.Example, true
+	case len(schema.Examples) > 0:
+		return schema.Examples[0], true
+	case schema.Default != nil:
+		return schema.Default, true
+	case len(schema.Enum) > 0:
+		return schema.Enum[0], true
+	}
+	return nil, false
+}
+
+// generate builds a value matching schema, taking examples of nested
+// schemas and feeder references for properties named like a column.
+// Properties that refer back to a schema being generated are left out.
+func (imp *openAPIImport) generate(schema *openAPISchema, refs []string) any {
+	if schema != nil && schema.Ref != "" {
+		if slices.Contains(refs, schema.Ref) || len(refs) > maxRefDepth {
+			return nil
+		}
+		refs = append(refs, schema.Ref)
+	}
+	schema = imp.schema(schema, 0)
+	if schema == nil {
+		return nil
+	}
+	if value, ok := schemaExample(schema); ok {
+		return value
+	}
+
+	if len(schema.AllOf) > 0 {
+		merged := map[string]any{}
+		for _, part := range schema.AllOf {
+			if object, ok := imp.generate(part, refs).(map[string]any); ok {
+				for k, v := range object {
+					merged[k] = v
+				}
+			}
+		}
+		return merged
+	}
+	for _, alternatives := range [][]*openAPISchema{schema.OneOf, schema.AnyOf} {
+		if len(alternatives) > 0 {
+			return imp.generate(alternatives[0], refs)
+		}
+	}
+
+	switch {
+	case schema.Type == "object" || (schema.Type == "" && len(schema.Properties) > 0):
+		object := make(map[string]any, len(schema.Properties))
+		for name, property := range schema.Properties {
+			if imp.feeder[name] {
+				object[name] = "{{" + name + "}}"
+				continue
+			}
+			if value := imp.generate(property, refs); value != nil {
+				object[name] = value
+			}
+		}
+		return object
+	case schema.Type == "array":
+		if item := imp.generate(schema.Items, refs); item != nil {
+			return []any{item}
+		}
+		return []any{}
+	case schema.Type == "integer":
+		return 1
+	case schema.Type == "number":
+		return 1.5
+	case schema.Type == "boolean":
+		return true
+	}
+	switch schema.Format {
+	case "date":
+		return "2024-01-01"
+	case "date-time":
+		return "2024-01-01T00:00:00Z"
+	case "uuid":
+		return "00000000-0000-0000-0000-000000000000"
+	case "email":
+		return "user@example.com"
+	}
+	return "string"
+}
+
+func scalarString(value any) string {
+	switch v := value.(type) {
+	case nil:
+		return ""
+	case string:
+		return v
+	case float64:
+		return strings.TrimSuffix(strings.TrimRight(fmt.Sprintf("%f", v), "0"), ".")
+	case int, bool:
+		return fmt.Sprint(v)
+	default:
+		data, _ := json.Marshal(v)
+		return string(data)
+	}
+}
+
+// refName returns the component name of a local $ref of the given kind.
+func refName(ref, kind string) (string, bool) {
+	return strings.CutPrefix(ref, "#/components/"+kind+"/")
+}
+
+func (imp *openAPIImport) schema(schema *openAPISchema, depth int) *openAPISchema {
+	for schema != nil && schema.Ref != "" && depth <= maxRefDepth {
+		name, ok := refName(schema.Ref, "schemas")
+		if !ok {
+			return nil
+		}
+		schema = imp.doc.Components.Schemas[name]
+		depth++
+	}
+	return schema
+}
+
+func (imp *openAPIImport) parameter(p *openAPIParameter) *openAPIParameter {
+	for depth := 0; p != nil && p.Ref != "" && depth <= maxRefDepth; depth++ {
+		name, ok := refName(p.Ref, "parameters")
+		if !ok {
+			return nil
+		}
+		p = imp.doc.Components.Parameters[name]
+	}
+	return p
+}
+
+func (imp *openAPIImport) requestBody(body *openAPIRequestBody) *openAPIRequestBody {
+	for depth := 0; body != nil && body.Ref != "" && depth <= maxRefDepth; depth++ {
+		name, ok := refName(body.Ref, "requestBodies")
+		if !ok {
+			return nil
+		}
+		body = imp.doc.Components.RequestBodies[name]
+	}
+	return body
+}
+
+func (imp *openAPIImport) example(ex *openAPIExample) *openAPIExample {
+	for depth := 0; ex != nil && ex.Ref != "" && depth <= maxRefDepth; depth++ {
+		name, ok := refName(ex.Ref, "examples")
+		if !ok {
+			return nil
+		}
+		ex = imp.doc.Components.Examples[name]
+	}
+	return ex
+}
@@ -0,0 +1,275 @@
+package importer
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/Vinayak9769/loadagg/pkg/models"
+)
+
+const testOpenAPI = `
+openapi: "3.0.3"
+info:
+  title: Shop
+servers:
+  - url: https://{env}.shop.test/v1/
+    variables:
+      env:
+        default: api
+paths:
+  /items/{id}:
+    parameters:
+      - name: id
+        in: path
+        required: true
+        schema:
+          type: integer
+    get:
+      operationId: getItem
+      parameters:
+        - $ref: '#/components/parameters/Fields'
+        - name: X-Trace
+          in: header
+          example: abc
+        - name: session
+          in: cookie
+          required: true
+    delete:
+      summary: Delete an item
+  /search:
+    get:
+      operationId: search
+      parameters:
+        - name: q
+          in: query
+          required: true
+          schema:
+            type: string
+            example: red shoes
+        - name: page
+          in: query
+          schema:
+            type: integer
+  /orders:
+    post:
+      operationId: createOrder
+      requestBody:
+        $ref: '#/components/requestBodies/Order'
+  /login:
+    post:
+      requestBody:
+        content:
+          application/x-www-form-urlencoded:
+            schema:
+              type: object
+              properties:
+                user:
+                  type: string
+                  example: a b
+                remember:
+                  type: boolean
+  /upload:
+    put:
+      requestBody:
+        content:
+          application/octet-stream: {}
+components:
+  parameters:
+    Fields:
+      name: fields
+      in: query
+      example: name,price
+  requestBodies:
+    Order:
+      content:
+        application/json:
+          schema:
+            $ref: '#/components/schemas/Order'
+  schemas:
+    Order:
+      type: object
+      properties:
+        sku:
+          type: string
+        quantity:
+          type: integer
+          example: 2
+        created:
+          type: string
+          format: date-time
+        lines:
+          type: array
+          items:
+            $ref: '#/components/schemas/Order'
+`
+
+func TestOpenAPIOperations(t *testing.T) {
+	_, operations, err := OpenAPI([]byte(testOpenAPI), OpenAPIOptions{})
+	if err != nil {
+		t.Fatalf("OpenAPI error: %v", err)
+	}
+	want := []Operation{
+		{ID: "GET /items/{id}", Method: "GET", Path: "/items/{id}", OperationID: "getItem"},
+		{ID: "DELETE /items/{id}", Method: "DELETE", Path: "/items/{id}", Summary: "Delete an item"},
+		{ID: "POST /login", Method: "POST", Path: "/login"},
+		{ID: "POST /orders", Method: "POST", Path: "/orders", OperationID: "createOrder"},
+		{ID: "GET /search", Method: "GET", Path: "/search", OperationID: "search"},
+		{ID: "PUT /upload", Method: "PUT", Path: "/upload"},
+	}
+	if !reflect.DeepEqual(operations, want) {
+		t.Errorf("operations = %+v, want %+v", operations, want)
+	}
+}
+
+func TestOpenAPIEndpoints(t *testing.T) {
+	type endpoint struct {
+		url     string
+		body    string
+		headers map[string]string
+	}
+	tests := []struct {
+		name      string
+		opts      OpenAPIOptions
+		endpoints map[string]endpoint
+	}{
+		{
+			name: "examples and generated values",
+			endpoints: map[string]endpoint{
+				"GET /items/{id}": {
+					url:     "https://api.shop.test/v1/items/1?fields=name%2Cprice",
+					headers: map[string]string{"X-Trace": "abc"},
+				},
+				"DELETE /items/{id}": {url: "https://api.shop.test/v1/items/1"},
+				"POST /login": {
+					url:     "https://api.shop.test/v1/login",
+					body:    "remember=true&user=a+b",
+					headers: map[string]string{"Content-Type": "application/x-www-form-urlencoded"},
+				},
+				"POST /orders": {
+					url:     "https://api.shop.test/v1/orders",
+					body:    `{"created":"2024-01-01T00:00:00Z","lines":[],"quantity":2,"sku":"string"}`,
+					headers: map[string]string{"Content-Type": "application/json"},
+				},
+				"GET /search": {url: "https://api.shop.test/v1/search?q=red+shoes"},
+				"PUT /upload": {url: "https://api.shop.test/v1/upload"},
+			},
+		},
+		{
+			name: "feeder references",
+			opts: OpenAPIOptions{
+				BaseURL: "http://localhost:8080",
+				Feeder:  &models.Feeder{Columns: []string{"id", "q", "sku", "user"}},
+			},
+			endpoints: map[string]endpoint{
+				"GET /items/{id}": {
+					url:     "http://localhost:8080/items/{{id}}?fields=name%2Cprice",
+					headers: map[string]string{"X-Trace": "abc"},
+				},
+				"DELETE /items/{id}": {url: "http://localhost:8080/items/{{id}}"},
+				"POST /login": {
+					url:     "http://localhost:8080/login",
+					body:    "remember=true&user={{user}}",
+					headers: map[string]string{"Content-Type": "application/x-www-form-urlencoded"},
+				},
+				"POST /orders": {
+					url:     "http://localhost:8080/orders",
+					body:    `{"created":"2024-01-01T00:00:00Z","lines":[],"quantity":2,"sku":"{{sku}}"}`,
+					headers: map[string]string{"Content-Type": "application/json"},
+				},
+				"GET /search": {url: "http://localhost:8080/search?q={{q}}"},
+				"PUT /upload": {url: "http://localhost:8080/upload"},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, _, err := OpenAPI([]byte(testOpenAPI), tt.opts)
+			if err != nil {
+				t.Fatalf("OpenAPI error: %v", err)
+			}
+			if len(result.Config.Endpoints) != len(tt.endpoints) {
+				t.Fatalf("got %d endpoints, want %d", len(result.Config.Endpoints), len(tt.endpoints))
+			}
+			for _, e := range result.Config.Endpoints {
+				want, ok := tt.endpoints[e.Name]
+				if !ok {
+					t.Errorf("unexpected endpoint %q", e.Name)
+					continue
+				}
+				got := endpoint{url: e.URL, body: e.Body, headers: e.Headers}
+				if !reflect.DeepEqual(got, want) {
+					t.Errorf("%s = %+v, want %+v", e.Name, got, want)
+				}
+				if e.Weight != 1 {
+					t.Errorf("%s weight = %d, want 1", e.Name, e.Weight)
+				}
+			}
+		})
+	}
+}
+
+func TestOpenAPIWarnings(t *testing.T) {
+	result, _, err := OpenAPI([]byte(testOpenAPI), OpenAPIOptions{})
+	if err != nil {
+		t.Fatalf("OpenAPI error: %v", err)
+	}
+	want := []string{
+		`GET /items/{id}: no example for path parameter "id", using a generated value`,
+		`DELETE /items/{id}: no example for path parameter "id", using a generated value`,
+		`PUT /upload: no JSON or form request body, sending none`,
+	}
+	if !reflect.DeepEqual(result.Warnings, want) {
+		t.Errorf("warnings = %q, want %q", result.Warnings, want)
+	}
+	if result.Name != "Shop" || result.TargetURL != "https://api.shop.test/v1" {
+		t.Errorf("name and target = %q %q", result.Name, result.TargetURL)
+	}
+}
+
+func TestOpenAPISelect(t *testing.T) {
+	result, _, err := OpenAPI([]byte(testOpenAPI), OpenAPIOptions{
+		Select: []OperationSelection{
+			{ID: "search", Weight: 3},
+			{ID: "DELETE /items/{id}"},
+		},
+	})
+	if err != nil {
+		t.Fatalf("OpenAPI error: %v", err)
+	}
+	var got []string
+	var weights []int
+	for _, e := range result.Config.Endpoints {
+		got = append(got, e.Name)
+		weights = append(weights, e.Weight)
+	}
+	if want := []string{"GET /search", "DELETE /items/{id}"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("endpoints = %q, want %q", got, want)
+	}
+	if want := []int{3, 1}; !reflect.DeepEqual(weights, want) {
+		t.Errorf("weights = %v, want %v", weights, want)
+	}
+	if result.Config.HTTPMethod != "GET" {
+		t.Errorf("method = %q, want the first endpoint's", result.Config.HTTPMethod)
+	}
+}
+
+func TestOpenAPIErrors(t *testing.T) {
+	tests := []struct {
+		name string
+		spec string
+		opts OpenAPIOptions
+	}{
+		{"not yaml", "openapi: [", OpenAPIOptions{}},
+		{"swagger 2", `{"swagger": "2.0", "paths": {}}`, OpenAPIOptions{}},
+		{"no operations", `{"openapi": "3.1.0", "servers": [{"url": "https://a.test"}], "paths": {}}`, OpenAPIOptions{}},
+		{"relative server", `{"openapi": "3.1.0", "servers": [{"url": "/v1"}], "paths": {"/a": {"get": {}}}}`, OpenAPIOptions{}},
+		{"unknown selection", testOpenAPI, OpenAPIOptions{Select: []OperationSelection{{ID: "nope"}}}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, _, err := OpenAPI([]byte(tt.spec), tt.opts); err == nil {
+				t.Error("OpenAPI succeeded, want an error")
+			}
+		})
+	}
+}
@@ -0,0 +1,113 @@
+// Package protoset resolves gRPC method descriptors from descriptor sets,
+// whether uploaded by a user or fetched through server reflection.
+package protoset
+
+import (
+	"fmt"
+	"sort"
+
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protodesc"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/reflect/protoregistry"
+	"google.golang.org/protobuf/types/descriptorpb"
+	"google.golang.org/protobuf/types/dynamicpb"
+
+	// well-known types descriptor sets may import without including
+	_ "google.golang.org/protobuf/types/known/anypb"
+	_ "google.golang.org/protobuf/types/known/durationpb"
+	_ "google.golang.org/protobuf/types/known/emptypb"
+	_ "google.golang.org/protobuf/types/known/fieldmaskpb"
+	_ "google.golang.org/protobuf/types/known/structpb"
+	_ "google.golang.org/protobuf/types/known/timestamppb"
+	_ "google.golang.org/protobuf/types/known/wrapperspb"
+)
+
+// Parse decodes a serialized FileDescriptorSet.
+func Parse(data []byte) (*protoregistry.Files, error) {
+	var set descriptorpb.FileDescriptorSet
+	if err := proto.Unmarshal(data, &set); err != nil {
+		return nil, fmt.Errorf("invalid descriptor set: %v", err)
+	}
+	return Build(set.File)
+}
+
+// Build links file descriptors into a registry. Imports of well-known types
+// that are missing from files are taken from the linked-in definitions.
+func Build(files []*descriptorpb.FileDescriptorProto) (*protoregistry.Files, error) {
+	if len(files) == 0 {
+		return nil, fmt.Errorf("descriptor set contains no files")
+	}
+
+	present := make(map[string]bool, len(files))
+	for _, fd := range files {
+		present[fd.GetName()] = true
+	}
+	set := &descriptorpb.FileDescriptorSet{File: files}
+	for i := 0; i < len(set.File); i++ {
+		for _, dep := range set.File[i].GetDependency() {
+			if present[dep] {
+				continue
+			}
+			known, err := protoregistry.GlobalFiles.FindFileByPath(dep)
+			if err != nil {
+				return nil, fmt.Errorf("descriptor set is missing import %s, build it with --include_imports", dep)
+			}
+			present[dep] = true
+			set.File = append(set.File, protodesc.ToFileDescriptorProto(known))
+		}
+	}
+
+	registry, err := protodesc.NewFiles(set)
+	if err != nil {
+		return nil, fmt.Errorf("invalid descriptor set: %v", err)
+	}
+	return registry, nil
+}
+
+// FindMethod looks up a method of a fully qualified service.
+func FindMethod(files *protoregistry.Files, service, method string) (protoreflect.MethodDescriptor, error) {
+	desc, err := files.FindDescriptorByName(protoreflect.FullName(service))
+	if err != nil {
+		return nil, fmt.Errorf("service %s not found", service)
+	}
+	sd, ok := desc.(protoreflect.ServiceDescriptor)
+	if !ok {
+		return nil, fmt.Errorf("%s is not a service", service)
+	}
+	md := sd.Methods().ByName(protoreflect.Name(method))
+	if md == nil {
+		return nil, fmt.Errorf("method %s not found in service %s", method, service)
+	}
+	if md.IsStreamingClient() {
+		return nil, fmt.Errorf("method %s.%s uses client streaming, only unary and server-streaming calls are supported", service, method)
+	}
+	return md, nil
+}
+
+// Services lists the fully qualified names of all services in files.
+func Services(files *protoregistry.Files) []string {
+	var services []string
+	files.RangeFiles(func(fd protoreflect.FileDescriptor) bool {
+		for i := 0; i < fd.Services().Len(); i++ {
+			services = append(services, string(fd.Services().Get(i).FullName()))
+		}
+		return true
+	})
+	sort.Strings(services)
+	return services
+}
+
+// NewRequest builds the input message of md from its protobuf JSON form.
+// An empty message yields the zero value.
+func NewRequest(md protoreflect.MethodDescriptor, message []byte) (*dynamicpb.Message, error) {
+	req := dynamicpb.NewMessage(md.Input())
+	if len(message) == 0 {
+		return req, nil
+	}
+	if err := protojson.Unmarshal(message, req); err != nil {
+		return nil, fmt.Errorf("message does not match %s: %v", md.Input().FullName(), err)
+	}
+	return req, nil
+}
@@ -0,0 +1,95 @@
+package protoset
+
+import (
+	"context"
+	"fmt"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	rpb "google.golang.org/grpc/reflection/grpc_reflection_v1"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protoregistry"
+	"google.golang.org/protobuf/types/descriptorpb"
+)
+
+// v1alphaReflectionMethod is the pre-v1 name of the reflection service. Its
+// messages are wire compatible with v1, so older servers are queried with the
+// v1 types.
+const v1alphaReflectionMethod = "/grpc.reflection.v1alpha.ServerReflection/ServerReflectionInfo"
+
+// Reflect fetches the file defining service, and everything it imports,
+// through the server reflection API.
+func Reflect(ctx context.Context, conn grpc.ClientConnInterface, service string) (*protoregistry.Files, error) {
+	files, err := reflect(ctx, conn, rpb.ServerReflection_ServerReflectionInfo_FullMethodName, service)
+	if status.Code(err) == codes.Unimplemented {
+		files, err = reflect(ctx, conn, v1alphaReflectionMethod, service)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("server reflection failed: %v", err)
+	}
+	return Build(files)
+}
+
+func reflect(ctx context.Context, conn grpc.ClientConnInterface, method, service string) ([]*descriptorpb.FileDescriptorProto, error) {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	stream, err := conn.NewStream(ctx, &rpb.ServerReflection_ServiceDesc.Streams[0], method)
+	if err != nil {
+		return nil, err
+	}
+
+	var files []*descriptorpb.FileDescriptorProto
+	seen := make(map[string]bool)
+	requested := make(map[string]bool)
+	queue := []*rpb.ServerReflectionRequest{{
+		MessageRequest: &rpb.ServerReflectionRequest_FileContainingSymbol{FileContainingSymbol: service},
+	}}
+
+	for len(queue) > 0 {
+		req := queue[0]
+		queue = queue[1:]
+		if err := stream.SendMsg(req); err != nil {
+			return nil, err
+		}
+		var resp rpb.ServerReflectionResponse
+		if err := stream.RecvMsg(&resp); err != nil {
+			return nil, err
+		}
+		if e := resp.GetErrorResponse(); e != nil {
+			return nil, status.Error(codes.Code(e.GetErrorCode()), e.GetErrorMessage())
+		}
+
+		for _, raw := range resp.GetFileDescriptorResponse().GetFileDescriptorProto() {
+			var fd descriptorpb.FileDescriptorProto
+			if err := proto.Unmarshal(raw, &fd); err != nil {
+				return nil, err
+			}
+			if seen[fd.GetName()] {
+				continue
+			}
+			seen[fd.GetName()] = true
+			files = append(files, &fd)
+		}
+
+		// servers usually send the imports along, fetch any that are missing
+		for _, fd := range files {
+			for _, dep := range fd.GetDependency() {
+				if seen[dep] || requested[dep] {
+					continue
+				}
+				if _, err := protoregistry.GlobalFiles.FindFileByPath(dep); err == nil {
+					continue
+				}
+				requested[dep] = true
+				queue = append(queue, &rpb.ServerReflectionRequest{
+					MessageRequest: &rpb.ServerReflectionRequest_FileByFilename{FileByFilename: dep},
+				})
+			}
+		}
+	}
+
+	stream.CloseSend()
+	return files, nil
+}
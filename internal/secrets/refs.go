@@ -0,0 +1,159 @@
+package secrets
+
+import (
+	"bytes"
+	"encoding/json"
+	"regexp"
+	"slices"
+	"strings"
+
+	"github.com/Vinayak9769/loadagg/pkg/models"
+)
+
+// Redacted replaces secret values in anything reported back to users.
+const Redacted = "[redacted]"
+
+var (
+	// refPattern matches {{secret "name"}}, allowing spaces inside the
+	// braces
+	refPattern  = regexp.MustCompile(`\{\{\s*secret\s+"([^"]*)"\s*\}\}`)
+	namePattern = regexp.MustCompile(`^[A-Za-z0-9._-]{1,63}$`)
+)
+
+// ValidName reports whether name can be used for a secret. Names double as
+// file names where workers find the values.
+func ValidName(name string) bool {
+	return namePattern.MatchString(name) && name != "." && name != ".."
+}
+
+// References returns the names of the secrets referenced in s, in order of
+// first appearance.
+func References(s string) []string {
+	var names []string
+	seen := map[string]bool{}
+	for _, m := range refPattern.FindAllStringSubmatch(s, -1) {
+		if !seen[m[1]] {
+			seen[m[1]] = true
+			names = append(names, m[1])
+		}
+	}
+	return names
+}
+
+// Expand replaces every reference in s with the value of the secret.
+// References to secrets missing from values are left as they are.
+func Expand(s string, values map[string]string) string {
+	if !strings.Contains(s, "{{") {
+		return s
+	}
+	return refPattern.ReplaceAllStringFunc(s, func(ref string) string {
+		name := refPattern.FindStringSubmatch(ref)[1]
+		if value, ok := values[name]; ok {
+			return value
+		}
+		return ref
+	})
+}
+
+// ExpandJSON replaces every reference in the strings of a JSON document,
+// where the quotes of a reference appear escaped. The document is returned
+// unchanged when it references nothing or is not valid JSON.
+func ExpandJSON(raw json.RawMessage, values map[string]string) json.RawMessage {
+	if !bytes.Contains(raw, []byte("{{")) {
+		return raw
+	}
+	doc, err := decodeJSON(raw)
+	if err != nil {
+		return raw
+	}
+	expanded, err := json.Marshal(mapJSONStrings(doc, func(s string) string { return Expand(s, values) }))
+	if err != nil {
+		return raw
+	}
+	return expanded
+}
+
+// jsonStrings returns the strings of a JSON document, keys included.
+func jsonStrings(raw json.RawMessage) []string {
+	var texts []string
+	if doc, err := decodeJSON(raw); err == nil {
+		mapJSONStrings(doc, func(s string) string {
+			texts = append(texts, s)
+			return s
+		})
+	}
+	return texts
+}
+
+func decodeJSON(raw json.RawMessage) (any, error) {
+	decoder := json.NewDecoder(bytes.NewReader(raw))
+	decoder.UseNumber()
+	var doc any
+	err := decoder.Decode(&doc)
+	return doc, err
+}
+
+// mapJSONStrings returns a copy of a decoded JSON document with f applied
+// to every string in it.
+func mapJSONStrings(doc any, f func(string) string) any {
+	switch v := doc.(type) {
+	case string:
+		return f(v)
+	case []any:
+		out := make([]any, len(v))
+		for i, item := range v {
+			out[i] = mapJSONStrings(item, f)
+		}
+		return out
+	case map[string]any:
+		out := make(map[string]any, len(v))
+		for key, item := range v {
+			out[f(key)] = mapJSONStrings(item, f)
+		}
+		return out
+	default:
+		return v
+	}
+}
+
+// ConfigReferences returns the secrets referenced in the request headers and
+// bodies of a test, in its gRPC metadata and message, in its WebSocket
+// messages, in its proxy URL and by its TLS settings.
+func ConfigReferences(config models.LoadTestConfig) []string {
+	var texts []string
+	for _, value := range config.Headers {
+		texts = append(texts, value)
+	}
+	texts = append(texts, config.Body)
+	for _, specs := range [][]models.RequestSpec{config.Steps, config.Endpoints} {
+		for _, spec := range specs {
+			for _, value := range spec.Headers {
+				texts = append(texts, value)
+			}
+			texts = append(texts, spec.Body)
+		}
+	}
+	if config.GRPC != nil {
+		for _, value := range config.GRPC.Metadata {
+			texts = append(texts, value)
+		}
+		texts = append(texts, jsonStrings(config.GRPC.Message)...)
+	}
+	if config.WebSocket != nil {
+		for _, message := range config.WebSocket.Messages {
+			texts = append(texts, jsonStrings(message)...)
+		}
+	}
+	if config.Network != nil {
+		texts = append(texts, config.Network.Proxy)
+	}
+	names := References(strings.Join(texts, "\n"))
+	if config.TLS != nil {
+		for _, name := range config.TLS.SecretNames() {
+			if !slices.Contains(names, name) {
+				names = append(names, name)
+			}
+		}
+	}
+	return names
+}
@@ -0,0 +1,118 @@
+// Package secrets encrypts user secrets for storage and expands the
+// {{secret "name"}} references test configs make to them.
+package secrets
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+)
+
+// Sealed is a secret encrypted with a data key of its own, which is stored
+// next to it encrypted with the master key identified by KeyID.
+type Sealed struct {
+	KeyID      string
+	WrappedKey []byte
+	Ciphertext []byte
+}
+
+// Sealer does envelope encryption under one master key.
+type Sealer struct {
+	keyID  string
+	master cipher.AEAD
+}
+
+// NewSealer takes the master key as 32 base64 encoded bytes, as generated
+// by `openssl rand -base64 32`.
+func NewSealer(encoded string) (*Sealer, error) {
+	if encoded == "" {
+		return nil, errors.New("no master key configured")
+	}
+	key, err := base64.StdEncoding.DecodeString(encoded)
+	if err != nil {
+		return nil, fmt.Errorf("master key is not valid base64: %v", err)
+	}
+	if len(key) != 32 {
+		return nil, fmt.Errorf("master key must be 32 bytes, got %d", len(key))
+	}
+	master, err := newAEAD(key)
+	if err != nil {
+		return nil, err
+	}
+	sum := sha256.Sum256(key)
+	return &Sealer{keyID: hex.EncodeToString(sum[:4]), master: master}, nil
+}
+
+// Seal encrypts plaintext under a fresh data key. The same associatedData,
+// such as the owner and name of the secret, must be given to Open, so that
+// a sealed value cannot be moved to another row.
+func (s *Sealer) Seal(plaintext, associatedData []byte) (*Sealed, error) {
+	dataKey := make([]byte, 32)
+	if _, err := io.ReadFull(rand.Reader, dataKey); err != nil {
+		return nil, err
+	}
+	data, err := newAEAD(dataKey)
+	if err != nil {
+		return nil, err
+	}
+	ciphertext, err := seal(data, plaintext, associatedData)
+	if err != nil {
+		return nil, err
+	}
+	wrappedKey, err := seal(s.master, dataKey, associatedData)
+	if err != nil {
+		return nil, err
+	}
+	return &Sealed{KeyID: s.keyID, WrappedKey: wrappedKey, Ciphertext: ciphertext}, nil
+}
+
+// Open decrypts a value sealed under the same master key.
+func (s *Sealer) Open(sealed *Sealed, associatedData []byte) ([]byte, error) {
+	if sealed.KeyID != s.keyID {
+		return nil, fmt.Errorf("secret was sealed with master key %s, not the configured %s", sealed.KeyID, s.keyID)
+	}
+	dataKey, err := open(s.master, sealed.WrappedKey, associatedData)
+	if err != nil {
+		return nil, err
+	}
+	data, err := newAEAD(dataKey)
+	if err != nil {
+		return nil, err
+	}
+	return open(data, sealed.Ciphertext, associatedData)
+}
+
+func newAEAD(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+// seal prepends a random nonce to the ciphertext.
+func seal(aead cipher.AEAD, plaintext, associatedData []byte) ([]byte, error) {
+	nonce := make([]byte, aead.NonceSize(), aead.NonceSize()+len(plaintext)+aead.Overhead())
+	if _, err := io.ReadFull(rand.Reader, nonce); err != nil {
+		return nil, err
+	}
+	return aead.Seal(nonce, nonce, plaintext, associatedData), nil
+}
+
+func open(aead cipher.AEAD, sealed, associatedData []byte) ([]byte, error) {
+	if len(sealed) < aead.NonceSize() {
+		return nil, errors.New("sealed value is truncated")
+	}
+	nonce, ciphertext := sealed[:aead.NonceSize()], sealed[aead.NonceSize():]
+	plaintext, err := aead.Open(nil, nonce, ciphertext, associatedData)
+	if err != nil {
+		return nil, errors.New("secret cannot be decrypted")
+	}
+	return plaintext, nil
+}
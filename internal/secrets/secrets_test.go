@@ -0,0 +1,121 @@
+package secrets
+
+import (
+	"bytes"
+	"encoding/base64"
+	"testing"
+)
+
+var (
+	testKey  = base64.StdEncoding.EncodeToString(bytes.Repeat([]byte{1}, 32))
+	otherKey = base64.StdEncoding.EncodeToString(bytes.Repeat([]byte{2}, 32))
+)
+
+func newTestSealer(t *testing.T, key string) *Sealer {
+	t.Helper()
+	s, err := NewSealer(key)
+	if err != nil {
+		t.Fatalf("NewSealer error: %v", err)
+	}
+	return s
+}
+
+func TestNewSealerErrors(t *testing.T) {
+	tests := []struct {
+		name string
+		key  string
+	}{
+		{"missing", ""},
+		{"not base64", "not a key!"},
+		{"too short", base64.StdEncoding.EncodeToString(make([]byte, 16))},
+		{"too long", base64.StdEncoding.EncodeToString(make([]byte, 33))},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := NewSealer(tt.key); err == nil {
+				t.Errorf("NewSealer(%q) succeeded, want an error", tt.key)
+			}
+		})
+	}
+}
+
+func TestSealOpen(t *testing.T) {
+	s := newTestSealer(t, testKey)
+	for _, plaintext := range []string{"", "s3cret", string(bytes.Repeat([]byte("x"), 4096))} {
+		sealed, err := s.Seal([]byte(plaintext), []byte("user-1/api-key"))
+		if err != nil {
+			t.Fatalf("Seal error: %v", err)
+		}
+		if plaintext != "" && bytes.Contains(sealed.Ciphertext, []byte(plaintext)) {
+			t.Errorf("ciphertext holds the plaintext %q", plaintext)
+		}
+		opened, err := s.Open(sealed, []byte("user-1/api-key"))
+		if err != nil {
+			t.Fatalf("Open error: %v", err)
+		}
+		if string(opened) != plaintext {
+			t.Errorf("Open = %q, want %q", opened, plaintext)
+		}
+	}
+}
+
+func TestSealUsesFreshKeys(t *testing.T) {
+	s := newTestSealer(t, testKey)
+	a, _ := s.Seal([]byte("s3cret"), nil)
+	b, _ := s.Seal([]byte("s3cret"), nil)
+	if bytes.Equal(a.Ciphertext, b.Ciphertext) || bytes.Equal(a.WrappedKey, b.WrappedKey) {
+		t.Error("sealing the same value twice gave the same ciphertext or wrapped key")
+	}
+}
+
+func TestKeyID(t *testing.T) {
+	a, b := newTestSealer(t, testKey), newTestSealer(t, testKey)
+	other := newTestSealer(t, otherKey)
+	sealed, _ := a.Seal([]byte("s3cret"), nil)
+	if len(sealed.KeyID) != 8 {
+		t.Errorf("key id %q, want 8 hex characters", sealed.KeyID)
+	}
+	if again, _ := b.Seal([]byte("s3cret"), nil); again.KeyID != sealed.KeyID {
+		t.Errorf("key ids %q and %q differ for the same master key", sealed.KeyID, again.KeyID)
+	}
+	if third, _ := other.Seal([]byte("s3cret"), nil); third.KeyID == sealed.KeyID {
+		t.Error("different master keys share a key id")
+	}
+}
+
+func TestOpenRejects(t *testing.T) {
+	s := newTestSealer(t, testKey)
+	ad := []byte("user-1/api-key")
+	sealed, err := s.Seal([]byte("s3cret"), ad)
+	if err != nil {
+		t.Fatalf("Seal error: %v", err)
+	}
+	another, _ := s.Seal([]byte("other"), ad)
+
+	flip := func(b []byte) []byte {
+		b = append([]byte(nil), b...)
+		b[len(b)-1] ^= 1
+		return b
+	}
+	tests := []struct {
+		name   string
+		sealer *Sealer
+		sealed *Sealed
+		ad     string
+	}{
+		{"other associated data", s, sealed, "user-2/api-key"},
+		{"other master key", newTestSealer(t, otherKey), sealed, string(ad)},
+		{"key id rewritten", newTestSealer(t, otherKey), &Sealed{KeyID: newTestSealer(t, otherKey).keyID, WrappedKey: sealed.WrappedKey, Ciphertext: sealed.Ciphertext}, string(ad)},
+		{"tampered ciphertext", s, &Sealed{KeyID: sealed.KeyID, WrappedKey: sealed.WrappedKey, Ciphertext: flip(sealed.Ciphertext)}, string(ad)},
+		{"tampered wrapped key", s, &Sealed{KeyID: sealed.KeyID, WrappedKey: flip(sealed.WrappedKey), Ciphertext: sealed.Ciphertext}, string(ad)},
+		{"truncated ciphertext", s, &Sealed{KeyID: sealed.KeyID, WrappedKey: sealed.WrappedKey, Ciphertext: sealed.Ciphertext[:4]}, string(ad)},
+		{"ciphertext of another secret", s, &Sealed{KeyID: sealed.KeyID, WrappedKey: sealed.WrappedKey, Ciphertext: another.Ciphertext}, string(ad)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if plaintext, err := tt.sealer.Open(tt.sealed, []byte(tt.ad)); err == nil {
+				t.Errorf("Open succeeded with %q, want an error", plaintext)
+			}
+		})
+	}
+}
@@ -0,0 +1,126 @@
+package worker
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/Vinayak9769/loadagg/pkg/models"
+)
+
+// APIClient talks to the worker endpoints of the API using the test-scoped
+// worker token.
+type APIClient struct {
+	baseURL    string
+	token      string
+	testID     string
+	httpClient *http.Client
+	// for uploads fetched once at start, which may be large
+	downloadClient *http.Client
+}
+
+func NewAPIClient(cfg *Config) *APIClient {
+	if cfg.APIURL == "" || cfg.WorkerToken == "" {
+		return nil
+	}
+	return &APIClient{
+		baseURL:        cfg.APIURL,
+		token:          cfg.WorkerToken,
+		testID:         cfg.TestID,
+		httpClient:     &http.Client{Timeout: 2 * time.Second},
+		downloadClient: &http.Client{Timeout: time.Minute},
+	}
+}
+
+// Control fetches the live control state of the test.
+func (c *APIClient) Control(ctx context.Context) (*models.LoadTestControl, error) {
+	var control models.LoadTestControl
+	if err := c.do(ctx, http.MethodGet, "control", nil, &control); err != nil {
+		return nil, err
+	}
+	return &control, nil
+}
+
+// RegisterAtBarrier registers this worker at the start barrier and returns
+// the barrier state.
+func (c *APIClient) RegisterAtBarrier(ctx context.Context, index int, podName string) (*models.StartBarrier, error) {
+	req := map[string]interface{}{
+		"worker_index": index,
+		"pod_name":     podName,
+	}
+	var barrier models.StartBarrier
+	if err := c.do(ctx, http.MethodPost, "barrier", req, &barrier); err != nil {
+		return nil, err
+	}
+	return &barrier, nil
+}
+
+// Protoset fetches the descriptor set uploaded for a gRPC test.
+func (c *APIClient) Protoset(ctx context.Context) ([]byte, error) {
+	resp, err := c.request(ctx, c.downloadClient, http.MethodGet, "protoset", nil)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	return io.ReadAll(resp.Body)
+}
+
+// Replay fetches this worker's share of the access log replayed by the test.
+func (c *APIClient) Replay(ctx context.Context, index, count int) ([]models.ReplayEntry, error) {
+	resp, err := c.request(ctx, c.downloadClient, http.MethodGet, fmt.Sprintf("replay?index=%d&count=%d", index, count), nil)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	var entries []models.ReplayEntry
+	if err := json.NewDecoder(resp.Body).Decode(&entries); err != nil {
+		return nil, err
+	}
+	return entries, nil
+}
+
+func (c *APIClient) do(ctx context.Context, method, path string, body, out interface{}) error {
+	resp, err := c.request(ctx, c.httpClient, method, path, body)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	return json.NewDecoder(resp.Body).Decode(out)
+}
+
+// request sends a request to a worker endpoint of the test, failing on any
+// status but 200.
+func (c *APIClient) request(ctx context.Context, client *http.Client, method, path string, body interface{}) (*http.Response, error) {
+	var reader io.Reader
+	if body != nil {
+		data, err := json.Marshal(body)
+		if err != nil {
+			return nil, err
+		}
+		reader = bytes.NewReader(data)
+	}
+
+	url := fmt.Sprintf("%s/api/v1/loadtests/%s/%s", c.baseURL, c.testID, path)
+	req, err := http.NewRequestWithContext(ctx, method, url, reader)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Authorization", "Bearer "+c.token)
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode != http.StatusOK {
+		resp.Body.Close()
+		return nil, fmt.Errorf("%s %s returned status %d", method, path, resp.StatusCode)
+	}
+	return resp, nil
+}
@@ -0,0 +1,258 @@
+package worker
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/json"
+	"fmt"
+	"net"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Vinayak9769/loadagg/pkg/models"
+)
+
+// defaultVUs is the VU pool size used when the test sets no max_concurrency.
+const defaultVUs = 50
+
+// defaultMaxResponseBytes is the response body limit used when the test sets
+// no max_response_bytes.
+const defaultMaxResponseBytes = 10 << 20
+
+// Config is the worker configuration the controller passes through the Job's
+// environment.
+type Config struct {
+	TestID      string
+	TargetType  string
+	TargetURL   string
+	HTTPMethod  string
+	Protocol    string
+	Headers     map[string]string
+	Body        string
+	Duration    time.Duration
+	WorkerIndex int
+	WorkerCount int
+	PodName     string
+	GRPC        *models.GRPCConfig
+	WebSocket   *models.WebSocketConfig
+	Socket      *models.SocketConfig
+	DNS         *models.DNSConfig
+	Stream      *models.StreamConfig
+	Steps       []models.RequestSpec
+	Endpoints   []models.RequestSpec
+	Feeder      *models.Feeder
+	TLS         *models.TLSConfig
+	Network     *models.NetworkConfig
+	Replay      *models.ReplayConfig
+	Cookies     *models.CookieConfig
+	Executor    string
+	ThinkTime   *models.ThinkTime
+	Pacing      time.Duration
+
+	// values of the secrets referenced in headers and bodies, redacted
+	// from reported errors
+	SecretValues []string
+	// loaded from the secrets the TLS settings reference
+	ClientCert *tls.Certificate
+	RootCAs    *x509.CertPool
+	// private ranges of the organization's allowlist, the only private
+	// addresses the worker connects to
+	AllowedNetworks []*net.IPNet
+
+	// this worker's share of the test-wide rate and VU count
+	RequestsPerSec int
+	VUs            int
+
+	APIURL              string
+	WorkerToken         string
+	StartTimeout        time.Duration
+	ControlPollInterval time.Duration
+	MetricsInterval     time.Duration
+	RequestTimeout      time.Duration
+	MaxResponseBytes    int64
+}
+
+func LoadConfig() (*Config, error) {
+	cfg := &Config{
+		TestID:              os.Getenv("TEST_ID"),
+		TargetType:          os.Getenv("TARGET_TYPE"),
+		TargetURL:           os.Getenv("TARGET_URL"),
+		HTTPMethod:          os.Getenv("HTTP_METHOD"),
+		Protocol:            os.Getenv("HTTP_PROTOCOL"),
+		Body:                os.Getenv("HTTP_BODY"),
+		Executor:            os.Getenv("EXECUTOR"),
+		Pacing:              time.Duration(envInt("PACING_MS", 0)) * time.Millisecond,
+		PodName:             os.Getenv("HOSTNAME"),
+		APIURL:              strings.TrimSuffix(os.Getenv("API_URL"), "/"),
+		WorkerToken:         os.Getenv("WORKER_TOKEN"),
+		StartTimeout:        time.Duration(envInt("START_TIMEOUT_SECONDS", 60)) * time.Second,
+		ControlPollInterval: time.Duration(envInt("CONTROL_POLL_SECONDS", 5)) * time.Second,
+		MetricsInterval:     time.Duration(envInt("METRICS_INTERVAL_SECONDS", 2)) * time.Second,
+		RequestTimeout:      time.Duration(envInt("REQUEST_TIMEOUT_SECONDS", 30)) * time.Second,
+		MaxResponseBytes:    int64(envInt("MAX_RESPONSE_BYTES", defaultMaxResponseBytes)),
+	}
+
+	if cfg.TargetURL == "" || os.Getenv("DURATION_SECONDS") == "" || os.Getenv("REQUESTS_PER_SEC") == "" {
+		return nil, fmt.Errorf("missing required environment variables: DURATION_SECONDS, REQUESTS_PER_SEC, TARGET_URL")
+	}
+	if cfg.HTTPMethod == "" {
+		cfg.HTTPMethod = "GET"
+	}
+	if cfg.Protocol == "" {
+		cfg.Protocol = ProtocolHTTP1
+	}
+
+	cfg.Duration = time.Duration(envInt("DURATION_SECONDS", 60)) * time.Second
+	cfg.WorkerIndex = envInt("JOB_COMPLETION_INDEX", 0)
+	cfg.WorkerCount = envInt("WORKER_COUNT", 1)
+
+	// REQUESTS_PER_SEC is the total for the test; WORKER_RATES holds each
+	// worker's share, indexed by the Job completion index
+	cfg.RequestsPerSec = envInt("REQUESTS_PER_SEC", 1)
+	if rates := os.Getenv("WORKER_RATES"); rates != "" {
+		cfg.RequestsPerSec = shareAt(rates, cfg.WorkerIndex)
+	}
+	cfg.VUs = shareAt(os.Getenv("WORKER_CONCURRENCY"), cfg.WorkerIndex)
+
+	if headers := os.Getenv("HTTP_HEADERS"); headers != "" {
+		if err := json.Unmarshal([]byte(headers), &cfg.Headers); err != nil {
+			return nil, fmt.Errorf("invalid HTTP_HEADERS: %v", err)
+		}
+	}
+
+	if steps := os.Getenv("HTTP_STEPS"); steps != "" {
+		if err := json.Unmarshal([]byte(steps), &cfg.Steps); err != nil {
+			return nil, fmt.Errorf("invalid HTTP_STEPS: %v", err)
+		}
+	}
+
+	if endpoints := os.Getenv("HTTP_ENDPOINTS"); endpoints != "" {
+		if err := json.Unmarshal([]byte(endpoints), &cfg.Endpoints); err != nil {
+			return nil, fmt.Errorf("invalid HTTP_ENDPOINTS: %v", err)
+		}
+	}
+
+	if feeder := os.Getenv("FEEDER"); feeder != "" {
+		if err := json.Unmarshal([]byte(feeder), &cfg.Feeder); err != nil {
+			return nil, fmt.Errorf("invalid FEEDER: %v", err)
+		}
+	}
+
+	if cookies := os.Getenv("COOKIES_CONFIG"); cookies != "" {
+		if err := json.Unmarshal([]byte(cookies), &cfg.Cookies); err != nil {
+			return nil, fmt.Errorf("invalid COOKIES_CONFIG: %v", err)
+		}
+	}
+
+	if thinkTime := os.Getenv("THINK_TIME"); thinkTime != "" {
+		if err := json.Unmarshal([]byte(thinkTime), &cfg.ThinkTime); err != nil {
+			return nil, fmt.Errorf("invalid THINK_TIME: %v", err)
+		}
+	}
+
+	if replayConfig := os.Getenv("REPLAY_CONFIG"); replayConfig != "" {
+		if err := json.Unmarshal([]byte(replayConfig), &cfg.Replay); err != nil {
+			return nil, fmt.Errorf("invalid REPLAY_CONFIG: %v", err)
+		}
+	}
+
+	if tlsConfig := os.Getenv("TLS_CONFIG"); tlsConfig != "" {
+		if err := json.Unmarshal([]byte(tlsConfig), &cfg.TLS); err != nil {
+			return nil, fmt.Errorf("invalid TLS_CONFIG: %v", err)
+		}
+	}
+
+	if networkConfig := os.Getenv("NETWORK_CONFIG"); networkConfig != "" {
+		if err := json.Unmarshal([]byte(networkConfig), &cfg.Network); err != nil {
+			return nil, fmt.Errorf("invalid NETWORK_CONFIG: %v", err)
+		}
+	}
+
+	if networks := os.Getenv("ALLOWED_NETWORKS"); networks != "" {
+		for _, cidr := range strings.Split(networks, ",") {
+			_, network, err := net.ParseCIDR(cidr)
+			if err != nil {
+				return nil, fmt.Errorf("invalid ALLOWED_NETWORKS: %v", err)
+			}
+			cfg.AllowedNetworks = append(cfg.AllowedNetworks, network)
+		}
+	}
+
+	if grpcConfig := os.Getenv("GRPC_CONFIG"); grpcConfig != "" {
+		if err := json.Unmarshal([]byte(grpcConfig), &cfg.GRPC); err != nil {
+			return nil, fmt.Errorf("invalid GRPC_CONFIG: %v", err)
+		}
+	}
+
+	if wsConfig := os.Getenv("WEBSOCKET_CONFIG"); wsConfig != "" {
+		if err := json.Unmarshal([]byte(wsConfig), &cfg.WebSocket); err != nil {
+			return nil, fmt.Errorf("invalid WEBSOCKET_CONFIG: %v", err)
+		}
+	}
+
+	if socketConfig := os.Getenv("SOCKET_CONFIG"); socketConfig != "" {
+		if err := json.Unmarshal([]byte(socketConfig), &cfg.Socket); err != nil {
+			return nil, fmt.Errorf("invalid SOCKET_CONFIG: %v", err)
+		}
+	}
+
+	if dnsConfig := os.Getenv("DNS_CONFIG"); dnsConfig != "" {
+		if err := json.Unmarshal([]byte(dnsConfig), &cfg.DNS); err != nil {
+			return nil, fmt.Errorf("invalid DNS_CONFIG: %v", err)
+		}
+	}
+
+	if streamConfig := os.Getenv("STREAM_CONFIG"); streamConfig != "" {
+		if err := json.Unmarshal([]byte(streamConfig), &cfg.Stream); err != nil {
+			return nil, fmt.Errorf("invalid STREAM_CONFIG: %v", err)
+		}
+	}
+
+	if dir := os.Getenv("SECRETS_DIR"); dir != "" {
+		if err := expandSecrets(cfg, dir); err != nil {
+			return nil, err
+		}
+		if err := loadTLSSecrets(cfg, dir); err != nil {
+			return nil, err
+		}
+	}
+
+	return cfg, nil
+}
+
+// vuCount is the VU pool size for a per-worker share, falling back to the
+// default when the test did not set max_concurrency.
+func vuCount(share int) int {
+	if share > 0 {
+		return share
+	}
+	return defaultVUs
+}
+
+func envInt(key string, fallback int) int {
+	value := os.Getenv(key)
+	if value == "" {
+		return fallback
+	}
+	f, err := strconv.ParseFloat(value, 64)
+	if err != nil {
+		return fallback
+	}
+	return int(f)
+}
+
+// shareAt returns the index-th entry of a comma separated list of shares, or
+// 0 when the list is shorter, e.g. for a pod that is being scaled away.
+func shareAt(list string, index int) int {
+	parts := strings.Split(list, ",")
+	if index < 0 || index >= len(parts) {
+		return 0
+	}
+	n, err := strconv.Atoi(strings.TrimSpace(parts[index]))
+	if err != nil {
+		return 0
+	}
+	return n
+}
@@ -0,0 +1,188 @@
+package worker
+
+import (
+	"context"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"math/rand/v2"
+	"net"
+	"strings"
+	"time"
+
+	"github.com/Vinayak9769/loadagg/pkg/models"
+	"golang.org/x/net/dns/dnsmessage"
+)
+
+// rcodeNames are the conventional names of DNS response codes, used as the
+// status codes of dns tests.
+var rcodeNames = map[dnsmessage.RCode]string{
+	dnsmessage.RCodeSuccess:        "NOERROR",
+	dnsmessage.RCodeFormatError:    "FORMERR",
+	dnsmessage.RCodeServerFailure:  "SERVFAIL",
+	dnsmessage.RCodeNameError:      "NXDOMAIN",
+	dnsmessage.RCodeNotImplemented: "NOTIMP",
+	dnsmessage.RCodeRefused:        "REFUSED",
+}
+
+// dnsDriver sends one query per iteration to the resolver in the target URL
+// and keys the outcome by response code. Only NOERROR counts as a success.
+type dnsDriver struct {
+	cfg       *Config
+	recorder  *Recorder
+	addr      string
+	dial      dialFunc
+	transport string
+	name      dnsmessage.Name
+	qtype     dnsmessage.Type
+	label     string
+}
+
+func newDNSDriver(cfg *Config, recorder *Recorder) (*dnsDriver, error) {
+	if cfg.DNS == nil || cfg.DNS.Name == "" {
+		return nil, fmt.Errorf("missing DNS_CONFIG name for dns target")
+	}
+	addr, err := targetAddress(cfg.TargetURL, "53")
+	if err != nil {
+		return nil, err
+	}
+
+	typeName := strings.ToUpper(cfg.DNS.Type)
+	if typeName == "" {
+		typeName = "A"
+	}
+	qtype, ok := models.DNSRecordTypes[typeName]
+	if !ok {
+		return nil, fmt.Errorf("unsupported DNS record type %q", cfg.DNS.Type)
+	}
+	transport := cfg.DNS.Transport
+	if transport == "" {
+		transport = "udp"
+	}
+	if transport != "udp" && transport != "tcp" {
+		return nil, fmt.Errorf("unsupported DNS transport %q", cfg.DNS.Transport)
+	}
+	name, err := dnsmessage.NewName(dnsFQDN(cfg.DNS.Name))
+	if err != nil {
+		return nil, fmt.Errorf("invalid DNS name: %v", err)
+	}
+
+	return &dnsDriver{
+		cfg:       cfg,
+		recorder:  recorder,
+		addr:      addr,
+		dial:      newDialer(cfg),
+		transport: transport,
+		name:      name,
+		qtype:     dnsmessage.Type(qtype),
+		label:     typeName + " " + cfg.DNS.Name,
+	}, nil
+}
+
+func dnsFQDN(name string) string {
+	if strings.HasSuffix(name, ".") {
+		return name
+	}
+	return name + "."
+}
+
+func (d *dnsDriver) Iterate(_ context.Context, _ *VU, intended time.Time) {
+	start := time.Now()
+	rcode, err := d.query()
+	done := time.Now()
+	if err != nil {
+		d.recorder.RecordError(classifyError(err, requestPhase{connected: d.transport == "udp"}), err.Error(),
+			done.Sub(start), done.Sub(intended))
+		return
+	}
+
+	code, ok := rcodeNames[rcode]
+	if !ok {
+		code = fmt.Sprintf("RCODE%d", rcode)
+	}
+	res := result{
+		code:        code,
+		ok:          rcode == dnsmessage.RCodeSuccess,
+		uncorrected: done.Sub(start),
+		corrected:   done.Sub(intended),
+	}
+	if !res.ok {
+		res.message = fmt.Sprintf("%s answered %s", d.label, code)
+	}
+	d.recorder.Record(res)
+}
+
+// query sends the question and returns the response code of the answer.
+func (d *dnsDriver) query() (dnsmessage.RCode, error) {
+	id := uint16(rand.UintN(1 << 16))
+	msg := dnsmessage.Message{
+		Header:    dnsmessage.Header{ID: id, RecursionDesired: true},
+		Questions: []dnsmessage.Question{{Name: d.name, Type: d.qtype, Class: dnsmessage.ClassINET}},
+	}
+	packet, err := msg.Pack()
+	if err != nil {
+		return 0, err
+	}
+
+	conn, err := d.dial(context.Background(), d.transport, d.addr)
+	if err != nil {
+		return 0, err
+	}
+	defer conn.Close()
+	conn.SetDeadline(time.Now().Add(d.cfg.RequestTimeout))
+
+	if d.transport == "tcp" {
+		return d.queryTCP(conn, id, packet)
+	}
+	return d.queryUDP(conn, id, packet)
+}
+
+func (d *dnsDriver) queryUDP(conn net.Conn, id uint16, packet []byte) (dnsmessage.RCode, error) {
+	if _, err := conn.Write(packet); err != nil {
+		return 0, err
+	}
+	buf := make([]byte, maxDatagramSize)
+	for {
+		n, err := conn.Read(buf)
+		if err != nil {
+			return 0, err
+		}
+		// stray datagrams are skipped until the answer or the deadline
+		if header, err := parseDNSHeader(buf[:n]); err == nil && header.ID == id && header.Response {
+			return header.RCode, nil
+		}
+	}
+}
+
+// queryTCP frames the query and answer with a two byte length prefix.
+func (d *dnsDriver) queryTCP(conn net.Conn, id uint16, packet []byte) (dnsmessage.RCode, error) {
+	framed := binary.BigEndian.AppendUint16(nil, uint16(len(packet)))
+	if _, err := conn.Write(append(framed, packet...)); err != nil {
+		return 0, err
+	}
+	var length [2]byte
+	if _, err := io.ReadFull(conn, length[:]); err != nil {
+		return 0, err
+	}
+	answer := make([]byte, binary.BigEndian.Uint16(length[:]))
+	if _, err := io.ReadFull(conn, answer); err != nil {
+		return 0, err
+	}
+	header, err := parseDNSHeader(answer)
+	if err != nil {
+		return 0, err
+	}
+	if header.ID != id {
+		return 0, fmt.Errorf("answer id %d does not match query id %d", header.ID, id)
+	}
+	return header.RCode, nil
+}
+
+func parseDNSHeader(packet []byte) (dnsmessage.Header, error) {
+	var parser dnsmessage.Parser
+	return parser.Start(packet)
+}
+
+func (d *dnsDriver) Close() error {
+	return nil
+}
@@ -0,0 +1,110 @@
+package worker
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/Vinayak9769/loadagg/pkg/models"
+)
+
+// Driver performs the iterations of one target type. Iterate runs on many VU
+// goroutines at once and reports its outcome to the recorder itself.
+type Driver interface {
+	// Iterate performs one iteration of vu that was scheduled to start at
+	// intended. Waits inside the iteration, such as think time between
+	// steps, end early once ctx is done.
+	Iterate(ctx context.Context, vu *VU, intended time.Time)
+	Close() error
+}
+
+// VU is one virtual user of an executor. Iterate is never called for the
+// same VU concurrently, so drivers can keep per-user state, such as cookies,
+// in it without locking.
+type VU struct {
+	state any // owned by the driver
+}
+
+// Subscriber is implemented by target types whose VUs each hold one
+// long-lived subscription instead of making requests on a schedule.
+type Subscriber interface {
+	// Subscribe keeps a subscription open, reconnecting whenever it drops,
+	// until ctx is done.
+	Subscribe(ctx context.Context)
+	Close() error
+}
+
+// driverFactory builds the driver of a target type once at start up.
+type driverFactory func(ctx context.Context, cfg *Config, api *APIClient, recorder *Recorder) (Driver, error)
+
+// drivers holds the protocol drivers by target type; adding a protocol only
+// takes a Driver implementation and an entry here.
+var drivers = map[string]driverFactory{
+	models.TargetHTTP: func(_ context.Context, cfg *Config, _ *APIClient, recorder *Recorder) (Driver, error) {
+		return newHTTPDriver(cfg, recorder), nil
+	},
+	models.TargetGRPC: func(ctx context.Context, cfg *Config, api *APIClient, recorder *Recorder) (Driver, error) {
+		return newGRPCDriver(ctx, cfg, api, recorder)
+	},
+	models.TargetWebSocket: func(_ context.Context, cfg *Config, _ *APIClient, recorder *Recorder) (Driver, error) {
+		return newWebSocketDriver(cfg, recorder)
+	},
+	models.TargetTCP: func(_ context.Context, cfg *Config, _ *APIClient, recorder *Recorder) (Driver, error) {
+		return newTCPDriver(cfg, recorder)
+	},
+	models.TargetUDP: func(_ context.Context, cfg *Config, _ *APIClient, recorder *Recorder) (Driver, error) {
+		return newUDPDriver(cfg, recorder)
+	},
+	models.TargetDNS: func(_ context.Context, cfg *Config, _ *APIClient, recorder *Recorder) (Driver, error) {
+		return newDNSDriver(cfg, recorder)
+	},
+}
+
+// subscribers holds the target types run by the SubscriptionExecutor.
+var subscribers = map[string]func(cfg *Config, recorder *Recorder) Subscriber{
+	models.TargetSSE: func(cfg *Config, recorder *Recorder) Subscriber {
+		return newStreamDriver(cfg, recorder, false)
+	},
+	models.TargetLongPoll: func(cfg *Config, recorder *Recorder) Subscriber {
+		return newStreamDriver(cfg, recorder, true)
+	},
+}
+
+// newExecutor sets up the driver of the configured target type and the
+// executor that runs it. The returned closer releases the driver.
+func newExecutor(ctx context.Context, cfg *Config, api *APIClient, recorder *Recorder) (Executor, io.Closer, error) {
+	if cfg.Replay != nil {
+		executor, driver, err := newReplayExecutor(ctx, cfg, api, recorder)
+		if err != nil {
+			return nil, nil, err
+		}
+		return executor, driver, nil
+	}
+	if factory, ok := subscribers[cfg.TargetType]; ok {
+		subscriber := factory(cfg, recorder)
+		return NewSubscriptionExecutor(cfg, subscriber, recorder), subscriber, nil
+	}
+
+	driver, err := newDriver(ctx, cfg, api, recorder)
+	if err != nil {
+		return nil, nil, err
+	}
+	if cfg.Executor == models.ExecutorVUs {
+		return NewVUExecutor(cfg, driver, recorder), driver, nil
+	}
+	return NewArrivalRateExecutor(cfg, driver, recorder), driver, nil
+}
+
+// newDriver returns the driver for the configured target type.
+func newDriver(ctx context.Context, cfg *Config, api *APIClient, recorder *Recorder) (Driver, error) {
+	targetType := cfg.TargetType
+	if targetType == "" {
+		targetType = models.TargetHTTP
+	}
+	factory, ok := drivers[targetType]
+	if !ok {
+		return nil, fmt.Errorf("unsupported target type %q", cfg.TargetType)
+	}
+	return factory(ctx, cfg, api, recorder)
+}
@@ -0,0 +1,73 @@
+package worker
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"io"
+	"net"
+	"strings"
+	"syscall"
+)
+
+// Error categories reported alongside the status code map for requests that
+// did not produce a complete response.
+const (
+	ErrDNS            = "dns"
+	ErrConnectRefused = "connect_refused"
+	ErrConnectTimeout = "connect_timeout"
+	ErrTLSHandshake   = "tls_handshake"
+	ErrReadTimeout    = "read_timeout"
+	ErrReset          = "reset"
+	ErrBodyTooLarge   = "body_too_large"
+	ErrOther          = "other"
+)
+
+var errBodyTooLarge = errors.New("response body exceeds the size limit")
+
+// requestPhase tracks how far a request got, so that a timeout can be told
+// apart as a connect or a read timeout.
+type requestPhase struct {
+	connected    bool
+	tlsStarted   bool
+	tlsCompleted bool
+}
+
+// classifyError maps a request error to one of the error categories.
+func classifyError(err error, phase requestPhase) string {
+	var dnsErr *net.DNSError
+	var certErr *tls.CertificateVerificationError
+	var recordErr tls.RecordHeaderError
+	var alertErr tls.AlertError
+	var unknownAuthority x509.UnknownAuthorityError
+	var hostnameErr x509.HostnameError
+	var invalidCert x509.CertificateInvalidError
+
+	switch {
+	case errors.Is(err, errBodyTooLarge):
+		return ErrBodyTooLarge
+	case errors.As(err, &dnsErr):
+		return ErrDNS
+	case errors.Is(err, syscall.ECONNREFUSED):
+		return ErrConnectRefused
+	case errors.As(err, &certErr), errors.As(err, &recordErr), errors.As(err, &alertErr),
+		errors.As(err, &unknownAuthority), errors.As(err, &hostnameErr), errors.As(err, &invalidCert),
+		phase.tlsStarted && !phase.tlsCompleted:
+		return ErrTLSHandshake
+	case isTimeout(err) && !phase.connected:
+		return ErrConnectTimeout
+	case isTimeout(err):
+		return ErrReadTimeout
+	case errors.Is(err, syscall.ECONNRESET), errors.Is(err, syscall.EPIPE),
+		errors.Is(err, syscall.ECONNABORTED), errors.Is(err, io.ErrUnexpectedEOF),
+		errors.Is(err, io.EOF), strings.Contains(err.Error(), "connection reset"):
+		return ErrReset
+	default:
+		return ErrOther
+	}
+}
+
+func isTimeout(err error) bool {
+	var netErr net.Error
+	return errors.As(err, &netErr) && netErr.Timeout()
+}
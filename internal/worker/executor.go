@@ -0,0 +1,238 @@
+package worker
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// Executor generates a worker's load until the test ends and follows live
+// control changes.
+type Executor interface {
+	Run(ctx context.Context, end time.Time)
+	SetRate(rate int)
+	SetPaused(paused bool)
+	SetVUs(vus int)
+}
+
+// ArrivalRateExecutor starts iterations on a fixed schedule regardless of how
+// long earlier iterations take. What an iteration does is up to the Driver.
+// Each scheduled start is handed to an idle VU; if none frees up before the
+// next start is due the iteration is dropped, so a slow target cannot silently
+// lower the offered load. Latency is recorded both from the actual send time
+// and from the scheduled send time, the latter correcting for coordinated
+// omission.
+type ArrivalRateExecutor struct {
+	cfg      *Config
+	driver   Driver
+	recorder *Recorder
+
+	mu      sync.Mutex
+	rate    int
+	paused  bool
+	vus     int
+	changed chan struct{}
+
+	work    chan time.Time
+	quit    chan struct{}
+	vuCtx   context.Context
+	stopVUs context.CancelFunc
+	wg      sync.WaitGroup
+}
+
+func NewArrivalRateExecutor(cfg *Config, driver Driver, recorder *Recorder) *ArrivalRateExecutor {
+	vuCtx, stopVUs := context.WithCancel(context.Background())
+	return &ArrivalRateExecutor{
+		cfg:      cfg,
+		driver:   driver,
+		recorder: recorder,
+		rate:     cfg.RequestsPerSec,
+		changed:  make(chan struct{}, 1),
+		work:     make(chan time.Time),
+		quit:     make(chan struct{}),
+		vuCtx:    vuCtx,
+		stopVUs:  stopVUs,
+	}
+}
+
+// SetRate changes the iteration rate; the schedule restarts from now.
+func (e *ArrivalRateExecutor) SetRate(rate int) {
+	e.mu.Lock()
+	e.rate = rate
+	e.mu.Unlock()
+	e.recorder.SetTargetRPS(rate)
+	e.notify()
+}
+
+func (e *ArrivalRateExecutor) SetPaused(paused bool) {
+	e.mu.Lock()
+	e.paused = paused
+	e.mu.Unlock()
+	e.notify()
+}
+
+// SetVUs grows or shrinks the VU pool. Shrinking waits for VUs to become
+// idle, so it never interrupts an in-flight request.
+func (e *ArrivalRateExecutor) SetVUs(vus int) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	for ; e.vus < vus; e.vus++ {
+		e.wg.Add(1)
+		go e.runVU(e.vuCtx, &VU{})
+	}
+	if e.vus > vus {
+		stop := e.vus - vus
+		e.vus = vus
+		go func() {
+			for i := 0; i < stop; i++ {
+				select {
+				case e.quit <- struct{}{}:
+				case <-e.vuCtx.Done():
+					return
+				}
+			}
+		}()
+	}
+}
+
+// Run schedules iterations until end, which is pushed back by the length of
+// every pause. It returns after in-flight requests have finished.
+func (e *ArrivalRateExecutor) Run(ctx context.Context, end time.Time) {
+	defer func() {
+		e.stopVUs()
+		e.wg.Wait()
+	}()
+	e.SetVUs(vuCount(e.cfg.VUs))
+	e.recorder.SetTargetRPS(e.currentRate())
+
+	anchor := time.Now()
+	var scheduled int64
+
+	for {
+		rate, paused := e.state()
+
+		if paused {
+			pauseStart := time.Now()
+			if !e.waitForChange(ctx) {
+				return
+			}
+			pauseLength := time.Since(pauseStart)
+			e.recorder.AddPaused(pauseLength)
+			end = end.Add(pauseLength)
+			anchor, scheduled = time.Now(), 0
+			continue
+		}
+
+		if rate <= 0 {
+			// no share of the rate, e.g. more workers than requests per second
+			if !e.waitUntil(ctx, end) {
+				return
+			}
+			anchor, scheduled = time.Now(), 0
+			continue
+		}
+
+		intended := anchor.Add(time.Duration(scheduled) * time.Second / time.Duration(rate))
+		if !intended.Before(end) {
+			return
+		}
+
+		if wait := time.Until(intended); wait > 0 {
+			timer := time.NewTimer(wait)
+			select {
+			case <-ctx.Done():
+				timer.Stop()
+				return
+			case <-e.changed:
+				timer.Stop()
+				anchor, scheduled = time.Now(), 0
+				continue
+			case <-timer.C:
+			}
+		}
+
+		scheduled++
+		if !e.handoff(ctx, intended, anchor.Add(time.Duration(scheduled)*time.Second/time.Duration(rate))) {
+			return
+		}
+	}
+}
+
+// handoff passes an iteration to a VU, waiting for one to become idle at most
+// until the next iteration is due. It returns false if the context ends.
+func (e *ArrivalRateExecutor) handoff(ctx context.Context, intended, next time.Time) bool {
+	select {
+	case e.work <- intended:
+		return true
+	default:
+	}
+
+	timer := time.NewTimer(time.Until(next))
+	defer timer.Stop()
+	select {
+	case e.work <- intended:
+	case <-timer.C:
+		e.recorder.Dropped()
+	case <-ctx.Done():
+		return false
+	}
+	return true
+}
+
+func (e *ArrivalRateExecutor) runVU(ctx context.Context, vu *VU) {
+	defer e.wg.Done()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-e.quit:
+			return
+		case intended := <-e.work:
+			e.recorder.Iteration()
+			e.driver.Iterate(ctx, vu, intended)
+		}
+	}
+}
+
+func (e *ArrivalRateExecutor) state() (int, bool) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	return e.rate, e.paused
+}
+
+func (e *ArrivalRateExecutor) currentRate() int {
+	rate, _ := e.state()
+	return rate
+}
+
+func (e *ArrivalRateExecutor) notify() {
+	select {
+	case e.changed <- struct{}{}:
+	default:
+	}
+}
+
+func (e *ArrivalRateExecutor) waitForChange(ctx context.Context) bool {
+	select {
+	case <-ctx.Done():
+		return false
+	case <-e.changed:
+		return true
+	}
+}
+
+// waitUntil blocks until the next control change, returning false if the
+// context ends or end passes first.
+func (e *ArrivalRateExecutor) waitUntil(ctx context.Context, end time.Time) bool {
+	timer := time.NewTimer(time.Until(end))
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return false
+	case <-timer.C:
+		return false
+	case <-e.changed:
+		return true
+	}
+}
@@ -0,0 +1,118 @@
+package worker
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"testing"
+	"time"
+)
+
+// fakeDriver takes delay per iteration and records it the way the protocol
+// drivers do: from the actual start and from the scheduled one.
+type fakeDriver struct {
+	recorder *Recorder
+	delay    time.Duration
+
+	mu       sync.Mutex
+	intended []time.Time
+}
+
+func (d *fakeDriver) Iterate(_ context.Context, _ *VU, intended time.Time) {
+	start := time.Now()
+	d.mu.Lock()
+	d.intended = append(d.intended, intended)
+	d.mu.Unlock()
+
+	time.Sleep(d.delay)
+	d.recorder.Record(result{code: "200", ok: true, uncorrected: time.Since(start), corrected: time.Since(intended)})
+}
+
+func (d *fakeDriver) Close() error { return nil }
+
+// runArrivalRate runs an ArrivalRateExecutor for length and returns the
+// scheduled starts of the iterations it ran, in order, and its recorder.
+func runArrivalRate(t *testing.T, rate, vus int, delay, length time.Duration) ([]time.Time, *Recorder) {
+	t.Helper()
+	recorder := NewRecorder("test-1", 0)
+	driver := &fakeDriver{recorder: recorder, delay: delay}
+	cfg := &Config{RequestsPerSec: rate, VUs: vus}
+	NewArrivalRateExecutor(cfg, driver, recorder).Run(context.Background(), time.Now().Add(length))
+
+	intended := append([]time.Time(nil), driver.intended...)
+	sort.Slice(intended, func(i, j int) bool { return intended[i].Before(intended[j]) })
+	return intended, recorder
+}
+
+func TestArrivalRateSchedule(t *testing.T) {
+	// iterations take longer than the interval, but enough VUs are free
+	intended, recorder := runArrivalRate(t, 100, 10, 30*time.Millisecond, 300*time.Millisecond)
+
+	if len(intended) < 25 || len(intended) > 30 {
+		t.Fatalf("ran %d iterations in 300ms at 100/s, want about 30", len(intended))
+	}
+	// starts stay on the fixed schedule however long iterations take
+	for i, at := range intended {
+		if got, want := at.Sub(intended[0]), time.Duration(i)*10*time.Millisecond; got != want {
+			t.Fatalf("iteration %d scheduled %v after the first, want %v", i, got, want)
+		}
+	}
+
+	m := recorder.Snapshot()
+	if m.DroppedIterations != 0 {
+		t.Errorf("dropped %d iterations with idle VUs available", m.DroppedIterations)
+	}
+	if m.Iterations != int64(len(intended)) || m.TotalRequests != int64(len(intended)) {
+		t.Errorf("recorded %d iterations and %d requests, want %d", m.Iterations, m.TotalRequests, len(intended))
+	}
+}
+
+func TestArrivalRateDrops(t *testing.T) {
+	// a single VU busy for 45ms can only take one start in five
+	intended, recorder := runArrivalRate(t, 100, 1, 45*time.Millisecond, 300*time.Millisecond)
+
+	m := recorder.Snapshot()
+	if m.DroppedIterations == 0 {
+		t.Fatal("no iterations dropped while the only VU was busy")
+	}
+	// every scheduled start is either run or counted as dropped
+	if scheduled := m.Iterations + m.DroppedIterations; scheduled < 28 || scheduled > 30 {
+		t.Errorf("%d iterations run and %d dropped, want about 30 scheduled", m.Iterations, m.DroppedIterations)
+	}
+	if m.Iterations != int64(len(intended)) {
+		t.Errorf("recorded %d iterations, the driver ran %d", m.Iterations, len(intended))
+	}
+
+	// starts that were handed over late keep their scheduled time, so the
+	// corrected latency includes the wait for a free VU
+	for i, at := range intended {
+		if offset := at.Sub(intended[0]); offset%(10*time.Millisecond) != 0 {
+			t.Errorf("iteration %d scheduled %v after the first, off the 10ms schedule", i, offset)
+		}
+	}
+	if m.LatencyCorrected.Sum <= m.LatencyUncorrected.Sum {
+		t.Errorf("corrected latency sum %g is not above uncorrected %g", m.LatencyCorrected.Sum, m.LatencyUncorrected.Sum)
+	}
+}
+
+func TestArrivalRatePause(t *testing.T) {
+	recorder := NewRecorder("test-1", 0)
+	driver := &fakeDriver{recorder: recorder}
+	e := NewArrivalRateExecutor(&Config{RequestsPerSec: 100, VUs: 2}, driver, recorder)
+	e.SetPaused(true)
+
+	start := time.Now()
+	time.AfterFunc(100*time.Millisecond, func() { e.SetPaused(false) })
+	e.Run(context.Background(), start.Add(100*time.Millisecond))
+
+	// the pause pushes the end back by its own length
+	if took := time.Since(start); took < 190*time.Millisecond {
+		t.Errorf("run ended after %v, want the 100ms pause added to the 100ms run", took)
+	}
+	if len(driver.intended) == 0 {
+		t.Fatal("no iterations ran after resuming")
+	}
+	if first := driver.intended[0]; first.Sub(start) < 100*time.Millisecond {
+		t.Errorf("first iteration scheduled %v after the start, during the pause", first.Sub(start))
+	}
+}
@@ -0,0 +1,133 @@
+package worker
+
+import (
+	"encoding/json"
+	"net/url"
+	"strings"
+	"sync/atomic"
+
+	"github.com/Vinayak9769/loadagg/pkg/models"
+)
+
+// feeder hands out the rows of a test's data table, one per iteration in
+// turn. Workers start at different rows so that small tables still spread
+// over the workers.
+type feeder struct {
+	rows []feederRow
+	next atomic.Uint64
+}
+
+// feederRow fills {{column}} references with one row's values, escaped for
+// where they land: as path segments or query values in URLs, as JSON string
+// content or form values in bodies of those types, and verbatim elsewhere.
+type feederRow struct {
+	path  *strings.Replacer
+	query *strings.Replacer
+	json  *strings.Replacer
+	text  *strings.Replacer
+	// contentType is the test's Content-Type header, used for requests that
+	// do not set their own
+	contentType string
+}
+
+func newFeeder(cfg *Config) *feeder {
+	if cfg.Feeder == nil || len(cfg.Feeder.Rows) == 0 {
+		return nil
+	}
+	f := &feeder{rows: make([]feederRow, len(cfg.Feeder.Rows))}
+	for i, row := range cfg.Feeder.Rows {
+		f.rows[i] = newFeederRow(cfg.Feeder, row, headerValue(cfg.Headers, "Content-Type"))
+	}
+	f.next.Store(uint64(cfg.WorkerIndex))
+	return f
+}
+
+func newFeederRow(table *models.Feeder, values []string, contentType string) feederRow {
+	var path, query, jsonText, verbatim []string
+	for i, column := range table.Columns {
+		if i >= len(values) {
+			break
+		}
+		ref := "{{" + column + "}}"
+		path = append(path, ref, url.PathEscape(values[i]))
+		query = append(query, ref, url.QueryEscape(values[i]))
+		jsonText = append(jsonText, ref, jsonEscape(values[i]))
+		verbatim = append(verbatim, ref, values[i])
+	}
+	return feederRow{
+		path:        strings.NewReplacer(path...),
+		query:       strings.NewReplacer(query...),
+		json:        strings.NewReplacer(jsonText...),
+		text:        strings.NewReplacer(verbatim...),
+		contentType: contentType,
+	}
+}
+
+// jsonEscape returns s as the content of a JSON string, without the quotes.
+func jsonEscape(s string) string {
+	quoted, _ := json.Marshal(s)
+	return string(quoted[1 : len(quoted)-1])
+}
+
+// headerValue looks a header up by its case-insensitive name.
+func headerValue(headers map[string]string, name string) string {
+	for key, value := range headers {
+		if strings.EqualFold(key, name) {
+			return value
+		}
+	}
+	return ""
+}
+
+// row returns the row for the next iteration, or nil without a feeder.
+func (f *feeder) row() *feederRow {
+	if f == nil {
+		return nil
+	}
+	n := f.next.Add(1) - 1
+	return &f.rows[n%uint64(len(f.rows))]
+}
+
+func (r *feederRow) fill(spec models.RequestSpec) models.RequestSpec {
+	if r == nil {
+		return spec
+	}
+	spec.URL = r.fillURL(spec.URL)
+	spec.Body = r.fillBody(spec.Body, spec.Headers)
+	if len(spec.Headers) > 0 {
+		headers := make(map[string]string, len(spec.Headers))
+		for name, value := range spec.Headers {
+			headers[name] = r.text.Replace(value)
+		}
+		spec.Headers = headers
+	}
+	return spec
+}
+
+// fillURL escapes values as path segments up to the query string and as
+// query values after it.
+func (r *feederRow) fillURL(rawURL string) string {
+	path, query, found := strings.Cut(rawURL, "?")
+	if !found {
+		return r.path.Replace(path)
+	}
+	return r.path.Replace(path) + "?" + r.query.Replace(query)
+}
+
+// fillBody escapes values for the body's Content-Type, taken from the
+// request's headers or else the test's.
+func (r *feederRow) fillBody(body string, headers map[string]string) string {
+	contentType := headerValue(headers, "Content-Type")
+	if contentType == "" {
+		contentType = r.contentType
+	}
+	mediaType, _, _ := strings.Cut(strings.ToLower(contentType), ";")
+	mediaType = strings.TrimSpace(mediaType)
+	switch {
+	case mediaType == "application/json" || strings.HasSuffix(mediaType, "+json"):
+		return r.json.Replace(body)
+	case mediaType == "application/x-www-form-urlencoded":
+		return r.query.Replace(body)
+	}
+	return r.text.Replace(body)
+}
@@ -0,0 +1,172 @@
+package worker
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net"
+	"net/url"
+	"time"
+
+	"github.com/Vinayak9769/loadagg/internal/protoset"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/types/dynamicpb"
+)
+
+// reflectionTimeout bounds resolving the method through server reflection.
+const reflectionTimeout = 30 * time.Second
+
+// grpcDriver makes unary or server-streaming calls with a request message
+// built from JSON against descriptors resolved at start up. Outcomes are
+// keyed by gRPC status code.
+type grpcDriver struct {
+	cfg      *Config
+	conn     *grpc.ClientConn
+	method   string
+	desc     protoreflect.MethodDescriptor
+	request  *dynamicpb.Message
+	metadata metadata.MD
+	recorder *Recorder
+}
+
+func newGRPCDriver(ctx context.Context, cfg *Config, api *APIClient, recorder *Recorder) (*grpcDriver, error) {
+	if cfg.GRPC == nil {
+		return nil, fmt.Errorf("missing GRPC_CONFIG for grpc target")
+	}
+
+	u, err := url.Parse(cfg.TargetURL)
+	if err != nil {
+		return nil, fmt.Errorf("invalid TARGET_URL: %v", err)
+	}
+	creds := insecure.NewCredentials()
+	if u.Scheme == "grpcs" {
+		creds = credentials.NewTLS(newTLSConfig(cfg))
+	}
+	dial := newDialer(cfg)
+	target := u.Host
+	if newResolvingDialer(cfg) != nil {
+		// hand the host name to the dialer instead of letting gRPC
+		// resolve it
+		target = "passthrough:///" + u.Host
+	}
+	conn, err := grpc.NewClient(target,
+		grpc.WithTransportCredentials(creds),
+		grpc.WithContextDialer(func(ctx context.Context, addr string) (net.Conn, error) {
+			return dial(ctx, "tcp", addr)
+		}))
+	if err != nil {
+		return nil, err
+	}
+
+	desc, err := resolveMethod(ctx, cfg, api, conn)
+	if err != nil {
+		conn.Close()
+		return nil, err
+	}
+	request, err := protoset.NewRequest(desc, cfg.GRPC.Message)
+	if err != nil {
+		conn.Close()
+		return nil, err
+	}
+
+	return &grpcDriver{
+		cfg:      cfg,
+		conn:     conn,
+		method:   fmt.Sprintf("/%s/%s", desc.Parent().FullName(), desc.Name()),
+		desc:     desc,
+		request:  request,
+		metadata: metadata.New(cfg.GRPC.Metadata),
+		recorder: recorder,
+	}, nil
+}
+
+// resolveMethod finds the method descriptor in the test's uploaded descriptor
+// set or, without one, through server reflection.
+func resolveMethod(ctx context.Context, cfg *Config, api *APIClient, conn *grpc.ClientConn) (protoreflect.MethodDescriptor, error) {
+	if cfg.GRPC.ProtosetID != "" {
+		if api == nil {
+			return nil, fmt.Errorf("fetching the protoset requires API_URL and WORKER_TOKEN")
+		}
+		data, err := api.Protoset(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("failed to fetch protoset: %v", err)
+		}
+		files, err := protoset.Parse(data)
+		if err != nil {
+			return nil, err
+		}
+		return protoset.FindMethod(files, cfg.GRPC.Service, cfg.GRPC.Method)
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, reflectionTimeout)
+	defer cancel()
+	files, err := protoset.Reflect(ctx, conn, cfg.GRPC.Service)
+	if err != nil {
+		return nil, err
+	}
+	return protoset.FindMethod(files, cfg.GRPC.Service, cfg.GRPC.Method)
+}
+
+func (d *grpcDriver) Iterate(_ context.Context, _ *VU, intended time.Time) {
+	ctx, cancel := context.WithTimeout(context.Background(), d.cfg.RequestTimeout)
+	defer cancel()
+	ctx = metadata.NewOutgoingContext(ctx, d.metadata)
+
+	sent := time.Now()
+	var messages int64
+	var err error
+	if d.desc.IsStreamingServer() {
+		messages, err = d.serverStream(ctx)
+	} else {
+		err = d.conn.Invoke(ctx, d.method, d.request, dynamicpb.NewMessage(d.desc.Output()))
+	}
+	done := time.Now()
+
+	st := status.Convert(err)
+	d.recorder.Record(result{
+		code:        st.Code().String(),
+		ok:          st.Code() == codes.OK,
+		message:     st.Message(),
+		multiplexed: true,
+		messages:    messages,
+		uncorrected: done.Sub(sent),
+		corrected:   done.Sub(intended),
+	})
+}
+
+// serverStream sends the request and reads responses until the server ends
+// the stream, returning the number of messages received.
+func (d *grpcDriver) serverStream(ctx context.Context) (int64, error) {
+	stream, err := d.conn.NewStream(ctx, &grpc.StreamDesc{ServerStreams: true}, d.method)
+	if err != nil {
+		return 0, err
+	}
+	if err := stream.SendMsg(d.request); err != nil {
+		return 0, err
+	}
+	if err := stream.CloseSend(); err != nil {
+		return 0, err
+	}
+
+	var messages int64
+	for {
+		err := stream.RecvMsg(dynamicpb.NewMessage(d.desc.Output()))
+		if err == io.EOF {
+			return messages, nil
+		}
+		if err != nil {
+			return messages, err
+		}
+		messages++
+	}
+}
+
+func (d *grpcDriver) Close() error {
+	return d.conn.Close()
+}
@@ -0,0 +1,242 @@
+package worker
+
+import (
+	"context"
+	"io"
+	"math/rand/v2"
+	"net/http"
+	"net/http/cookiejar"
+	"net/http/httptrace"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Vinayak9769/loadagg/pkg/models"
+)
+
+type httpDriver struct {
+	cfg      *Config
+	client   *http.Client
+	recorder *Recorder
+	feeder   *feeder
+
+	// cumulative endpoint weights for picking one per iteration
+	weights []int
+}
+
+func newHTTPDriver(cfg *Config, recorder *Recorder) *httpDriver {
+	d := &httpDriver{
+		cfg: cfg,
+		client: &http.Client{
+			Timeout:   cfg.RequestTimeout,
+			Transport: newTransport(cfg, vuCount(cfg.VUs)),
+			// redirects are recorded as responses; following them could
+			// also take the load to a host the allowlist does not cover
+			CheckRedirect: func(*http.Request, []*http.Request) error {
+				return http.ErrUseLastResponse
+			},
+		},
+		recorder: recorder,
+		feeder:   newFeeder(cfg),
+	}
+	total := 0
+	for i, endpoint := range cfg.Endpoints {
+		total += max(0, endpoint.Weight)
+		d.weights = append(d.weights, total)
+		if endpoint.Name == "" {
+			cfg.Endpoints[i].Name = defaultEndpointName(endpoint)
+		}
+	}
+	return d
+}
+
+func (d *httpDriver) Iterate(ctx context.Context, vu *VU, intended time.Time) {
+	row := d.feeder.row()
+	jar := d.jar(vu, row)
+
+	if len(d.cfg.Endpoints) > 0 {
+		d.send(row.fill(d.pickEndpoint()), row, jar, intended)
+		return
+	}
+	if len(d.cfg.Steps) == 0 {
+		d.send(row.fill(models.RequestSpec{
+			Method: d.cfg.HTTPMethod,
+			URL:    d.cfg.TargetURL,
+			Body:   d.cfg.Body,
+		}), row, jar, intended)
+		return
+	}
+
+	// steps after the first are due once the previous one and the think
+	// time before them are over; a configured think time replaces the
+	// recorded ones
+	due := intended
+	for i, step := range d.cfg.Steps {
+		if i > 0 {
+			think := time.Duration(step.ThinkTimeMS) * time.Millisecond
+			if d.cfg.ThinkTime != nil {
+				think = sampleThinkTime(d.cfg.ThinkTime)
+			}
+			if think > 0 && !sleepContext(ctx, think) {
+				return
+			}
+			due = time.Now()
+		}
+		d.send(row.fill(step), row, jar, due)
+	}
+}
+
+// sleepContext waits for d and reports whether it did so before ctx was done.
+func sleepContext(ctx context.Context, d time.Duration) bool {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return false
+	case <-timer.C:
+		return true
+	}
+}
+
+// defaultEndpointName labels an unnamed endpoint by method and path.
+func defaultEndpointName(endpoint models.RequestSpec) string {
+	if u, err := url.Parse(endpoint.URL); err == nil {
+		return endpoint.Method + " " + u.Path
+	}
+	return endpoint.Method + " " + endpoint.URL
+}
+
+// pickEndpoint chooses an endpoint at random in proportion to its weight.
+func (d *httpDriver) pickEndpoint() models.RequestSpec {
+	total := d.weights[len(d.weights)-1]
+	if total <= 0 {
+		return d.cfg.Endpoints[rand.IntN(len(d.cfg.Endpoints))]
+	}
+	n := rand.IntN(total)
+	i := sort.SearchInts(d.weights, n+1)
+	return d.cfg.Endpoints[i]
+}
+
+// jar returns the cookie jar of vu, creating it on the VU's first iteration
+// or on every one when jars are reset per iteration. It returns nil when
+// cookies are disabled.
+func (d *httpDriver) jar(vu *VU, row *feederRow) http.CookieJar {
+	cookies := d.cfg.Cookies
+	if cookies != nil && cookies.Disabled {
+		return nil
+	}
+	jar, _ := vu.state.(*cookiejar.Jar)
+	if jar != nil && (cookies == nil || !cookies.ResetPerIteration) {
+		return jar
+	}
+
+	jar, _ = cookiejar.New(nil)
+	if cookies != nil {
+		d.seed(jar, cookies.Initial, row)
+	}
+	vu.state = jar
+	return jar
+}
+
+// seed puts the initial cookies into a new jar, scoped to their domain or
+// else to the target host.
+func (d *httpDriver) seed(jar *cookiejar.Jar, initial []models.Cookie, row *feederRow) {
+	target, err := url.Parse(d.cfg.TargetURL)
+	if err != nil {
+		return
+	}
+	for _, c := range initial {
+		u := *target
+		if c.Domain != "" {
+			u.Host = strings.TrimPrefix(c.Domain, ".")
+		}
+		cookie := &http.Cookie{Name: c.Name, Value: c.Value, Domain: c.Domain, Path: c.Path}
+		if row != nil {
+			cookie.Value = row.text.Replace(cookie.Value)
+		}
+		if cookie.Path == "" {
+			cookie.Path = "/"
+		}
+		jar.SetCookies(&u, []*http.Cookie{cookie})
+	}
+}
+
+// send makes one request with the test's headers plus the request's own,
+// filling feeder references in the test's headers from row. Cookies are
+// kept in jar unless it is nil.
+func (d *httpDriver) send(spec models.RequestSpec, row *feederRow, jar http.CookieJar, intended time.Time) {
+	var body io.Reader
+	if spec.Body != "" && spec.Method != http.MethodGet {
+		body = strings.NewReader(spec.Body)
+	}
+
+	req, err := http.NewRequest(spec.Method, spec.URL, body)
+	if err != nil {
+		d.recorder.RecordEndpointError(spec.Name, ErrOther, err.Error(), 0, time.Since(intended))
+		return
+	}
+	for name, value := range d.cfg.Headers {
+		if row != nil {
+			value = row.text.Replace(value)
+		}
+		req.Header.Set(name, value)
+	}
+	for name, value := range spec.Headers {
+		req.Header.Set(name, value)
+	}
+
+	trace := &requestTrace{}
+	req = req.WithContext(httptrace.WithClientTrace(req.Context(), trace.clientTrace()))
+
+	client := d.client
+	if jar != nil {
+		// a shallow copy shares the transport and its connections
+		withJar := *d.client
+		withJar.Jar = jar
+		client = &withJar
+	}
+
+	sent := time.Now()
+	resp, err := client.Do(req)
+	if err == nil {
+		err = d.drain(resp)
+	}
+	done := time.Now()
+
+	if err != nil {
+		d.recorder.RecordEndpointError(spec.Name, classifyError(err, trace.requestPhase()), err.Error(), done.Sub(sent), done.Sub(intended))
+		return
+	}
+	timings := trace.timings(done)
+	d.recorder.Record(result{
+		code:        strconv.Itoa(resp.StatusCode),
+		endpoint:    spec.Name,
+		ok:          resp.StatusCode >= 200 && resp.StatusCode < 300,
+		proto:       resp.Proto,
+		multiplexed: isMultiplexed(resp.ProtoMajor),
+		uncorrected: done.Sub(sent),
+		corrected:   done.Sub(intended),
+		timings:     &timings,
+	})
+}
+
+// drain reads and closes the response body, failing once it exceeds the
+// configured size limit.
+func (d *httpDriver) drain(resp *http.Response) error {
+	defer resp.Body.Close()
+	n, err := io.Copy(io.Discard, io.LimitReader(resp.Body, d.cfg.MaxResponseBytes+1))
+	if err != nil {
+		return err
+	}
+	if n > d.cfg.MaxResponseBytes {
+		return errBodyTooLarge
+	}
+	return nil
+}
+
+func (d *httpDriver) Close() error {
+	d.client.CloseIdleConnections()
+	return nil
+}
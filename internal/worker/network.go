@@ -0,0 +1,240 @@
+package worker
+
+import (
+	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/http/httptrace"
+	"net/url"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/Vinayak9769/loadagg/pkg/models"
+	"github.com/quic-go/quic-go"
+)
+
+type dialFunc func(ctx context.Context, network, address string) (net.Conn, error)
+
+// newDialer returns how the worker opens connections: through the host
+// overrides, DNS server and DNS cache of the test when it sets any, the
+// standard dialer otherwise.
+func newDialer(cfg *Config) dialFunc {
+	if d := newResolvingDialer(cfg); d != nil {
+		return d.DialContext
+	}
+	return newNetDialer(cfg).DialContext
+}
+
+// newNetDialer returns a dialer that refuses addresses outside the allowlist.
+func newNetDialer(cfg *Config) *net.Dialer {
+	return &net.Dialer{
+		Timeout: socketDialTimeout,
+		Control: func(_, address string, _ syscall.RawConn) error {
+			host, _, err := net.SplitHostPort(address)
+			if err != nil {
+				return err
+			}
+			return checkAddress(cfg, net.ParseIP(host))
+		},
+	}
+}
+
+// checkAddress refuses addresses in the blocked networks outside the private
+// ranges of the allowlist. The API checked the target when the
+// test was created, but names are resolved again here and may have changed
+// since.
+func checkAddress(cfg *Config, ip net.IP) error {
+	if ip == nil {
+		return errors.New("connecting to an unparsable address is not allowed")
+	}
+	if !models.IsBlockedIP(ip) {
+		return nil
+	}
+	for _, network := range cfg.AllowedNetworks {
+		if network.Contains(ip) {
+			return nil
+		}
+	}
+	return fmt.Errorf("connecting to %s is not allowed, the address is not in the allowlist", ip)
+}
+
+// newQUICDialer returns how HTTP/3 connections are opened. QUIC does not
+// dial through a net.Dialer, so addresses are resolved and checked here.
+func newQUICDialer(cfg *Config) func(context.Context, string, *tls.Config, *quic.Config) (*quic.Conn, error) {
+	d := newResolvingDialer(cfg)
+	if d == nil {
+		d = &resolvingDialer{cfg: cfg, dialer: newNetDialer(cfg), resolver: net.DefaultResolver}
+	}
+	return d.dialQUIC
+}
+
+// newResolvingDialer returns nil unless the test changes name resolution.
+func newResolvingDialer(cfg *Config) *resolvingDialer {
+	n := cfg.Network
+	if n == nil || (len(n.Hosts) == 0 && n.DNSServer == "" && n.DNSCacheTTL <= 0) {
+		return nil
+	}
+
+	dialer := newNetDialer(cfg)
+	d := &resolvingDialer{
+		cfg:      cfg,
+		dialer:   dialer,
+		hosts:    make(map[string]string, len(n.Hosts)),
+		resolver: net.DefaultResolver,
+		ttl:      time.Duration(n.DNSCacheTTL) * time.Second,
+		cache:    make(map[string]cachedLookup),
+	}
+	for host, ip := range n.Hosts {
+		d.hosts[strings.ToLower(host)] = ip
+	}
+	if server := n.DNSServer; server != "" {
+		d.resolver = &net.Resolver{
+			PreferGo: true,
+			Dial: func(ctx context.Context, network, _ string) (net.Conn, error) {
+				return dialer.DialContext(ctx, network, server)
+			},
+		}
+	}
+	return d
+}
+
+// newProxy returns the proxy selection of the test, falling back to the
+// standard proxy environment variables.
+func newProxy(cfg *Config) func(*http.Request) (*url.URL, error) {
+	if cfg.Network == nil || cfg.Network.Proxy == "" {
+		return http.ProxyFromEnvironment
+	}
+	proxyURL, err := url.Parse(cfg.Network.Proxy)
+	if err != nil {
+		return func(*http.Request) (*url.URL, error) { return nil, err }
+	}
+	return http.ProxyURL(proxyURL)
+}
+
+// resolvingDialer resolves host names itself before connecting, so that
+// overrides and a custom resolver apply.
+type resolvingDialer struct {
+	cfg      *Config
+	dialer   *net.Dialer
+	hosts    map[string]string
+	resolver *net.Resolver
+	ttl      time.Duration
+
+	mu    sync.Mutex
+	cache map[string]cachedLookup
+}
+
+type cachedLookup struct {
+	addrs   []string
+	expires time.Time
+}
+
+// DialContext connects to the addresses of the host in turn until one
+// accepts.
+func (d *resolvingDialer) DialContext(ctx context.Context, network, address string) (net.Conn, error) {
+	host, port, err := net.SplitHostPort(address)
+	if err != nil {
+		return d.dialer.DialContext(ctx, network, address)
+	}
+	addrs, err := d.lookup(ctx, host)
+	if err != nil {
+		return nil, err
+	}
+
+	var lastErr error
+	for _, addr := range addrs {
+		conn, err := d.dialer.DialContext(ctx, network, net.JoinHostPort(addr, port))
+		if err == nil {
+			return conn, nil
+		}
+		lastErr = err
+		if ctx.Err() != nil {
+			break
+		}
+	}
+	return nil, lastErr
+}
+
+func (d *resolvingDialer) lookup(ctx context.Context, host string) ([]string, error) {
+	if addr, ok := d.hosts[strings.ToLower(host)]; ok {
+		return []string{addr}, nil
+	}
+	if net.ParseIP(host) != nil {
+		return []string{host}, nil
+	}
+
+	if d.ttl > 0 {
+		d.mu.Lock()
+		cached, ok := d.cache[host]
+		d.mu.Unlock()
+		if ok && time.Now().Before(cached.expires) {
+			return cached.addrs, nil
+		}
+	}
+	addrs, err := d.resolver.LookupHost(ctx, host)
+	if err != nil {
+		return nil, err
+	}
+	if d.ttl > 0 {
+		d.mu.Lock()
+		d.cache[host] = cachedLookup{addrs: addrs, expires: time.Now().Add(d.ttl)}
+		d.mu.Unlock()
+	}
+	return addrs, nil
+}
+
+// remoteIP returns the IP part of a connection's remote address.
+func remoteIP(addr net.Addr) string {
+	if addr == nil {
+		return ""
+	}
+	host, _, err := net.SplitHostPort(addr.String())
+	if err != nil {
+		return addr.String()
+	}
+	return host
+}
+
+// dialQUIC connects HTTP/3 to the first address of the host. http3 only
+// reports the connect and TLS phases from its own dialer, so they are traced
+// here the same way: the QUIC handshake sets up the connection and TLS in one
+// exchange and is reported as both.
+func (d *resolvingDialer) dialQUIC(ctx context.Context, address string, tlsConfig *tls.Config, quicConfig *quic.Config) (*quic.Conn, error) {
+	host, port, err := net.SplitHostPort(address)
+	if err != nil {
+		return nil, err
+	}
+	addrs, err := d.lookup(ctx, host)
+	if err != nil {
+		return nil, err
+	}
+	if err := checkAddress(d.cfg, net.ParseIP(addrs[0])); err != nil {
+		return nil, err
+	}
+	addr := net.JoinHostPort(addrs[0], port)
+
+	trace := httptrace.ContextClientTrace(ctx)
+	if trace != nil && trace.ConnectStart != nil {
+		trace.ConnectStart("udp", addr)
+	}
+	if trace != nil && trace.TLSHandshakeStart != nil {
+		trace.TLSHandshakeStart()
+	}
+	conn, err := quic.DialAddrEarly(ctx, addr, tlsConfig, quicConfig)
+	if trace != nil && trace.TLSHandshakeDone != nil {
+		var state tls.ConnectionState
+		if conn != nil {
+			state = conn.ConnectionState().TLS
+		}
+		trace.TLSHandshakeDone(state, err)
+	}
+	if trace != nil && trace.ConnectDone != nil {
+		trace.ConnectDone("udp", addr, err)
+	}
+	return conn, err
+}
@@ -0,0 +1,400 @@
+package worker
+
+import (
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/Vinayak9769/loadagg/internal/secrets"
+	"github.com/Vinayak9769/loadagg/pkg/models"
+)
+
+// Recorder accumulates the results of one worker and renders them as the
+// LoadTestMetrics the controller scrapes from the pod logs.
+type Recorder struct {
+	mu          sync.Mutex
+	testID      string
+	workerIndex int
+	start       time.Time
+	paused      time.Duration
+	targetRPS   int
+
+	total, successful, failed, dropped int64
+	iterations                         int64
+	statusCodes                        map[string]int64
+	errors                             map[string]int64
+	errorSamples                       map[string][]string
+	corrected, uncorrected             *models.Histogram
+	phases                             *models.PhaseHistograms
+	newConns, reusedConns, streams     int64
+	protocols                          map[string]int64
+	targetIPs                          map[string]int64
+	messages, messagesSent             int64
+	connectTime                        *models.Histogram
+	disconnects                        map[string]int64
+	activeStreams, peakStreams         int64
+	reconnects                         int64
+	firstEvent, eventGaps              *models.Histogram
+	endpoints                          map[string]*models.EndpointMetrics
+	redactor                           *strings.Replacer // hides secret values in error samples
+}
+
+// result is the outcome of a request that received a response.
+type result struct {
+	code        string // HTTP status or gRPC status code name
+	endpoint    string // name of the request, reported separately when set
+	ok          bool
+	message     string // error detail kept as a sample when not ok
+	proto       string
+	multiplexed bool  // sent as a stream on an HTTP/2 or HTTP/3 connection
+	messages    int64 // streamed response messages
+
+	// uncorrected is measured from the actual send time, corrected from the
+	// time the request was scheduled
+	uncorrected, corrected time.Duration
+	timings                *phaseTimings // nil when the driver does not trace requests
+}
+
+func NewRecorder(testID string, workerIndex int) *Recorder {
+	return &Recorder{
+		testID:       testID,
+		workerIndex:  workerIndex,
+		start:        time.Now(),
+		statusCodes:  make(map[string]int64),
+		errors:       make(map[string]int64),
+		errorSamples: make(map[string][]string),
+		corrected:    models.NewHistogram(),
+		uncorrected:  models.NewHistogram(),
+		phases:       models.NewPhaseHistograms(),
+		protocols:    make(map[string]int64),
+		targetIPs:    make(map[string]int64),
+		connectTime:  models.NewHistogram(),
+		disconnects:  make(map[string]int64),
+		firstEvent:   models.NewHistogram(),
+		eventGaps:    models.NewHistogram(),
+		endpoints:    make(map[string]*models.EndpointMetrics),
+	}
+}
+
+// Start resets the reference point for elapsed time, e.g. to the
+// synchronized start instant.
+func (r *Recorder) Start(t time.Time) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.start = t
+}
+
+// Redact keeps values out of the error samples the recorder reports.
+func (r *Recorder) Redact(values []string) {
+	var pairs []string
+	for _, value := range values {
+		if value != "" {
+			pairs = append(pairs, value, secrets.Redacted)
+		}
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if len(pairs) > 0 {
+		r.redactor = strings.NewReplacer(pairs...)
+	}
+}
+
+func (r *Recorder) SetTargetRPS(rps int) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.targetRPS = rps
+}
+
+func (r *Recorder) AddPaused(d time.Duration) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.paused += d
+}
+
+// Record stores the outcome of one request that received a response.
+func (r *Recorder) Record(res result) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.total++
+	if res.ok {
+		r.successful++
+	} else {
+		r.failed++
+	}
+	if res.code != "" {
+		r.statusCodes[res.code]++
+	}
+	if !res.ok && res.message != "" {
+		models.AddErrorSample(r.errorSamples, res.code, r.redact(res.message))
+	}
+	if res.proto != "" {
+		r.protocols[res.proto]++
+	}
+	if res.multiplexed {
+		r.streams++
+	}
+	r.messages += res.messages
+	r.observe(res.uncorrected, res.corrected)
+	if endpoint := r.endpoint(res.endpoint, res.uncorrected, res.corrected); endpoint != nil {
+		if res.ok {
+			endpoint.SuccessfulRequests++
+		} else {
+			endpoint.FailedRequests++
+		}
+		if res.code != "" {
+			endpoint.StatusCodes[res.code]++
+		}
+	}
+
+	timings := res.timings
+	if timings == nil {
+		return
+	}
+	if timings.remoteIP != "" {
+		r.targetIPs[timings.remoteIP]++
+	}
+	if timings.reused {
+		r.reusedConns++
+	} else {
+		r.newConns++
+	}
+	observePhase(r.phases.DNS, timings.dns)
+	observePhase(r.phases.Connect, timings.connect)
+	observePhase(r.phases.TLS, timings.tls)
+	observePhase(r.phases.TTFB, timings.ttfb)
+	observePhase(r.phases.Transfer, timings.transfer)
+}
+
+// observePhase skips phases the request did not go through.
+func observePhase(h *models.Histogram, d time.Duration) {
+	if d > 0 {
+		h.Observe(d.Seconds())
+	}
+}
+
+// RecordConnect stores the setup time of a session-oriented connection.
+func (r *Recorder) RecordConnect(d time.Duration) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.connectTime.Observe(d.Seconds())
+}
+
+// RecordDisconnect counts a session that ended, by reason.
+func (r *Recorder) RecordDisconnect(reason string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.disconnects[reason]++
+}
+
+func (r *Recorder) MessageSent() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.messagesSent++
+}
+
+func (r *Recorder) MessageReceived() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.messages++
+}
+
+// StreamOpened and StreamClosed track the subscriptions currently held.
+func (r *Recorder) StreamOpened() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.activeStreams++
+	r.peakStreams = max(r.peakStreams, r.activeStreams)
+}
+
+func (r *Recorder) StreamClosed() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.activeStreams--
+}
+
+func (r *Recorder) Reconnected() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.reconnects++
+}
+
+// RecordEvent counts an event received on a subscription. The first event
+// of a subscription is timed from its start, later ones from the previous
+// event.
+func (r *Recorder) RecordEvent(first bool, d time.Duration) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.messages++
+	if first {
+		r.firstEvent.Observe(d.Seconds())
+	} else {
+		r.eventGaps.Observe(d.Seconds())
+	}
+}
+
+// RecordError stores a request that failed without a complete response,
+// keeping a few of the messages of each category as samples.
+func (r *Recorder) RecordError(category, message string, uncorrected, corrected time.Duration) {
+	r.RecordEndpointError("", category, message, uncorrected, corrected)
+}
+
+// RecordEndpointError is RecordError for a named request.
+func (r *Recorder) RecordEndpointError(endpoint, category, message string, uncorrected, corrected time.Duration) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.total++
+	r.failed++
+	r.errors[category]++
+	models.AddErrorSample(r.errorSamples, category, r.redact(message))
+	r.observe(uncorrected, corrected)
+	if e := r.endpoint(endpoint, uncorrected, corrected); e != nil {
+		e.FailedRequests++
+		e.Errors[category]++
+	}
+}
+
+func (r *Recorder) redact(message string) string {
+	if r.redactor == nil {
+		return message
+	}
+	return r.redactor.Replace(message)
+}
+
+func (r *Recorder) observe(uncorrected, corrected time.Duration) {
+	r.uncorrected.Observe(uncorrected.Seconds())
+	r.corrected.Observe(corrected.Seconds())
+}
+
+// endpoint counts a request of the named endpoint and returns its metrics
+// for the outcome, or nil for unnamed requests.
+func (r *Recorder) endpoint(name string, uncorrected, corrected time.Duration) *models.EndpointMetrics {
+	if name == "" {
+		return nil
+	}
+	e := r.endpoints[name]
+	if e == nil {
+		e = models.NewEndpointMetrics()
+		r.endpoints[name] = e
+	}
+	e.TotalRequests++
+	e.LatencyUncorrected.Observe(uncorrected.Seconds())
+	e.LatencyCorrected.Observe(corrected.Seconds())
+	return e
+}
+
+// Iteration counts an iteration a VU started.
+func (r *Recorder) Iteration() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.iterations++
+}
+
+// Dropped counts a scheduled iteration that could not start because every
+// VU was still busy.
+func (r *Recorder) Dropped() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.dropped++
+}
+
+func (r *Recorder) Snapshot() *models.LoadTestMetrics {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	now := time.Now()
+	elapsed := max(0, now.Sub(r.start)-r.paused)
+
+	metrics := &models.LoadTestMetrics{
+		TestID:             r.testID,
+		Timestamp:          now.UTC(),
+		ElapsedSeconds:     int64(elapsed.Seconds()),
+		TotalRequests:      r.total,
+		SuccessfulRequests: r.successful,
+		FailedRequests:     r.failed,
+		MinResponseTime:    r.uncorrected.Min,
+		MaxResponseTime:    r.uncorrected.Max,
+		StatusCodes:        make(map[string]int64, len(r.statusCodes)),
+		PausedSeconds:      int64(r.paused.Seconds()),
+		WorkerIndex:        r.workerIndex,
+		TargetRPS:          r.targetRPS,
+		DroppedIterations:  r.dropped,
+		Iterations:         r.iterations,
+		LatencyCorrected:   r.corrected.Clone(),
+		LatencyUncorrected: r.uncorrected.Clone(),
+		Phases:             r.phases.Clone(),
+		NewConnections:     r.newConns,
+		ReusedConnections:  r.reusedConns,
+		Streams:            r.streams,
+		MessagesReceived:   r.messages,
+		MessagesSent:       r.messagesSent,
+		Protocols:          make(map[string]int64, len(r.protocols)),
+		ActiveConnections:  r.activeStreams,
+		PeakConnections:    r.peakStreams,
+		Reconnects:         r.reconnects,
+	}
+	for code, count := range r.statusCodes {
+		metrics.StatusCodes[code] = count
+	}
+	for proto, count := range r.protocols {
+		metrics.Protocols[proto] = count
+	}
+	if len(r.targetIPs) > 0 {
+		metrics.TargetIPs = make(map[string]int64, len(r.targetIPs))
+		for ip, count := range r.targetIPs {
+			metrics.TargetIPs[ip] = count
+		}
+	}
+	if len(r.errors) > 0 {
+		metrics.Errors = make(map[string]int64, len(r.errors))
+		for category, count := range r.errors {
+			metrics.Errors[category] = count
+		}
+	}
+	if r.connectTime.Count > 0 {
+		metrics.ConnectTime = r.connectTime.Clone()
+		metrics.ConnectTime.Summarize()
+	}
+	if r.firstEvent.Count > 0 {
+		metrics.TimeToFirstEvent = r.firstEvent.Clone()
+		metrics.TimeToFirstEvent.Summarize()
+	}
+	if r.eventGaps.Count > 0 {
+		metrics.EventGaps = r.eventGaps.Clone()
+		metrics.EventGaps.Summarize()
+	}
+	if len(r.disconnects) > 0 {
+		metrics.Disconnects = make(map[string]int64, len(r.disconnects))
+		for reason, count := range r.disconnects {
+			metrics.Disconnects[reason] = count
+		}
+	}
+	if len(r.errorSamples) > 0 {
+		metrics.ErrorSamples = make(map[string][]string, len(r.errorSamples))
+		for category, samples := range r.errorSamples {
+			metrics.ErrorSamples[category] = append([]string(nil), samples...)
+		}
+	}
+	if len(r.endpoints) > 0 {
+		metrics.Endpoints = make(map[string]*models.EndpointMetrics, len(r.endpoints))
+		models.MergeEndpoints(metrics.Endpoints, r.endpoints)
+		for _, e := range metrics.Endpoints {
+			e.Summarize(elapsed.Seconds())
+		}
+	}
+	metrics.LatencyCorrected.Summarize()
+	metrics.LatencyUncorrected.Summarize()
+	metrics.Phases.Summarize()
+
+	if r.total > 0 {
+		metrics.AvgResponseTime = r.uncorrected.Sum / float64(r.total)
+		metrics.ErrorRate = float64(r.failed) * 100 / float64(r.total)
+	}
+	if elapsed > 0 {
+		metrics.RequestsPerSecond = float64(r.total) / elapsed.Seconds()
+		metrics.IterationRate = float64(r.iterations) / elapsed.Seconds()
+	}
+
+	return metrics
+}
@@ -0,0 +1,137 @@
+package worker
+
+import (
+	"math"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/Vinayak9769/loadagg/internal/secrets"
+)
+
+func TestRecorderCounts(t *testing.T) {
+	r := NewRecorder("test-1", 2)
+	r.Record(result{code: "200", ok: true, proto: "HTTP/2.0", multiplexed: true, uncorrected: 10 * time.Millisecond, corrected: 30 * time.Millisecond})
+	r.Record(result{code: "200", ok: true, endpoint: "GET /a", uncorrected: 20 * time.Millisecond, corrected: 20 * time.Millisecond})
+	r.Record(result{code: "503", message: "unavailable", endpoint: "GET /a", uncorrected: 5 * time.Millisecond, corrected: 5 * time.Millisecond})
+	r.RecordError(ErrReadTimeout, "deadline exceeded", time.Second, 2*time.Second)
+	r.RecordEndpointError("GET /a", ErrReadTimeout, "deadline exceeded", time.Second, time.Second)
+	r.Iteration()
+	r.Iteration()
+	r.Dropped()
+
+	m := r.Snapshot()
+	if m.TestID != "test-1" || m.WorkerIndex != 2 {
+		t.Errorf("snapshot of %q worker %d", m.TestID, m.WorkerIndex)
+	}
+	if m.TotalRequests != 5 || m.SuccessfulRequests != 2 || m.FailedRequests != 3 {
+		t.Errorf("requests %d/%d/%d, want 5 total, 2 successful, 3 failed", m.TotalRequests, m.SuccessfulRequests, m.FailedRequests)
+	}
+	if want := map[string]int64{"200": 2, "503": 1}; !reflect.DeepEqual(m.StatusCodes, want) {
+		t.Errorf("status codes = %v, want %v", m.StatusCodes, want)
+	}
+	if want := map[string]int64{ErrReadTimeout: 2}; !reflect.DeepEqual(m.Errors, want) {
+		t.Errorf("errors = %v, want %v", m.Errors, want)
+	}
+	// samples are kept once per message
+	if want := map[string][]string{"503": {"unavailable"}, ErrReadTimeout: {"deadline exceeded"}}; !reflect.DeepEqual(m.ErrorSamples, want) {
+		t.Errorf("error samples = %v, want %v", m.ErrorSamples, want)
+	}
+	if m.Iterations != 2 || m.DroppedIterations != 1 {
+		t.Errorf("iterations %d, dropped %d; want 2 and 1", m.Iterations, m.DroppedIterations)
+	}
+	if m.Streams != 1 || m.Protocols["HTTP/2.0"] != 1 {
+		t.Errorf("streams %d, protocols %v", m.Streams, m.Protocols)
+	}
+	if m.ErrorRate != 60 {
+		t.Errorf("error rate = %g, want 60", m.ErrorRate)
+	}
+
+	// latency is kept both from the send time and from the scheduled time
+	if m.LatencyUncorrected.Count != 5 || m.LatencyCorrected.Count != 5 {
+		t.Errorf("latency counts %d and %d, want 5", m.LatencyUncorrected.Count, m.LatencyCorrected.Count)
+	}
+	if got := m.LatencyUncorrected.Sum; math.Abs(got-2.035) > 1e-9 {
+		t.Errorf("uncorrected sum = %g, want 2.035", got)
+	}
+	if got := m.LatencyCorrected.Sum; math.Abs(got-3.055) > 1e-9 {
+		t.Errorf("corrected sum = %g, want 3.055", got)
+	}
+	if m.MinResponseTime != 0.005 || m.MaxResponseTime != 1 || math.Abs(m.AvgResponseTime-0.407) > 1e-9 {
+		t.Errorf("min %g, max %g, avg %g; want 0.005, 1, 0.407", m.MinResponseTime, m.MaxResponseTime, m.AvgResponseTime)
+	}
+
+	endpoint := m.Endpoints["GET /a"]
+	if endpoint == nil || len(m.Endpoints) != 1 {
+		t.Fatalf("endpoints = %v, want only GET /a", m.Endpoints)
+	}
+	if endpoint.TotalRequests != 3 || endpoint.SuccessfulRequests != 1 || endpoint.FailedRequests != 2 {
+		t.Errorf("endpoint requests %d/%d/%d, want 3 total, 1 successful, 2 failed",
+			endpoint.TotalRequests, endpoint.SuccessfulRequests, endpoint.FailedRequests)
+	}
+	if endpoint.Errors[ErrReadTimeout] != 1 || endpoint.StatusCodes["503"] != 1 {
+		t.Errorf("endpoint errors %v, status codes %v", endpoint.Errors, endpoint.StatusCodes)
+	}
+}
+
+func TestRecorderPhases(t *testing.T) {
+	r := NewRecorder("test-1", 0)
+	r.Record(result{code: "200", ok: true, timings: &phaseTimings{
+		remoteIP: "10.0.0.1",
+		dns:      time.Millisecond, connect: 2 * time.Millisecond, tls: 3 * time.Millisecond,
+		ttfb: 4 * time.Millisecond, transfer: 5 * time.Millisecond,
+	}})
+	// a reused connection goes through no DNS, connect or TLS phase
+	r.Record(result{code: "200", ok: true, timings: &phaseTimings{
+		remoteIP: "10.0.0.1", reused: true, ttfb: 4 * time.Millisecond,
+	}})
+	r.Record(result{code: "200", ok: true})
+
+	m := r.Snapshot()
+	if m.NewConnections != 1 || m.ReusedConnections != 1 {
+		t.Errorf("new %d, reused %d connections; want 1 and 1", m.NewConnections, m.ReusedConnections)
+	}
+	if want := map[string]int64{"10.0.0.1": 2}; !reflect.DeepEqual(m.TargetIPs, want) {
+		t.Errorf("target IPs = %v, want %v", m.TargetIPs, want)
+	}
+	counts := map[string]int64{
+		"dns": m.Phases.DNS.Count, "connect": m.Phases.Connect.Count, "tls": m.Phases.TLS.Count,
+		"ttfb": m.Phases.TTFB.Count, "transfer": m.Phases.Transfer.Count,
+	}
+	want := map[string]int64{"dns": 1, "connect": 1, "tls": 1, "ttfb": 2, "transfer": 1}
+	if !reflect.DeepEqual(counts, want) {
+		t.Errorf("phase counts = %v, want %v", counts, want)
+	}
+}
+
+func TestRecorderRedact(t *testing.T) {
+	r := NewRecorder("test-1", 0)
+	r.Redact([]string{"s3cret", ""})
+	r.RecordError(ErrOther, "auth with s3cret failed", 0, 0)
+
+	m := r.Snapshot()
+	want := []string{"auth with " + secrets.Redacted + " failed"}
+	if got := m.ErrorSamples[ErrOther]; !reflect.DeepEqual(got, want) {
+		t.Errorf("samples = %q, want %q", got, want)
+	}
+}
+
+func TestRecorderRates(t *testing.T) {
+	r := NewRecorder("test-1", 0)
+	r.Start(time.Now().Add(-10 * time.Second))
+	r.AddPaused(4 * time.Second)
+	r.SetTargetRPS(7)
+	for i := 0; i < 12; i++ {
+		r.Iteration()
+		r.Record(result{code: "200", ok: true})
+	}
+
+	// paused time does not count towards the elapsed time
+	m := r.Snapshot()
+	if m.ElapsedSeconds != 6 || m.PausedSeconds != 4 || m.TargetRPS != 7 {
+		t.Errorf("elapsed %d, paused %d, target %d; want 6, 4, 7", m.ElapsedSeconds, m.PausedSeconds, m.TargetRPS)
+	}
+	if math.Abs(m.RequestsPerSecond-2) > 0.01 || math.Abs(m.IterationRate-2) > 0.01 {
+		t.Errorf("rates %g and %g, want 2", m.RequestsPerSecond, m.IterationRate)
+	}
+}
@@ -0,0 +1,233 @@
+package worker
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/Vinayak9769/loadagg/pkg/models"
+)
+
+// replayLoopGap separates the end of a looped log from its next pass.
+const replayLoopGap = time.Second
+
+// replayRequest is an access log entry handed to a VU.
+type replayRequest struct {
+	intended time.Time
+	entry    models.ReplayEntry
+}
+
+// ReplayExecutor sends this worker's share of an access log at the log's
+// relative timing, compressed by the replay speed. Like the arrival rate
+// executor it drops a request when no VU is idle before the next one is
+// due, rather than letting a slow target stretch the replay. Rate changes
+// only update the reported target, since the log sets the pace.
+type ReplayExecutor struct {
+	cfg      *Config
+	driver   *httpDriver
+	recorder *Recorder
+	entries  []models.ReplayEntry
+	base     string
+	speed    float64
+
+	mu      sync.Mutex
+	paused  bool
+	vus     int
+	changed chan struct{}
+
+	work    chan replayRequest
+	quit    chan struct{}
+	vuCtx   context.Context
+	stopVUs context.CancelFunc
+	wg      sync.WaitGroup
+}
+
+func newReplayExecutor(ctx context.Context, cfg *Config, api *APIClient, recorder *Recorder) (*ReplayExecutor, *httpDriver, error) {
+	if api == nil {
+		return nil, nil, fmt.Errorf("fetching the access log requires API_URL and WORKER_TOKEN")
+	}
+	entries, err := api.Replay(ctx, cfg.WorkerIndex, max(1, cfg.WorkerCount))
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to fetch access log: %v", err)
+	}
+
+	speed := cfg.Replay.Speed
+	if speed <= 0 {
+		speed = 1
+	}
+	driver := newHTTPDriver(cfg, recorder)
+	vuCtx, stopVUs := context.WithCancel(context.Background())
+	return &ReplayExecutor{
+		cfg:      cfg,
+		driver:   driver,
+		recorder: recorder,
+		entries:  entries,
+		base:     strings.TrimSuffix(cfg.TargetURL, "/"),
+		speed:    speed,
+		changed:  make(chan struct{}, 1),
+		work:     make(chan replayRequest),
+		quit:     make(chan struct{}),
+		vuCtx:    vuCtx,
+		stopVUs:  stopVUs,
+	}, driver, nil
+}
+
+func (e *ReplayExecutor) SetRate(rate int) {
+	e.recorder.SetTargetRPS(rate)
+}
+
+func (e *ReplayExecutor) SetPaused(paused bool) {
+	e.mu.Lock()
+	e.paused = paused
+	e.mu.Unlock()
+	select {
+	case e.changed <- struct{}{}:
+	default:
+	}
+}
+
+// SetVUs grows or shrinks the VU pool, letting in-flight requests finish.
+func (e *ReplayExecutor) SetVUs(vus int) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	for ; e.vus < vus; e.vus++ {
+		e.wg.Add(1)
+		go e.runVU(e.vuCtx)
+	}
+	if e.vus > vus {
+		stop := e.vus - vus
+		e.vus = vus
+		go func() {
+			for i := 0; i < stop; i++ {
+				select {
+				case e.quit <- struct{}{}:
+				case <-e.vuCtx.Done():
+					return
+				}
+			}
+		}()
+	}
+}
+
+// Run replays the entries until the log, or with loop the test, ends.
+// Pauses push back both the rest of the log and the end of the test.
+func (e *ReplayExecutor) Run(ctx context.Context, end time.Time) {
+	defer func() {
+		e.stopVUs()
+		e.wg.Wait()
+	}()
+	e.SetVUs(vuCount(e.cfg.VUs))
+	e.recorder.SetTargetRPS(e.cfg.RequestsPerSec)
+	if len(e.entries) == 0 {
+		return
+	}
+
+	anchor := time.Now()
+	var passOffset time.Duration
+	for i := 0; ; {
+		if e.isPaused() {
+			pauseStart := time.Now()
+			select {
+			case <-ctx.Done():
+				return
+			case <-e.changed:
+			}
+			pauseLength := time.Since(pauseStart)
+			e.recorder.AddPaused(pauseLength)
+			anchor = anchor.Add(pauseLength)
+			end = end.Add(pauseLength)
+			continue
+		}
+
+		if i == len(e.entries) {
+			if !e.cfg.Replay.Loop {
+				return
+			}
+			passOffset = e.offset(passOffset, e.entries[i-1]) + replayLoopGap
+			i = 0
+		}
+
+		intended := anchor.Add(e.offset(passOffset, e.entries[i]))
+		if !intended.Before(end) {
+			return
+		}
+		if wait := time.Until(intended); wait > 0 {
+			timer := time.NewTimer(wait)
+			select {
+			case <-ctx.Done():
+				timer.Stop()
+				return
+			case <-e.changed:
+				timer.Stop()
+				continue
+			case <-timer.C:
+			}
+		}
+
+		next := intended.Add(replayLoopGap)
+		if i+1 < len(e.entries) {
+			next = anchor.Add(e.offset(passOffset, e.entries[i+1]))
+		}
+		if !e.handoff(ctx, replayRequest{intended: intended, entry: e.entries[i]}, next) {
+			return
+		}
+		i++
+	}
+}
+
+// offset is when an entry is due after the start, at the replay speed.
+func (e *ReplayExecutor) offset(pass time.Duration, entry models.ReplayEntry) time.Duration {
+	return pass + time.Duration(float64(entry.OffsetMS)*float64(time.Millisecond)/e.speed)
+}
+
+// handoff passes a request to a VU, waiting for one to become idle at most
+// until the next request is due. It returns false if the context ends.
+func (e *ReplayExecutor) handoff(ctx context.Context, req replayRequest, next time.Time) bool {
+	select {
+	case e.work <- req:
+		return true
+	default:
+	}
+
+	timer := time.NewTimer(time.Until(next))
+	defer timer.Stop()
+	select {
+	case e.work <- req:
+	case <-timer.C:
+		e.recorder.Dropped()
+	case <-ctx.Done():
+		return false
+	}
+	return true
+}
+
+func (e *ReplayExecutor) runVU(ctx context.Context) {
+	defer e.wg.Done()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-e.quit:
+			return
+		case req := <-e.work:
+			e.recorder.Iteration()
+			// access logs hold no bodies, so requests that have one
+			// carry the test's body; the logged requests belong to no
+			// session, so no cookies are kept
+			e.driver.send(models.RequestSpec{
+				Method: req.entry.Method,
+				URL:    e.base + req.entry.Path,
+				Body:   e.cfg.Body,
+			}, nil, nil, req.intended)
+		}
+	}
+}
+
+func (e *ReplayExecutor) isPaused() bool {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	return e.paused
+}
@@ -0,0 +1,117 @@
+package worker
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/Vinayak9769/loadagg/internal/secrets"
+	"github.com/Vinayak9769/loadagg/pkg/models"
+)
+
+// expandSecrets replaces the {{secret "name"}} references in the request
+// headers and bodies, the gRPC metadata and message, the WebSocket messages
+// and the proxy URL with the values the controller mounted in dir, one file
+// per secret.
+func expandSecrets(cfg *Config, dir string) error {
+	names := secrets.ConfigReferences(models.LoadTestConfig{
+		Headers:   cfg.Headers,
+		Body:      cfg.Body,
+		Steps:     cfg.Steps,
+		Endpoints: cfg.Endpoints,
+		GRPC:      cfg.GRPC,
+		WebSocket: cfg.WebSocket,
+		Network:   cfg.Network,
+	})
+	if len(names) == 0 {
+		return nil
+	}
+
+	values := make(map[string]string, len(names))
+	for _, name := range names {
+		value, err := readSecret(dir, name)
+		if err != nil {
+			return err
+		}
+		values[name] = string(value)
+		cfg.SecretValues = append(cfg.SecretValues, string(value))
+	}
+
+	expandHeaders(cfg.Headers, values)
+	cfg.Body = secrets.Expand(cfg.Body, values)
+	for _, specs := range [][]models.RequestSpec{cfg.Steps, cfg.Endpoints} {
+		for i := range specs {
+			expandHeaders(specs[i].Headers, values)
+			specs[i].Body = secrets.Expand(specs[i].Body, values)
+		}
+	}
+	if cfg.GRPC != nil {
+		expandHeaders(cfg.GRPC.Metadata, values)
+		cfg.GRPC.Message = secrets.ExpandJSON(cfg.GRPC.Message, values)
+	}
+	if cfg.WebSocket != nil {
+		for i, message := range cfg.WebSocket.Messages {
+			cfg.WebSocket.Messages[i] = secrets.ExpandJSON(message, values)
+		}
+	}
+	if cfg.Network != nil {
+		cfg.Network.Proxy = secrets.Expand(cfg.Network.Proxy, values)
+	}
+	return nil
+}
+
+func expandHeaders(headers map[string]string, values map[string]string) {
+	for name, value := range headers {
+		headers[name] = secrets.Expand(value, values)
+	}
+}
+
+// loadTLSSecrets reads the client certificate and CA bundle the TLS
+// settings reference from dir.
+func loadTLSSecrets(cfg *Config, dir string) error {
+	if cfg.TLS == nil {
+		return nil
+	}
+
+	if cfg.TLS.ClientCertSecret != "" {
+		cert, err := readSecret(dir, cfg.TLS.ClientCertSecret)
+		if err != nil {
+			return err
+		}
+		key, err := readSecret(dir, cfg.TLS.ClientKeySecret)
+		if err != nil {
+			return err
+		}
+		pair, err := tls.X509KeyPair(cert, key)
+		if err != nil {
+			return fmt.Errorf("invalid client certificate: %v", err)
+		}
+		cfg.ClientCert = &pair
+	}
+
+	if cfg.TLS.CASecret != "" {
+		bundle, err := readSecret(dir, cfg.TLS.CASecret)
+		if err != nil {
+			return err
+		}
+		pool, err := x509.SystemCertPool()
+		if err != nil {
+			pool = x509.NewCertPool()
+		}
+		if !pool.AppendCertsFromPEM(bundle) {
+			return fmt.Errorf("secret %q holds no PEM certificates", cfg.TLS.CASecret)
+		}
+		cfg.RootCAs = pool
+	}
+	return nil
+}
+
+func readSecret(dir, name string) ([]byte, error) {
+	value, err := os.ReadFile(filepath.Join(dir, name))
+	if err != nil {
+		return nil, fmt.Errorf("secret %q is not mounted: %v", name, err)
+	}
+	return value, nil
+}
@@ -0,0 +1,29 @@
+package worker
+
+import (
+	"fmt"
+	"net"
+	"net/url"
+	"time"
+)
+
+// socketDialTimeout bounds connecting to targets, both raw sockets and those
+// of the HTTP transport.
+const socketDialTimeout = 10 * time.Second
+
+// targetAddress returns the host:port of a tcp://, udp:// or dns:// target
+// URL, using defaultPort when the URL has none.
+func targetAddress(targetURL, defaultPort string) (string, error) {
+	u, err := url.Parse(targetURL)
+	if err != nil {
+		return "", fmt.Errorf("invalid TARGET_URL: %v", err)
+	}
+	port := u.Port()
+	if port == "" {
+		port = defaultPort
+	}
+	if u.Hostname() == "" || port == "" {
+		return "", fmt.Errorf("TARGET_URL must include a host and port")
+	}
+	return net.JoinHostPort(u.Hostname(), port), nil
+}
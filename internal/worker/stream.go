@@ -0,0 +1,268 @@
+package worker
+
+import (
+	"bufio"
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptrace"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	defaultReconnectDelay = 3 * time.Second
+	defaultPollTimeout    = 60 * time.Second
+
+	// maxEventLine bounds one line of an event stream.
+	maxEventLine = 1 << 20
+)
+
+// streamDriver holds Server-Sent Events streams, or long polls for the same
+// kind of feed, with one subscription per VU. Connection attempts are
+// recorded as requests keyed by status code; events count as received
+// messages with their time to first event and the gaps between them.
+type streamDriver struct {
+	cfg            *Config
+	client         *http.Client
+	recorder       *Recorder
+	longPoll       bool
+	reconnectDelay time.Duration
+}
+
+// subscription is the state an EventSource keeps across reconnects.
+type subscription struct {
+	lastEventID string
+	retry       time.Duration
+}
+
+func newStreamDriver(cfg *Config, recorder *Recorder, longPoll bool) *streamDriver {
+	d := &streamDriver{
+		cfg: cfg,
+		client: &http.Client{
+			Transport: newTransport(cfg, vuCount(cfg.VUs)),
+			// like the http driver, do not follow redirects
+			CheckRedirect: func(*http.Request, []*http.Request) error {
+				return http.ErrUseLastResponse
+			},
+		},
+		recorder:       recorder,
+		longPoll:       longPoll,
+		reconnectDelay: defaultReconnectDelay,
+	}
+	if longPoll {
+		d.client.Timeout = defaultPollTimeout
+	}
+	if cfg.Stream != nil {
+		if cfg.Stream.ReconnectDelayMS > 0 {
+			d.reconnectDelay = time.Duration(cfg.Stream.ReconnectDelayMS) * time.Millisecond
+		}
+		if longPoll && cfg.Stream.PollTimeout > 0 {
+			d.client.Timeout = time.Duration(cfg.Stream.PollTimeout) * time.Second
+		}
+	}
+	return d
+}
+
+func (d *streamDriver) Subscribe(ctx context.Context) {
+	sub := &subscription{retry: d.reconnectDelay}
+	for {
+		var reason string
+		if d.longPoll {
+			reason = d.poll(ctx)
+		} else {
+			reason = d.stream(ctx, sub)
+		}
+		if ctx.Err() != nil {
+			return
+		}
+		d.recorder.RecordDisconnect(reason)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(sub.retry):
+		}
+		d.recorder.Reconnected()
+	}
+}
+
+// stream holds one event stream until it ends and returns why it did.
+func (d *streamDriver) stream(ctx context.Context, sub *subscription) string {
+	req, err := d.newRequest(ctx)
+	if err != nil {
+		d.recorder.RecordError(ErrOther, err.Error(), 0, 0)
+		return ErrOther
+	}
+	req.Header.Set("Accept", "text/event-stream")
+	req.Header.Set("Cache-Control", "no-cache")
+	if sub.lastEventID != "" {
+		req.Header.Set("Last-Event-ID", sub.lastEventID)
+	}
+
+	resp, reason := d.connect(req, func(resp *http.Response) bool {
+		return strings.HasPrefix(resp.Header.Get("Content-Type"), "text/event-stream")
+	})
+	if resp == nil {
+		return reason
+	}
+	defer resp.Body.Close()
+
+	d.recorder.StreamOpened()
+	defer d.recorder.StreamClosed()
+
+	err = d.readEvents(resp.Body, sub, time.Now())
+	switch {
+	case ctx.Err() != nil:
+		return ""
+	case err == nil:
+		return "eof"
+	default:
+		return classifyError(err, requestPhase{connected: true})
+	}
+}
+
+// readEvents dispatches the events of a stream until it ends, following the
+// EventSource rules: an event is complete at a blank line if it carried
+// data, id and retry fields update the subscription, comments are ignored.
+func (d *streamDriver) readEvents(body io.Reader, sub *subscription, opened time.Time) error {
+	scanner := bufio.NewScanner(body)
+	scanner.Buffer(make([]byte, 4096), maxEventLine)
+
+	last := opened
+	first := true
+	hasData := false
+	for scanner.Scan() {
+		line := strings.TrimSuffix(scanner.Text(), "\r")
+		if line == "" {
+			if hasData {
+				now := time.Now()
+				d.recorder.RecordEvent(first, now.Sub(last))
+				first, last, hasData = false, now, false
+			}
+			continue
+		}
+
+		field, value, _ := strings.Cut(line, ":")
+		value = strings.TrimPrefix(value, " ")
+		switch field {
+		case "data":
+			hasData = true
+		case "id":
+			sub.lastEventID = value
+		case "retry":
+			if ms, err := strconv.Atoi(value); err == nil && ms >= 0 {
+				sub.retry = time.Duration(ms) * time.Millisecond
+			}
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return err
+	}
+	return nil
+}
+
+// poll long polls until a request fails and returns why it did. Every
+// response with a body is an event; empty ones are polls the server let
+// time out.
+func (d *streamDriver) poll(ctx context.Context) string {
+	last := time.Now()
+	first, opened := true, false
+	for {
+		req, err := d.newRequest(ctx)
+		if err != nil {
+			d.recorder.RecordError(ErrOther, err.Error(), 0, 0)
+			return ErrOther
+		}
+		resp, reason := d.connect(req, nil)
+		if resp == nil {
+			return reason
+		}
+
+		n, err := io.Copy(io.Discard, io.LimitReader(resp.Body, d.cfg.MaxResponseBytes+1))
+		resp.Body.Close()
+		if err == nil && n > d.cfg.MaxResponseBytes {
+			err = errBodyTooLarge
+		}
+		if err != nil {
+			if ctx.Err() != nil {
+				return ""
+			}
+			return classifyError(err, requestPhase{connected: true})
+		}
+
+		if !opened {
+			d.recorder.StreamOpened()
+			defer d.recorder.StreamClosed()
+			opened = true
+		}
+		if n > 0 {
+			now := time.Now()
+			d.recorder.RecordEvent(first, now.Sub(last))
+			first, last = false, now
+		}
+	}
+}
+
+func (d *streamDriver) newRequest(ctx context.Context) (*http.Request, error) {
+	req, err := http.NewRequestWithContext(ctx, d.cfg.HTTPMethod, d.cfg.TargetURL, nil)
+	if err != nil {
+		return nil, err
+	}
+	for name, value := range d.cfg.Headers {
+		req.Header.Set(name, value)
+	}
+	return req, nil
+}
+
+// connect sends the request and records the attempt. It returns the
+// response if it is a 2xx accepted by valid, otherwise the reason the
+// subscription ended.
+func (d *streamDriver) connect(req *http.Request, valid func(*http.Response) bool) (*http.Response, string) {
+	trace := &requestTrace{}
+	req = req.WithContext(httptrace.WithClientTrace(req.Context(), trace.clientTrace()))
+
+	start := time.Now()
+	resp, err := d.client.Do(req)
+	done := time.Now()
+	if err != nil {
+		if req.Context().Err() != nil {
+			return nil, ""
+		}
+		category := classifyError(err, trace.requestPhase())
+		d.recorder.RecordError(category, err.Error(), done.Sub(start), done.Sub(start))
+		return nil, category
+	}
+
+	timings := trace.timings(done)
+	res := result{
+		code:        strconv.Itoa(resp.StatusCode),
+		ok:          resp.StatusCode >= 200 && resp.StatusCode < 300,
+		proto:       resp.Proto,
+		multiplexed: isMultiplexed(resp.ProtoMajor),
+		uncorrected: done.Sub(start),
+		corrected:   done.Sub(start),
+		timings:     &timings,
+	}
+	reason := "status_" + res.code
+	if res.ok && valid != nil && !valid(resp) {
+		res.ok = false
+		res.message = "unexpected Content-Type " + strconv.Quote(resp.Header.Get("Content-Type"))
+		reason = "unexpected_content_type"
+	}
+	d.recorder.Record(res)
+	if !res.ok {
+		resp.Body.Close()
+		return nil, reason
+	}
+	if !d.longPoll {
+		d.recorder.RecordConnect(done.Sub(start))
+	}
+	return resp, ""
+}
+
+func (d *streamDriver) Close() error {
+	d.client.CloseIdleConnections()
+	return nil
+}
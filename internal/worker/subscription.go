@@ -0,0 +1,159 @@
+package worker
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// SubscriptionExecutor keeps one subscription open per VU for the whole test
+// instead of scheduling iterations. The rate caps how many subscriptions
+// open per second, so a large VU count ramps up rather than connecting all
+// at once. Pausing closes every subscription until the test resumes.
+type SubscriptionExecutor struct {
+	cfg        *Config
+	subscriber Subscriber
+	recorder   *Recorder
+
+	mu      sync.Mutex
+	rate    int
+	paused  bool
+	vus     int
+	changed chan struct{}
+
+	running []context.CancelFunc
+	wg      sync.WaitGroup
+}
+
+func NewSubscriptionExecutor(cfg *Config, subscriber Subscriber, recorder *Recorder) *SubscriptionExecutor {
+	return &SubscriptionExecutor{
+		cfg:        cfg,
+		subscriber: subscriber,
+		recorder:   recorder,
+		rate:       cfg.RequestsPerSec,
+		vus:        vuCount(cfg.VUs),
+		changed:    make(chan struct{}, 1),
+	}
+}
+
+// SetRate changes how many subscriptions may open per second.
+func (e *SubscriptionExecutor) SetRate(rate int) {
+	e.mu.Lock()
+	e.rate = rate
+	e.mu.Unlock()
+	e.recorder.SetTargetRPS(rate)
+	e.notify()
+}
+
+func (e *SubscriptionExecutor) SetPaused(paused bool) {
+	e.mu.Lock()
+	e.paused = paused
+	e.mu.Unlock()
+	e.notify()
+}
+
+// SetVUs changes the number of subscriptions to hold.
+func (e *SubscriptionExecutor) SetVUs(vus int) {
+	e.mu.Lock()
+	e.vus = vus
+	e.mu.Unlock()
+	e.notify()
+}
+
+// Run opens and closes subscriptions to match the VU count until end, which
+// is pushed back by the length of every pause. It returns after all
+// subscriptions have closed.
+func (e *SubscriptionExecutor) Run(ctx context.Context, end time.Time) {
+	runCtx, cancel := context.WithCancel(ctx)
+	defer func() {
+		cancel()
+		e.wg.Wait()
+	}()
+	e.recorder.SetTargetRPS(e.state().rate)
+
+	for {
+		state := e.state()
+
+		if state.paused {
+			e.resize(0)
+			pauseStart := time.Now()
+			select {
+			case <-ctx.Done():
+				return
+			case <-e.changed:
+			}
+			pauseLength := time.Since(pauseStart)
+			e.recorder.AddPaused(pauseLength)
+			end = end.Add(pauseLength)
+			continue
+		}
+
+		wait := time.Until(end)
+		if wait <= 0 {
+			return
+		}
+		// below the VU count wait for the next opening slot, otherwise for a
+		// control change; without a rate everything opens at once
+		if e.resize(state.vus) < state.vus {
+			e.open(runCtx)
+			wait = 0
+			if state.rate > 0 {
+				wait = min(time.Until(end), time.Second/time.Duration(state.rate))
+			}
+		}
+
+		timer := time.NewTimer(wait)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return
+		case <-e.changed:
+			timer.Stop()
+		case <-timer.C:
+		}
+	}
+}
+
+// resize closes the newest subscriptions beyond n and returns how many are
+// left open.
+func (e *SubscriptionExecutor) resize(n int) int {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	for len(e.running) > n {
+		last := len(e.running) - 1
+		e.running[last]()
+		e.running = e.running[:last]
+	}
+	return len(e.running)
+}
+
+func (e *SubscriptionExecutor) open(ctx context.Context) {
+	subCtx, stop := context.WithCancel(ctx)
+	e.mu.Lock()
+	e.running = append(e.running, stop)
+	e.mu.Unlock()
+
+	e.wg.Add(1)
+	go func() {
+		defer e.wg.Done()
+		e.subscriber.Subscribe(subCtx)
+	}()
+}
+
+type subscriptionState struct {
+	rate, vus int
+	paused    bool
+}
+
+func (e *SubscriptionExecutor) state() subscriptionState {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	return subscriptionState{rate: e.rate, vus: e.vus, paused: e.paused}
+}
+
+func (e *SubscriptionExecutor) notify() {
+	select {
+	case e.changed <- struct{}{}:
+	default:
+	}
+}
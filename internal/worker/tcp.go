@@ -0,0 +1,181 @@
+package worker
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"net"
+	"time"
+)
+
+// tcpDriver writes a fixed payload to a TCP target and optionally reads a
+// response delimited by a byte sequence or of a fixed size. Connections are
+// kept open between iterations unless the config asks for a fresh one.
+type tcpDriver struct {
+	cfg           *Config
+	recorder      *Recorder
+	addr          string
+	dial          dialFunc
+	payload       []byte
+	delimiter     []byte
+	responseBytes int
+	reuse         bool
+
+	idle chan *tcpConn
+}
+
+// tcpConn buffers reads so a delimiter can be searched for without losing
+// bytes that follow it.
+type tcpConn struct {
+	net.Conn
+	reader *bufio.Reader
+}
+
+func newTCPDriver(cfg *Config, recorder *Recorder) (*tcpDriver, error) {
+	if cfg.Socket == nil {
+		return nil, fmt.Errorf("missing SOCKET_CONFIG for tcp target")
+	}
+	addr, err := targetAddress(cfg.TargetURL, "")
+	if err != nil {
+		return nil, err
+	}
+	payload, delimiter, err := cfg.Socket.Decode()
+	if err != nil {
+		return nil, err
+	}
+
+	return &tcpDriver{
+		cfg:           cfg,
+		recorder:      recorder,
+		addr:          addr,
+		dial:          newDialer(cfg),
+		payload:       payload,
+		delimiter:     delimiter,
+		responseBytes: cfg.Socket.ResponseBytes,
+		reuse:         !cfg.Socket.NewConnection,
+		idle:          make(chan *tcpConn, vuCount(cfg.VUs)),
+	}, nil
+}
+
+func (d *tcpDriver) Iterate(_ context.Context, _ *VU, intended time.Time) {
+	start := time.Now()
+	timings := &phaseTimings{}
+	phase := requestPhase{}
+
+	conn := d.idleConn()
+	if conn != nil {
+		timings.reused = true
+		phase.connected = true
+	} else {
+		c, err := d.dial(context.Background(), "tcp", d.addr)
+		if err != nil {
+			d.recorder.RecordError(classifyError(err, phase), err.Error(), time.Since(start), time.Since(intended))
+			return
+		}
+		timings.connect = time.Since(start)
+		phase.connected = true
+		conn = &tcpConn{Conn: c, reader: bufio.NewReader(c)}
+	}
+	timings.remoteIP = remoteIP(conn.RemoteAddr())
+
+	conn.SetDeadline(time.Now().Add(d.cfg.RequestTimeout))
+	err := d.roundTrip(conn, timings)
+	done := time.Now()
+	if err != nil {
+		conn.Close()
+		d.recorder.RecordError(classifyError(err, phase), err.Error(), done.Sub(start), done.Sub(intended))
+		return
+	}
+
+	d.release(conn)
+	d.recorder.Record(result{
+		ok:          true,
+		uncorrected: done.Sub(start),
+		corrected:   done.Sub(intended),
+		timings:     timings,
+	})
+}
+
+// roundTrip writes the payload and reads the expected response, timing the
+// first response byte from the end of the write.
+func (d *tcpDriver) roundTrip(conn *tcpConn, timings *phaseTimings) error {
+	if _, err := conn.Write(d.payload); err != nil {
+		return err
+	}
+	if len(d.delimiter) == 0 && d.responseBytes == 0 {
+		return nil
+	}
+
+	wrote := time.Now()
+	if _, err := conn.reader.Peek(1); err != nil {
+		return err
+	}
+	firstByte := time.Now()
+	timings.ttfb = firstByte.Sub(wrote)
+
+	var err error
+	if d.responseBytes > 0 {
+		_, err = io.CopyN(io.Discard, conn.reader, int64(d.responseBytes))
+	} else {
+		err = d.readDelimited(conn.reader)
+	}
+	timings.transfer = time.Since(firstByte)
+	return err
+}
+
+// readDelimited consumes the response up to and including the delimiter,
+// failing once it grows past MaxResponseBytes.
+func (d *tcpDriver) readDelimited(reader *bufio.Reader) error {
+	last := d.delimiter[len(d.delimiter)-1]
+	var response []byte
+	for {
+		chunk, err := reader.ReadSlice(last)
+		response = append(response, chunk...)
+		if d.cfg.MaxResponseBytes > 0 && int64(len(response)) > d.cfg.MaxResponseBytes {
+			return errBodyTooLarge
+		}
+		switch {
+		case err == bufio.ErrBufferFull:
+			continue
+		case err != nil:
+			return err
+		case bytes.HasSuffix(response, d.delimiter):
+			return nil
+		}
+	}
+}
+
+func (d *tcpDriver) idleConn() *tcpConn {
+	select {
+	case conn := <-d.idle:
+		return conn
+	default:
+		return nil
+	}
+}
+
+// release keeps a healthy connection for the next iteration.
+func (d *tcpDriver) release(conn *tcpConn) {
+	if !d.reuse {
+		conn.Close()
+		return
+	}
+	select {
+	case d.idle <- conn:
+	default:
+		conn.Close()
+	}
+}
+
+func (d *tcpDriver) Close() error {
+	for {
+		select {
+		case conn := <-d.idle:
+			conn.Close()
+		default:
+			return nil
+		}
+	}
+}
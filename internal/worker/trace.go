@@ -0,0 +1,110 @@
+package worker
+
+import (
+	"crypto/tls"
+	"net/http/httptrace"
+	"sync"
+	"time"
+)
+
+// requestTrace collects the phase timestamps of one request through
+// httptrace. Callbacks can fire on dialer goroutines, hence the lock.
+type requestTrace struct {
+	mu    sync.Mutex
+	phase requestPhase
+
+	dnsStart, connectStart, tlsStart time.Time
+	dns, connect, tlsHandshake       time.Duration
+	wroteRequest, firstByte          time.Time
+	reused                           bool
+	remoteIP                         string
+}
+
+// phaseTimings are the durations of the phases a request went through; the
+// connection setup phases are zero when they did not happen.
+type phaseTimings struct {
+	dns, connect, tls time.Duration
+	ttfb, transfer    time.Duration
+	reused            bool
+	remoteIP          string // address the request was sent to
+}
+
+func (t *requestTrace) clientTrace() *httptrace.ClientTrace {
+	return &httptrace.ClientTrace{
+		DNSStart: func(httptrace.DNSStartInfo) {
+			t.mu.Lock()
+			defer t.mu.Unlock()
+			t.dnsStart = time.Now()
+		},
+		DNSDone: func(httptrace.DNSDoneInfo) {
+			t.mu.Lock()
+			defer t.mu.Unlock()
+			t.dns = time.Since(t.dnsStart)
+		},
+		ConnectStart: func(string, string) {
+			t.mu.Lock()
+			defer t.mu.Unlock()
+			t.connectStart = time.Now()
+		},
+		ConnectDone: func(_, _ string, err error) {
+			t.mu.Lock()
+			defer t.mu.Unlock()
+			if err == nil {
+				t.connect = time.Since(t.connectStart)
+			}
+		},
+		TLSHandshakeStart: func() {
+			t.mu.Lock()
+			defer t.mu.Unlock()
+			t.phase.tlsStarted = true
+			t.tlsStart = time.Now()
+		},
+		TLSHandshakeDone: func(_ tls.ConnectionState, err error) {
+			t.mu.Lock()
+			defer t.mu.Unlock()
+			t.phase.tlsCompleted = err == nil
+			t.tlsHandshake = time.Since(t.tlsStart)
+		},
+		GotConn: func(info httptrace.GotConnInfo) {
+			t.mu.Lock()
+			defer t.mu.Unlock()
+			t.phase.connected = true
+			t.reused = info.Reused
+			t.remoteIP = remoteIP(info.Conn.RemoteAddr())
+		},
+		WroteRequest: func(httptrace.WroteRequestInfo) {
+			t.mu.Lock()
+			defer t.mu.Unlock()
+			t.wroteRequest = time.Now()
+		},
+		GotFirstResponseByte: func() {
+			t.mu.Lock()
+			defer t.mu.Unlock()
+			t.firstByte = time.Now()
+		},
+	}
+}
+
+func (t *requestTrace) requestPhase() requestPhase {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.phase
+}
+
+// timings returns the phase durations of a request that finished at done.
+func (t *requestTrace) timings(done time.Time) phaseTimings {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	timings := phaseTimings{reused: t.reused, remoteIP: t.remoteIP}
+	if !t.reused {
+		timings.dns, timings.connect, timings.tls = t.dns, t.connect, t.tlsHandshake
+	}
+	if !t.firstByte.IsZero() {
+		if !t.wroteRequest.IsZero() {
+			timings.ttfb = t.firstByte.Sub(t.wroteRequest)
+		}
+		timings.transfer = done.Sub(t.firstByte)
+	}
+	return timings
+}
@@ -0,0 +1,76 @@
+package worker
+
+import (
+	"crypto/tls"
+	"net/http"
+	"time"
+
+	"github.com/quic-go/quic-go/http3"
+)
+
+// Protocols a test can select with the protocol option.
+const (
+	ProtocolHTTP1 = "http1.1"
+	ProtocolH2    = "h2"
+	ProtocolH2C   = "h2c"
+	ProtocolH3    = "h3"
+)
+
+// newTransport returns a round tripper that speaks only the configured
+// protocol, so a target that does not support it fails instead of silently
+// falling back.
+func newTransport(cfg *Config, vus int) http.RoundTripper {
+	if cfg.Protocol == ProtocolH3 {
+		tlsConfig := newTLSConfig(cfg)
+		tlsConfig.NextProtos = []string{http3.NextProtoH3}
+		return &http3.Transport{TLSClientConfig: tlsConfig, Dial: newQUICDialer(cfg)}
+	}
+
+	transport := &http.Transport{
+		Proxy:               newProxy(cfg),
+		DialContext:         newDialer(cfg),
+		TLSClientConfig:     newTLSConfig(cfg),
+		TLSHandshakeTimeout: 10 * time.Second,
+		MaxIdleConns:        vus,
+		MaxIdleConnsPerHost: vus,
+		IdleConnTimeout:     90 * time.Second,
+		Protocols:           new(http.Protocols),
+	}
+	switch cfg.Protocol {
+	case ProtocolH2:
+		transport.Protocols.SetHTTP2(true)
+	case ProtocolH2C:
+		transport.Protocols.SetUnencryptedHTTP2(true)
+	default:
+		transport.Protocols.SetHTTP1(true)
+	}
+	return transport
+}
+
+// tlsVersions maps the min_version option to crypto/tls versions.
+var tlsVersions = map[string]uint16{
+	"1.0": tls.VersionTLS10,
+	"1.1": tls.VersionTLS11,
+	"1.2": tls.VersionTLS12,
+	"1.3": tls.VersionTLS13,
+}
+
+// newTLSConfig returns the client TLS settings of the test.
+func newTLSConfig(cfg *Config) *tls.Config {
+	tlsConfig := &tls.Config{RootCAs: cfg.RootCAs}
+	if cfg.TLS != nil {
+		tlsConfig.InsecureSkipVerify = cfg.TLS.InsecureSkipVerify
+		tlsConfig.ServerName = cfg.TLS.ServerName
+		tlsConfig.MinVersion = tlsVersions[cfg.TLS.MinVersion]
+	}
+	if cfg.ClientCert != nil {
+		tlsConfig.Certificates = []tls.Certificate{*cfg.ClientCert}
+	}
+	return tlsConfig
+}
+
+// isMultiplexed reports whether responses with the given protocol version
+// share their connection as streams.
+func isMultiplexed(protoMajor int) bool {
+	return protoMajor >= 2
+}
@@ -0,0 +1,81 @@
+package worker
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+// maxDatagramSize fits any UDP payload.
+const maxDatagramSize = 64 << 10
+
+// udpDriver sends the payload as one datagram from a fresh socket per
+// iteration and, if asked to, waits for a datagram back until the request
+// timeout.
+type udpDriver struct {
+	cfg           *Config
+	recorder      *Recorder
+	addr          string
+	dial          dialFunc
+	payload       []byte
+	awaitResponse bool
+}
+
+func newUDPDriver(cfg *Config, recorder *Recorder) (*udpDriver, error) {
+	if cfg.Socket == nil {
+		return nil, fmt.Errorf("missing SOCKET_CONFIG for udp target")
+	}
+	addr, err := targetAddress(cfg.TargetURL, "")
+	if err != nil {
+		return nil, err
+	}
+	payload, _, err := cfg.Socket.Decode()
+	if err != nil {
+		return nil, err
+	}
+
+	return &udpDriver{
+		cfg:           cfg,
+		recorder:      recorder,
+		addr:          addr,
+		dial:          newDialer(cfg),
+		payload:       payload,
+		awaitResponse: cfg.Socket.AwaitResponse,
+	}, nil
+}
+
+func (d *udpDriver) Iterate(_ context.Context, _ *VU, intended time.Time) {
+	start := time.Now()
+	err := d.exchange()
+	done := time.Now()
+	if err != nil {
+		// there is no handshake, a timeout can only be a missing response
+		d.recorder.RecordError(classifyError(err, requestPhase{connected: true}), err.Error(),
+			done.Sub(start), done.Sub(intended))
+		return
+	}
+	d.recorder.Record(result{ok: true, uncorrected: done.Sub(start), corrected: done.Sub(intended)})
+}
+
+func (d *udpDriver) exchange() error {
+	conn, err := d.dial(context.Background(), "udp", d.addr)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	conn.SetDeadline(time.Now().Add(d.cfg.RequestTimeout))
+	if _, err := conn.Write(d.payload); err != nil {
+		return err
+	}
+	if !d.awaitResponse {
+		return nil
+	}
+	buf := make([]byte, maxDatagramSize)
+	_, err = conn.Read(buf)
+	return err
+}
+
+func (d *udpDriver) Close() error {
+	return nil
+}
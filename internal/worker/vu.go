@@ -0,0 +1,250 @@
+package worker
+
+import (
+	"context"
+	"math/rand/v2"
+	"sync"
+	"time"
+
+	"github.com/Vinayak9769/loadagg/pkg/models"
+)
+
+// VUExecutor runs a closed model: every VU starts its next iteration once the
+// previous one, the think time after it and the pacing interval are over, so
+// a slower target lowers the offered load the way it would with real users.
+// The worker's share of requests_per_sec caps how fast iterations start
+// across all of its VUs.
+type VUExecutor struct {
+	cfg      *Config
+	driver   Driver
+	recorder *Recorder
+
+	mu     sync.Mutex
+	rate   int
+	paused bool
+	next   time.Time // earliest start of the next iteration under the rate cap
+	vus    int
+	// changed is closed and replaced on every control change, waking all
+	// VUs waiting on it
+	changed chan struct{}
+
+	quit    chan struct{}
+	vuCtx   context.Context
+	stopVUs context.CancelFunc
+	wg      sync.WaitGroup
+}
+
+func NewVUExecutor(cfg *Config, driver Driver, recorder *Recorder) *VUExecutor {
+	vuCtx, stopVUs := context.WithCancel(context.Background())
+	return &VUExecutor{
+		cfg:      cfg,
+		driver:   driver,
+		recorder: recorder,
+		rate:     cfg.RequestsPerSec,
+		changed:  make(chan struct{}),
+		quit:     make(chan struct{}),
+		vuCtx:    vuCtx,
+		stopVUs:  stopVUs,
+	}
+}
+
+// SetRate changes the cap on iteration starts.
+func (e *VUExecutor) SetRate(rate int) {
+	e.mu.Lock()
+	e.rate = rate
+	e.next = time.Time{}
+	e.notifyLocked()
+	e.mu.Unlock()
+	e.recorder.SetTargetRPS(rate)
+}
+
+func (e *VUExecutor) SetPaused(paused bool) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.paused = paused
+	e.next = time.Time{}
+	e.notifyLocked()
+}
+
+// SetVUs grows or shrinks the VU pool. A VU that is told to stop finishes its
+// current iteration first.
+func (e *VUExecutor) SetVUs(vus int) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	for ; e.vus < vus; e.vus++ {
+		e.wg.Add(1)
+		go e.runVU(e.vuCtx, &VU{})
+	}
+	if e.vus > vus {
+		stop := e.vus - vus
+		e.vus = vus
+		go func() {
+			for i := 0; i < stop; i++ {
+				select {
+				case e.quit <- struct{}{}:
+				case <-e.vuCtx.Done():
+					return
+				}
+			}
+		}()
+	}
+}
+
+// Run keeps the VUs going until end, which is pushed back by the length of
+// every pause. It returns after in-flight iterations have finished.
+func (e *VUExecutor) Run(ctx context.Context, end time.Time) {
+	defer func() {
+		e.stopVUs()
+		e.wg.Wait()
+	}()
+	e.recorder.SetTargetRPS(e.cfg.RequestsPerSec)
+	e.SetVUs(vuCount(e.cfg.VUs))
+
+	for {
+		paused, changed := e.state()
+
+		if paused {
+			pauseStart := time.Now()
+			for paused {
+				select {
+				case <-ctx.Done():
+					return
+				case <-changed:
+				}
+				paused, changed = e.state()
+			}
+			pauseLength := time.Since(pauseStart)
+			e.recorder.AddPaused(pauseLength)
+			end = end.Add(pauseLength)
+			continue
+		}
+
+		timer := time.NewTimer(time.Until(end))
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return
+		case <-timer.C:
+			return
+		case <-changed:
+			timer.Stop()
+		}
+	}
+}
+
+func (e *VUExecutor) runVU(ctx context.Context, vu *VU) {
+	defer e.wg.Done()
+	for {
+		if !e.acquire(ctx) {
+			return
+		}
+		start := time.Now()
+		e.recorder.Iteration()
+		e.driver.Iterate(ctx, vu, start)
+
+		wait := sampleThinkTime(e.cfg.ThinkTime)
+		if paced := time.Until(start.Add(e.cfg.Pacing)); paced > wait {
+			wait = paced
+		}
+		if wait > 0 && !e.sleep(ctx, wait) {
+			return
+		}
+	}
+}
+
+// acquire blocks until the VU may start an iteration: the test is not paused
+// and the rate cap has a free slot. It returns false if the VU should stop.
+func (e *VUExecutor) acquire(ctx context.Context) bool {
+	for {
+		e.mu.Lock()
+		changed := e.changed
+		if e.paused || e.rate <= 0 {
+			// paused, or no share of the rate, e.g. more workers than
+			// requests per second
+			e.mu.Unlock()
+			select {
+			case <-ctx.Done():
+				return false
+			case <-e.quit:
+				return false
+			case <-changed:
+			}
+			continue
+		}
+		slot := time.Now()
+		if e.next.After(slot) {
+			slot = e.next
+		}
+		e.next = slot.Add(time.Second / time.Duration(e.rate))
+		e.mu.Unlock()
+
+		wait := time.Until(slot)
+		if wait <= 0 {
+			return true
+		}
+		timer := time.NewTimer(wait)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return false
+		case <-e.quit:
+			timer.Stop()
+			return false
+		case <-changed:
+			// the slot was reserved under the old settings
+			timer.Stop()
+		case <-timer.C:
+			return true
+		}
+	}
+}
+
+// sleep waits between iterations, returning false if the VU should stop.
+func (e *VUExecutor) sleep(ctx context.Context, d time.Duration) bool {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return false
+	case <-e.quit:
+		return false
+	case <-timer.C:
+		return true
+	}
+}
+
+func (e *VUExecutor) state() (bool, chan struct{}) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	return e.paused, e.changed
+}
+
+func (e *VUExecutor) notifyLocked() {
+	close(e.changed)
+	e.changed = make(chan struct{})
+}
+
+// sampleThinkTime draws a pause from t, kept within its min_ms and max_ms
+// where set. A nil t means no pause.
+func sampleThinkTime(t *models.ThinkTime) time.Duration {
+	if t == nil {
+		return 0
+	}
+	var ms float64
+	switch t.Distribution {
+	case models.ThinkUniform:
+		ms = float64(t.MinMS) + rand.Float64()*float64(t.MaxMS-t.MinMS)
+	case models.ThinkNormal:
+		ms = float64(t.MS) + rand.NormFloat64()*float64(t.StdDevMS)
+	case models.ThinkExponential:
+		ms = rand.ExpFloat64() * float64(t.MS)
+	default:
+		ms = float64(t.MS)
+	}
+	ms = max(ms, float64(t.MinMS), 0)
+	if t.MaxMS > 0 {
+		ms = min(ms, float64(t.MaxMS))
+	}
+	return time.Duration(ms * float64(time.Millisecond))
+}
@@ -0,0 +1,279 @@
+package worker
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/gorilla/websocket"
+)
+
+const defaultCorrelationField = "id"
+
+var errDisconnected = errors.New("connection closed before the response arrived")
+
+// websocketDriver keeps one connection per VU and sends the scripted
+// messages round robin across them. Responses are matched to requests by
+// the correlation field, so several messages can be in flight on the same
+// connection.
+type websocketDriver struct {
+	cfg      *Config
+	recorder *Recorder
+	dialer   *websocket.Dialer
+	header   http.Header
+	field    string
+	script   []scriptMessage
+	sessions []*wsSession
+
+	next atomic.Uint64
+	seq  atomic.Uint64
+}
+
+// scriptMessage is a scripted message, decoded if it is a JSON object so the
+// correlation value can be filled in.
+type scriptMessage struct {
+	raw    []byte
+	object map[string]interface{}
+}
+
+// wsSession is the connection of one VU. It is dialed lazily and redialed
+// after a disconnect.
+type wsSession struct {
+	mu      sync.Mutex
+	conn    *websocket.Conn
+	pending map[string]chan time.Time
+
+	// gorilla connections allow one writer at a time
+	writeMu sync.Mutex
+}
+
+func newWebSocketDriver(cfg *Config, recorder *Recorder) (*websocketDriver, error) {
+	if cfg.WebSocket == nil || len(cfg.WebSocket.Messages) == 0 {
+		return nil, fmt.Errorf("missing WEBSOCKET_CONFIG messages for websocket target")
+	}
+
+	d := &websocketDriver{
+		cfg:      cfg,
+		recorder: recorder,
+		dialer: &websocket.Dialer{
+			Proxy:            newProxy(cfg),
+			NetDialContext:   newDialer(cfg),
+			HandshakeTimeout: cfg.RequestTimeout,
+			TLSClientConfig:  newTLSConfig(cfg),
+			Subprotocols:     cfg.WebSocket.Subprotocols,
+		},
+		header: make(http.Header),
+		field:  cfg.WebSocket.CorrelationField,
+	}
+	if d.field == "" {
+		d.field = defaultCorrelationField
+	}
+	for name, value := range cfg.Headers {
+		d.header.Set(name, value)
+	}
+	for _, raw := range cfg.WebSocket.Messages {
+		msg := scriptMessage{raw: raw}
+		json.Unmarshal(raw, &msg.object)
+		d.script = append(d.script, msg)
+	}
+	for i := 0; i < vuCount(cfg.VUs); i++ {
+		d.sessions = append(d.sessions, &wsSession{})
+	}
+	return d, nil
+}
+
+func (d *websocketDriver) Iterate(_ context.Context, _ *VU, intended time.Time) {
+	n := d.next.Add(1) - 1
+	session := d.sessions[n%uint64(len(d.sessions))]
+
+	conn, err := d.connect(session)
+	if err != nil {
+		d.recorder.RecordError(classifyError(err, requestPhase{}), err.Error(), 0, time.Since(intended))
+		return
+	}
+
+	payload, id := d.message(n)
+	var response chan time.Time
+	if id != "" {
+		response = session.await(id)
+	}
+
+	sent := time.Now()
+	if err := session.write(conn, payload); err != nil {
+		session.forget(id)
+		d.disconnect(session, conn, err)
+		d.recorder.RecordError(ErrReset, err.Error(), time.Since(sent), time.Since(intended))
+		return
+	}
+	d.recorder.MessageSent()
+
+	if response == nil {
+		// not correlatable, only the send is measured
+		done := time.Now()
+		d.recorder.Record(result{ok: true, uncorrected: done.Sub(sent), corrected: done.Sub(intended)})
+		return
+	}
+
+	timer := time.NewTimer(d.cfg.RequestTimeout)
+	defer timer.Stop()
+	select {
+	case received, ok := <-response:
+		if !ok {
+			d.recorder.RecordError(ErrReset, errDisconnected.Error(), time.Since(sent), time.Since(intended))
+			return
+		}
+		d.recorder.Record(result{ok: true, uncorrected: received.Sub(sent), corrected: received.Sub(intended)})
+	case <-timer.C:
+		session.forget(id)
+		d.recorder.RecordError(ErrReadTimeout, fmt.Sprintf("no response with %s=%s within %s", d.field, id, d.cfg.RequestTimeout),
+			time.Since(sent), time.Since(intended))
+	}
+}
+
+// message returns the n-th message of the script and its correlation value,
+// which is empty for messages that are not JSON objects.
+func (d *websocketDriver) message(n uint64) ([]byte, string) {
+	msg := d.script[n%uint64(len(d.script))]
+	if msg.object == nil {
+		return msg.raw, ""
+	}
+
+	id := strconv.FormatUint(d.seq.Add(1), 10)
+	object := make(map[string]interface{}, len(msg.object)+1)
+	for k, v := range msg.object {
+		object[k] = v
+	}
+	object[d.field] = id
+	payload, err := json.Marshal(object)
+	if err != nil {
+		return msg.raw, ""
+	}
+	return payload, id
+}
+
+// connect returns the session's connection, dialing it first if needed.
+func (d *websocketDriver) connect(session *wsSession) (*websocket.Conn, error) {
+	session.mu.Lock()
+	defer session.mu.Unlock()
+	if session.conn != nil {
+		return session.conn, nil
+	}
+
+	start := time.Now()
+	conn, resp, err := d.dialer.Dial(d.cfg.TargetURL, d.header)
+	if err != nil {
+		if resp != nil {
+			return nil, fmt.Errorf("websocket handshake failed with status %d", resp.StatusCode)
+		}
+		return nil, err
+	}
+	d.recorder.RecordConnect(time.Since(start))
+
+	session.conn = conn
+	session.pending = make(map[string]chan time.Time)
+	go d.read(session, conn)
+	return conn, nil
+}
+
+// read receives messages until the connection fails, completing the round
+// trips whose correlation value comes back.
+func (d *websocketDriver) read(session *wsSession, conn *websocket.Conn) {
+	for {
+		_, data, err := conn.ReadMessage()
+		if err != nil {
+			d.disconnect(session, conn, err)
+			return
+		}
+		received := time.Now()
+		d.recorder.MessageReceived()
+
+		var object map[string]interface{}
+		if json.Unmarshal(data, &object) != nil {
+			continue
+		}
+		value, ok := object[d.field]
+		if !ok {
+			continue
+		}
+		if ch := session.forget(fmt.Sprint(value)); ch != nil {
+			ch <- received
+		}
+	}
+}
+
+// disconnect drops a failed connection, fails its outstanding round trips
+// and records why it ended.
+func (d *websocketDriver) disconnect(session *wsSession, conn *websocket.Conn, err error) {
+	session.mu.Lock()
+	if session.conn != conn {
+		session.mu.Unlock()
+		return
+	}
+	session.conn = nil
+	for id, ch := range session.pending {
+		close(ch)
+		delete(session.pending, id)
+	}
+	session.mu.Unlock()
+
+	conn.Close()
+	d.recorder.RecordDisconnect(disconnectReason(err))
+}
+
+func (d *websocketDriver) Close() error {
+	deadline := time.Now().Add(time.Second)
+	for _, session := range d.sessions {
+		session.mu.Lock()
+		conn := session.conn
+		session.conn = nil
+		session.mu.Unlock()
+		if conn == nil {
+			continue
+		}
+		session.writeMu.Lock()
+		conn.WriteControl(websocket.CloseMessage,
+			websocket.FormatCloseMessage(websocket.CloseNormalClosure, ""), deadline)
+		session.writeMu.Unlock()
+		conn.Close()
+	}
+	return nil
+}
+
+func (s *wsSession) await(id string) chan time.Time {
+	ch := make(chan time.Time, 1)
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.pending[id] = ch
+	return ch
+}
+
+// forget removes and returns the waiter for id, if any.
+func (s *wsSession) forget(id string) chan time.Time {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	ch := s.pending[id]
+	delete(s.pending, id)
+	return ch
+}
+
+func (s *wsSession) write(conn *websocket.Conn, payload []byte) error {
+	s.writeMu.Lock()
+	defer s.writeMu.Unlock()
+	return conn.WriteMessage(websocket.TextMessage, payload)
+}
+
+// disconnectReason names why a connection ended: the close code sent by the
+// server, or the error category when the connection failed without one.
+func disconnectReason(err error) string {
+	var closeErr *websocket.CloseError
+	if errors.As(err, &closeErr) {
+		return fmt.Sprintf("close_%d", closeErr.Code)
+	}
+	return classifyError(err, requestPhase{connected: true})
+}
@@ -0,0 +1,157 @@
+package worker
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"time"
+)
+
+// Run executes one worker's share of a load test: it waits at the start
+// barrier, drives the arrival-rate executor until the duration has elapsed,
+// follows live control changes and logs metrics for the controller to scrape.
+func Run(ctx context.Context, cfg *Config) error {
+	log.Printf("Starting load test worker")
+	log.Printf("Test ID: %s", cfg.TestID)
+	log.Printf("Target URL: %s", cfg.TargetURL)
+	log.Printf("Duration: %s", cfg.Duration)
+	log.Printf("HTTP Method: %s", cfg.HTTPMethod)
+	log.Printf("Worker index: %d, RPS share: %d, VUs: %d", cfg.WorkerIndex, cfg.RequestsPerSec, vuCount(cfg.VUs))
+
+	recorder := NewRecorder(cfg.TestID, cfg.WorkerIndex)
+	recorder.Redact(cfg.SecretValues)
+	api := NewAPIClient(cfg)
+	executor, driver, err := newExecutor(ctx, cfg, api, recorder)
+	if err != nil {
+		return err
+	}
+	defer driver.Close()
+
+	start := waitForStart(ctx, cfg, api)
+	recorder.Start(start)
+	end := start.Add(cfg.Duration)
+
+	runCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	if api != nil {
+		go pollControl(runCtx, cfg, api, executor)
+	}
+	go func() {
+		ticker := time.NewTicker(cfg.MetricsInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-runCtx.Done():
+				return
+			case <-ticker.C:
+				logMetrics(recorder)
+			}
+		}
+	}()
+
+	executor.Run(runCtx, end)
+	cancel()
+
+	final := recorder.Snapshot()
+	logMetrics(recorder)
+	log.Printf("Load test completed. Made %d requests, dropped %d iterations.", final.TotalRequests, final.DroppedIterations)
+	return nil
+}
+
+// waitForStart registers at the start barrier and sleeps until the common
+// start instant the API broadcasts once every worker is ready or the start
+// timeout expires. Without API access the worker starts immediately.
+func waitForStart(ctx context.Context, cfg *Config, api *APIClient) time.Time {
+	if api == nil {
+		return time.Now()
+	}
+
+	// give up on the API well after its own deadline
+	giveUp := time.Now().Add(cfg.StartTimeout + 30*time.Second)
+	for time.Now().Before(giveUp) {
+		barrier, err := api.RegisterAtBarrier(ctx, cfg.WorkerIndex, cfg.PodName)
+		if err == nil && barrier.StartAtUnix != 0 {
+			startAt := time.Unix(barrier.StartAtUnix, 0)
+			log.Printf("Start barrier released (%d/%d workers), starting at %s", barrier.Registered, barrier.Expected, startAt.UTC().Format(time.RFC3339))
+			select {
+			case <-ctx.Done():
+			case <-time.After(time.Until(startAt)):
+			}
+			return startAt
+		}
+
+		select {
+		case <-ctx.Done():
+			return time.Now()
+		case <-time.After(time.Second):
+		}
+	}
+
+	log.Printf("Start barrier not released in time, starting now")
+	return time.Now()
+}
+
+// pollControl picks up live rate changes, worker scaling and pause/resume
+// requests made through the API.
+func pollControl(ctx context.Context, cfg *Config, api *APIClient, executor Executor) {
+	ticker := time.NewTicker(cfg.ControlPollInterval)
+	defer ticker.Stop()
+
+	version := 0
+	state := "running"
+	rate := cfg.RequestsPerSec
+	vus := vuCount(cfg.VUs)
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		control, err := api.Control(ctx)
+		if err != nil || control.Version <= version {
+			continue
+		}
+		version = control.Version
+
+		if control.State != "" && control.State != state {
+			log.Printf("Control update (version %d): state %s -> %s", version, state, control.State)
+			state = control.State
+			executor.SetPaused(state == "paused")
+		}
+
+		if newRate := indexShare(control.WorkerRates, cfg.WorkerIndex); newRate != rate {
+			log.Printf("Control update (version %d): RPS share %d -> %d", version, rate, newRate)
+			rate = newRate
+			executor.SetRate(rate)
+		}
+
+		if newVUs := vuCount(indexShare(control.WorkerConcurrency, cfg.WorkerIndex)); newVUs != vus {
+			log.Printf("Control update (version %d): VUs %d -> %d", version, vus, newVUs)
+			vus = newVUs
+			executor.SetVUs(vus)
+		}
+	}
+}
+
+// indexShare returns this worker's entry of a per-worker share list, or 0
+// when the worker is beyond the end of the list.
+func indexShare(shares []int, index int) int {
+	if index < 0 || index >= len(shares) {
+		return 0
+	}
+	return shares[index]
+}
+
+func logMetrics(recorder *Recorder) {
+	data, err := json.Marshal(recorder.Snapshot())
+	if err != nil {
+		log.Printf("Error encoding metrics: %v", err)
+		return
+	}
+	// the controller scrapes the last METRICS line from the pod log
+	fmt.Printf("METRICS: %s\n", data)
+}
@@ -12,6 +12,7 @@ import (
 
 	"github.com/Vinayak9769/loadagg/internal/controller"
 	"github.com/Vinayak9769/loadagg/internal/handlers"
+	"github.com/Vinayak9769/loadagg/internal/secrets"
 	"github.com/go-chi/chi/v5"
 	"github.com/joho/godotenv"
 	_ "github.com/lib/pq"
@@ -72,7 +73,7 @@ func main() {
 	router.Use(func(next http.Handler) http.Handler {
 		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 			w.Header().Set("Access-Control-Allow-Origin", "*")
-			w.Header().Set("Access-Control-Allow-Methods", "GET, POST, PUT, DELETE, OPTIONS")
+			w.Header().Set("Access-Control-Allow-Methods", "GET, POST, PUT, PATCH, DELETE, OPTIONS")
 			w.Header().Set("Access-Control-Allow-Headers", "Accept, Authorization, Content-Type, X-CSRF-Token, Cache-Control, Connection")
 			w.Header().Set("Access-Control-Expose-Headers", "Cache-Control, Connection")
 			w.Header().Set("Access-Control-Allow-Credentials", "true")
@@ -102,11 +103,35 @@ func main() {
 	if kubeClient == nil {
 		log.Println("Warning: Kubernetes client not available - load test features disabled")
 	}
-	loadTestController := controller.NewLoadTestController(kubeClient, "loadtest")
-	loadTestHandler := handlers.NewLoadTestHandler(db, loadTestController)
+	workerAPIURL := getEnv("WORKER_API_URL", "http://loadtest-api-service.loadtest.svc.cluster.local")
+	loadTestController := controller.NewLoadTestController(kubeClient, "loadtest", workerAPIURL)
+	// SECRETS_KEY is the master key user secrets are encrypted under
+	sealer, err := secrets.NewSealer(getEnv("SECRETS_KEY", ""))
+	if err != nil {
+		log.Printf("Warning: Secrets disabled: %v", err)
+	}
+	loadTestHandler := handlers.NewLoadTestHandler(db, loadTestController, sealer)
 
 	router.Mount("/api/v1/loadtests", loadTestHandler.Routes())
 
+	userHandler := handlers.NewUserHandler(db)
+	router.Mount("/api/v1/me", userHandler.Routes())
+
+	allowlistHandler := handlers.NewAllowlistHandler(db)
+	router.Mount("/api/v1/allowlist", allowlistHandler.Routes())
+
+	protosetHandler := handlers.NewProtosetHandler(db)
+	router.Mount("/api/v1/protosets", protosetHandler.Routes())
+
+	accessLogHandler := handlers.NewAccessLogHandler(db)
+	router.Mount("/api/v1/accesslogs", accessLogHandler.Routes())
+
+	secretHandler := handlers.NewSecretHandler(db, sealer)
+	router.Mount("/api/v1/secrets", secretHandler.Routes())
+
+	importHandler := handlers.NewImportHandler()
+	router.Mount("/api/v1/imports", importHandler.Routes())
+
 	serv := http.Server{
 		Addr:    ":" + getEnv("PORT", "8080"),
 		Handler: router,
@@ -0,0 +1,27 @@
+package models
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// GRPCConfig describes the call a gRPC load test makes. Method descriptors
+// come from an uploaded descriptor set or, failing that, server reflection.
+type GRPCConfig struct {
+	Service    string            `json:"service"` // fully qualified, e.g. helloworld.Greeter
+	Method     string            `json:"method"`
+	Message    json.RawMessage   `json:"message,omitempty"` // request message in protobuf JSON form
+	Metadata   map[string]string `json:"metadata,omitempty"`
+	ProtosetID string            `json:"protoset_id,omitempty"`
+	Reflection bool              `json:"reflection,omitempty"`
+}
+
+// Protoset is an uploaded FileDescriptorSet, as produced by
+// protoc --include_imports --descriptor_set_out.
+type Protoset struct {
+	ID        string    `json:"id"`
+	UserID    string    `json:"user_id"`
+	Name      string    `json:"name"`
+	Services  []string  `json:"services"`
+	CreatedAt time.Time `json:"created_at"`
+}
@@ -0,0 +1,165 @@
+package models
+
+import (
+	"math"
+	"sort"
+)
+
+// histogramBounds are the upper bounds, in seconds, of the latency buckets
+// shared by every worker so histograms can be merged bucket by bucket.
+// They grow log-linearly from 100µs to 100s; anything slower lands in the
+// final overflow bucket.
+var histogramBounds = func() []float64 {
+	steps := []float64{1, 1.5, 2, 3, 4, 5, 6, 7, 8, 9}
+	var bounds []float64
+	for decade := 1e-4; decade < 100; decade *= 10 {
+		for _, step := range steps {
+			bounds = append(bounds, step*decade)
+		}
+	}
+	return append(bounds, 100)
+}()
+
+// Histogram is a fixed-bucket latency histogram in seconds. Counts has one
+// entry per bound plus a trailing overflow bucket.
+type Histogram struct {
+	Counts []int64 `json:"counts"`
+	Count  int64   `json:"count"`
+	Sum    float64 `json:"sum"`
+	Min    float64 `json:"min"`
+	Max    float64 `json:"max"`
+	P50    float64 `json:"p50"`
+	P90    float64 `json:"p90"`
+	P95    float64 `json:"p95"`
+	P99    float64 `json:"p99"`
+}
+
+func NewHistogram() *Histogram {
+	return &Histogram{Counts: make([]int64, len(histogramBounds)+1)}
+}
+
+// HistogramBounds returns the bucket upper bounds in seconds.
+func HistogramBounds() []float64 {
+	return append([]float64(nil), histogramBounds...)
+}
+
+func (h *Histogram) Observe(seconds float64) {
+	if h.Count == 0 || seconds < h.Min {
+		h.Min = seconds
+	}
+	if seconds > h.Max {
+		h.Max = seconds
+	}
+	h.Count++
+	h.Sum += seconds
+	h.Counts[sort.SearchFloat64s(histogramBounds, seconds)]++
+}
+
+// Merge adds the observations of other into h.
+func (h *Histogram) Merge(other *Histogram) {
+	if other == nil || other.Count == 0 || len(other.Counts) != len(h.Counts) {
+		return
+	}
+	if h.Count == 0 || other.Min < h.Min {
+		h.Min = other.Min
+	}
+	if other.Max > h.Max {
+		h.Max = other.Max
+	}
+	h.Count += other.Count
+	h.Sum += other.Sum
+	for i, c := range other.Counts {
+		h.Counts[i] += c
+	}
+}
+
+// Quantile estimates the q-th quantile by interpolating inside the bucket
+// that contains it.
+func (h *Histogram) Quantile(q float64) float64 {
+	if h.Count == 0 {
+		return 0
+	}
+	rank := q * float64(h.Count)
+	var cumulative float64
+	for i, c := range h.Counts {
+		if c == 0 {
+			continue
+		}
+		if cumulative+float64(c) >= rank {
+			lower := 0.0
+			if i > 0 {
+				lower = histogramBounds[i-1]
+			}
+			upper := h.Max
+			if i < len(histogramBounds) {
+				upper = histogramBounds[i]
+			}
+			value := lower + (upper-lower)*(rank-cumulative)/float64(c)
+			return math.Min(math.Max(value, h.Min), h.Max)
+		}
+		cumulative += float64(c)
+	}
+	return h.Max
+}
+
+// Summarize fills in the percentile fields from the bucket counts.
+func (h *Histogram) Summarize() {
+	h.P50 = h.Quantile(0.50)
+	h.P90 = h.Quantile(0.90)
+	h.P95 = h.Quantile(0.95)
+	h.P99 = h.Quantile(0.99)
+}
+
+func (h *Histogram) Clone() *Histogram {
+	c := *h
+	c.Counts = append([]int64(nil), h.Counts...)
+	return &c
+}
+
+// PhaseHistograms break request latency down into the phases of an HTTP
+// request. DNS, Connect and TLS only contain requests that opened a new
+// connection.
+type PhaseHistograms struct {
+	DNS      *Histogram `json:"dns"`
+	Connect  *Histogram `json:"connect"`
+	TLS      *Histogram `json:"tls"`
+	TTFB     *Histogram `json:"ttfb"` // request written to first response byte
+	Transfer *Histogram `json:"transfer"`
+}
+
+func NewPhaseHistograms() *PhaseHistograms {
+	return &PhaseHistograms{
+		DNS:      NewHistogram(),
+		Connect:  NewHistogram(),
+		TLS:      NewHistogram(),
+		TTFB:     NewHistogram(),
+		Transfer: NewHistogram(),
+	}
+}
+
+func (p *PhaseHistograms) Merge(other *PhaseHistograms) {
+	if other == nil {
+		return
+	}
+	p.DNS.Merge(other.DNS)
+	p.Connect.Merge(other.Connect)
+	p.TLS.Merge(other.TLS)
+	p.TTFB.Merge(other.TTFB)
+	p.Transfer.Merge(other.Transfer)
+}
+
+func (p *PhaseHistograms) Summarize() {
+	for _, h := range []*Histogram{p.DNS, p.Connect, p.TLS, p.TTFB, p.Transfer} {
+		h.Summarize()
+	}
+}
+
+func (p *PhaseHistograms) Clone() *PhaseHistograms {
+	return &PhaseHistograms{
+		DNS:      p.DNS.Clone(),
+		Connect:  p.Connect.Clone(),
+		TLS:      p.TLS.Clone(),
+		TTFB:     p.TTFB.Clone(),
+		Transfer: p.Transfer.Clone(),
+	}
+}
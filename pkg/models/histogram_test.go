@@ -0,0 +1,177 @@
+package models
+
+import (
+	"math"
+	"testing"
+)
+
+func approxEqual(a, b float64) bool {
+	return math.Abs(a-b) <= 1e-9*math.Max(1, math.Abs(b))
+}
+
+func TestHistogramBounds(t *testing.T) {
+	bounds := HistogramBounds()
+	if len(bounds) != 61 {
+		t.Fatalf("got %d bounds, want 61", len(bounds))
+	}
+	if !approxEqual(bounds[0], 1e-4) || bounds[len(bounds)-1] != 100 {
+		t.Errorf("bounds run from %g to %g, want 1e-4 to 100", bounds[0], bounds[len(bounds)-1])
+	}
+	for i := 1; i < len(bounds); i++ {
+		if bounds[i] <= bounds[i-1] {
+			t.Errorf("bound %d (%g) is not above bound %d (%g)", i, bounds[i], i-1, bounds[i-1])
+		}
+	}
+	bounds[0] = -1
+	if HistogramBounds()[0] == -1 {
+		t.Error("HistogramBounds returned the shared slice")
+	}
+}
+
+func TestHistogramObserve(t *testing.T) {
+	tests := []struct {
+		name  string
+		value float64
+		upper float64 // upper bound of the bucket; 0 for the overflow bucket
+	}{
+		{"zero", 0, 1e-4},
+		{"below the first bound", 5e-5, 1e-4},
+		{"second bucket", 1.2e-4, 1.5e-4},
+		{"middle", 0.25, 0.3},
+		{"just above a bound", 0.0031, 0.004},
+		{"between decades", 9.5, 10},
+		{"last bucket", 95, 100},
+		{"overflow", 150, 0},
+	}
+	bounds := HistogramBounds()
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h := NewHistogram()
+			h.Observe(tt.value)
+			bucket := -1
+			for i, c := range h.Counts {
+				if c == 1 {
+					bucket = i
+				}
+			}
+			switch {
+			case tt.upper == 0 && bucket != len(bounds):
+				t.Errorf("Observe(%g) landed in bucket %d, want the overflow bucket", tt.value, bucket)
+			case tt.upper != 0 && (bucket >= len(bounds) || !approxEqual(bounds[bucket], tt.upper)):
+				t.Errorf("Observe(%g) landed in bucket %d, want the one up to %g", tt.value, bucket, tt.upper)
+			}
+		})
+	}
+}
+
+func TestHistogramStats(t *testing.T) {
+	h := NewHistogram()
+	for _, v := range []float64{0.2, 0.05, 0.4, 0.1} {
+		h.Observe(v)
+	}
+	if h.Count != 4 || !approxEqual(h.Sum, 0.75) || h.Min != 0.05 || h.Max != 0.4 {
+		t.Errorf("count %d, sum %g, min %g, max %g; want 4, 0.75, 0.05, 0.4", h.Count, h.Sum, h.Min, h.Max)
+	}
+}
+
+func TestHistogramMerge(t *testing.T) {
+	observe := func(values ...float64) *Histogram {
+		h := NewHistogram()
+		for _, v := range values {
+			h.Observe(v)
+		}
+		return h
+	}
+	tests := []struct {
+		name  string
+		into  *Histogram
+		other *Histogram
+		want  *Histogram
+	}{
+		{"disjoint", observe(0.01, 0.02), observe(0.5, 0.001), observe(0.01, 0.02, 0.5, 0.001)},
+		{"into empty", NewHistogram(), observe(0.3, 0.7), observe(0.3, 0.7)},
+		{"from empty", observe(0.3), NewHistogram(), observe(0.3)},
+		{"nil", observe(0.3), nil, observe(0.3)},
+		{"different buckets", observe(0.3), &Histogram{Counts: []int64{5}, Count: 5, Min: 0.001, Max: 1}, observe(0.3)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.into.Merge(tt.other)
+			got, want := tt.into, tt.want
+			if got.Count != want.Count || !approxEqual(got.Sum, want.Sum) || got.Min != want.Min || got.Max != want.Max {
+				t.Errorf("merged count %d, sum %g, min %g, max %g; want %d, %g, %g, %g",
+					got.Count, got.Sum, got.Min, got.Max, want.Count, want.Sum, want.Min, want.Max)
+			}
+			for i := range want.Counts {
+				if got.Counts[i] != want.Counts[i] {
+					t.Errorf("bucket %d = %d, want %d", i, got.Counts[i], want.Counts[i])
+				}
+			}
+		})
+	}
+}
+
+func TestHistogramQuantile(t *testing.T) {
+	// half the observations in (1ms, 1.5ms], half in (2ms, 3ms]
+	split := NewHistogram()
+	for i := 0; i < 50; i++ {
+		split.Observe(0.0012)
+		split.Observe(0.0025)
+	}
+	overflow := NewHistogram()
+	overflow.Observe(150)
+	overflow.Observe(250)
+	single := NewHistogram()
+	single.Observe(0.042)
+
+	tests := []struct {
+		name string
+		h    *Histogram
+		q    float64
+		want float64
+	}{
+		{"empty", NewHistogram(), 0.5, 0},
+		{"single value", single, 0.99, 0.042},
+		{"interpolated in the first bucket", split, 0.25, 0.00125},
+		{"top of the first bucket", split, 0.5, 0.0015},
+		{"interpolated in the second bucket", split, 0.75, 0.0025},
+		{"clamped to the minimum", split, 0, 0.0012},
+		{"clamped to the maximum", split, 1, 0.0025},
+		{"overflow bucket reaches the maximum", overflow, 0.5, 175},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.h.Quantile(tt.q); !approxEqual(got, tt.want) {
+				t.Errorf("Quantile(%g) = %g, want %g", tt.q, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestHistogramSummarizeAndClone(t *testing.T) {
+	h := NewHistogram()
+	for i := 1; i <= 100; i++ {
+		h.Observe(float64(i) / 1000)
+	}
+	c := h.Clone()
+	c.Summarize()
+	if h.P50 != 0 {
+		t.Error("Summarize on a clone changed the original")
+	}
+	if !(c.P50 <= c.P90 && c.P90 <= c.P95 && c.P95 <= c.P99 && c.P99 <= c.Max) {
+		t.Errorf("percentiles out of order: p50 %g, p90 %g, p95 %g, p99 %g, max %g", c.P50, c.P90, c.P95, c.P99, c.Max)
+	}
+	// bucket interpolation keeps estimates within the bucket of the true value
+	if c.P50 < 0.04 || c.P50 > 0.06 || c.P99 < 0.09 || c.P99 > 0.1 {
+		t.Errorf("p50 %g and p99 %g are outside the buckets of 0.05 and 0.099", c.P50, c.P99)
+	}
+
+	c.Observe(5)
+	var buckets int64
+	for _, n := range h.Counts {
+		buckets += n
+	}
+	if h.Count != 100 || h.Max != 0.1 || buckets != 100 {
+		t.Error("observing into a clone changed the original")
+	}
+}
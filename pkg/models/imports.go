@@ -0,0 +1,39 @@
+package models
+
+// RequestSpec is one HTTP request of a test that sends more than the single
+// request of the target URL.
+type RequestSpec struct {
+	Name        string            `json:"name,omitempty"`
+	Method      string            `json:"method"`
+	URL         string            `json:"url"`
+	Headers     map[string]string `json:"headers,omitempty"` // on top of the test's headers
+	Body        string            `json:"body,omitempty"`
+	ThinkTimeMS int               `json:"think_time_ms,omitempty"` // pause before the request
+	Weight      int               `json:"weight,omitempty"`        // share of an endpoint in the traffic mix
+}
+
+// Feeder supplies test data. Each iteration takes the next row, cycling,
+// and every {{column}} in request URLs, headers and bodies is replaced by
+// the row's value.
+type Feeder struct {
+	Columns []string   `json:"columns"`
+	Rows    [][]string `json:"rows"`
+}
+
+// ImportResult is a test drafted from a recording or a spec. It has the
+// shape of a create request so it can be reviewed, edited and then posted to
+// /api/v1/loadtests.
+type ImportResult struct {
+	Name      string          `json:"name"`
+	TargetURL string          `json:"target_url"`
+	Config    LoadTestConfig  `json:"config"`
+	Hosts     map[string]int  `json:"hosts,omitempty"` // requests per host in the source, before filtering
+	Skipped   []ImportSkipped `json:"skipped,omitempty"`
+	Warnings  []string        `json:"warnings,omitempty"` // parts of the draft that need review
+}
+
+// ImportSkipped is a part of the source that did not make it into the draft.
+type ImportSkipped struct {
+	Item   string `json:"item"`
+	Reason string `json:"reason"`
+}
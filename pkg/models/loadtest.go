@@ -18,14 +18,45 @@ type LoadTest struct {
 	Results []LoadTestResults `json:"results,omitempty"`
 }
 
+// Target types a load test can use; the zero value is TargetHTTP.
+const (
+	TargetHTTP      = "http"
+	TargetGRPC      = "grpc"
+	TargetWebSocket = "websocket"
+	TargetTCP       = "tcp"
+	TargetUDP       = "udp"
+	TargetDNS       = "dns"
+	TargetSSE       = "sse"
+	TargetLongPoll  = "long_poll"
+)
+
 type LoadTestConfig struct {
 	Duration      int   `json:"duration"` 
-	RequestsPerSec   int    `json:"requests_per_sec"`
+	RequestsPerSec   int    `json:"requests_per_sec"` // total across all workers
 	MaxConcurrency int    `json:"max_concurrency"`
     WorkerCount     int          `json:"worker_count"`
 	HTTPMethod   string `json:"http_method"`
 	Headers      map[string]string `json:"headers,omitempty"`
 	Body         string `json:"body,omitempty"`
+	StartTimeout int    `json:"start_timeout,omitempty"` // seconds to wait for all workers before starting
+	MaxResponseBytes int64 `json:"max_response_bytes,omitempty"` // larger bodies count as body_too_large errors
+	Protocol     string `json:"protocol,omitempty"` // http1.1 (default), h2, h2c, h3
+	TLS          *TLSConfig `json:"tls,omitempty"`
+	Network      *NetworkConfig `json:"network,omitempty"` // host overrides, proxy and DNS resolver
+	Executor     string `json:"executor,omitempty"` // arrival_rate (default), vus
+	ThinkTime    *ThinkTime `json:"think_time,omitempty"` // between steps, replacing recorded pauses, and after each iteration of the vus executor
+	PacingMS     int    `json:"pacing_ms,omitempty"` // vus executor: least time between the iteration starts of a VU
+	TargetType   string `json:"target_type,omitempty"` // http (default), grpc, websocket, tcp, udp, dns, sse, long_poll
+	GRPC         *GRPCConfig `json:"grpc,omitempty"`
+	WebSocket    *WebSocketConfig `json:"websocket,omitempty"`
+	Socket       *SocketConfig `json:"socket,omitempty"` // tcp and udp targets
+	DNS          *DNSConfig `json:"dns,omitempty"`
+	Stream       *StreamConfig `json:"stream,omitempty"` // sse and long_poll targets
+	Steps        []RequestSpec `json:"steps,omitempty"` // http targets: sent in order on every iteration instead of the target URL
+	Endpoints    []RequestSpec `json:"endpoints,omitempty"` // http targets: one picked by weight on every iteration instead of the target URL
+	Feeder       *Feeder `json:"feeder,omitempty"`
+	Cookies      *CookieConfig `json:"cookies,omitempty"` // http targets
+	Replay       *ReplayConfig `json:"replay,omitempty"` // http targets: requests_per_sec must cover the replay's peak rate
 }
 
 type LoadTestStatus struct {
@@ -35,6 +66,44 @@ type LoadTestStatus struct {
     Succeeded int32      `json:"succeeded"`
     Failed    int32      `json:"failed"`
     StartTime *metav1.Time `json:"start_time,omitempty"`
+    TargetRPS     int            `json:"target_rps"`
+    WorkerTargets []WorkerTarget `json:"worker_targets,omitempty"`
+    SynchronizedStart *time.Time `json:"synchronized_start,omitempty"`
+}
+
+// WorkerTarget is the share of the total target RPS assigned to the worker
+// with the given Job completion index.
+type WorkerTarget struct {
+    Index          int `json:"index"`
+    RequestsPerSec int `json:"requests_per_sec"`
+}
+
+// LoadTestControl is the live control state workers poll while a test runs.
+// Version increases on every change so workers can skip unchanged state.
+type LoadTestControl struct {
+    TestID            string     `json:"test_id"`
+    RequestsPerSec    int        `json:"requests_per_sec"` // total across all workers
+    WorkerCount       int        `json:"worker_count"`
+    WorkerRates       []int      `json:"worker_rates"` // indexed by Job completion index
+    MaxConcurrency    int        `json:"max_concurrency"` // total VUs across all workers, 0 for the worker default
+    WorkerConcurrency []int      `json:"worker_concurrency"`
+    State             string     `json:"state"` // running, paused
+    PausedAt          *time.Time `json:"paused_at,omitempty"`
+    PausedSeconds     float64    `json:"paused_seconds"` // completed pauses only
+    StartAt           *time.Time `json:"start_at,omitempty"` // synchronized start of all workers
+    Version           int        `json:"version"`
+    UpdatedAt         time.Time  `json:"updated_at"`
+}
+
+// StartBarrier is the coordination state worker pods poll before sending load.
+// StartAt is set once all expected workers registered or the deadline passed.
+type StartBarrier struct {
+    TestID      string     `json:"test_id"`
+    Expected    int        `json:"expected"`
+    Registered  int        `json:"registered"`
+    Deadline    time.Time  `json:"deadline"`
+    StartAt     *time.Time `json:"start_at,omitempty"`
+    StartAtUnix int64      `json:"start_at_unix,omitempty"`
 }
 
 type ResourceUsage struct {
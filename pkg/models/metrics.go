@@ -4,6 +4,10 @@ import (
 	"time"
 )
 
+// MaxErrorSamples is the number of distinct error messages kept per error
+// category.
+const MaxErrorSamples = 5
+
 type LoadTestMetrics struct {
     TestID             string                 `json:"test_id"`
     Timestamp          time.Time              `json:"timestamp"`
@@ -16,24 +20,69 @@ type LoadTestMetrics struct {
     MaxResponseTime    float64                `json:"max_response_time"`
     ErrorRate          float64                `json:"error_rate"`
     RequestsPerSecond  float64                `json:"requests_per_second"`
-    StatusCodes        map[string]int64       `json:"status_codes"`
+    StatusCodes        map[string]int64       `json:"status_codes"` // keyed by the exact status code
+    Errors             map[string]int64       `json:"errors,omitempty"` // requests without a response, by category
+    ErrorSamples       map[string][]string    `json:"error_samples,omitempty"`
+    PausedSeconds      int64                  `json:"paused_seconds"`
+    WorkerIndex        int                    `json:"worker_index"`
+    TargetRPS          int                    `json:"target_rps"`
+    DroppedIterations  int64                  `json:"dropped_iterations"`
+    Iterations         int64                  `json:"iterations"` // started, each sending one or more requests
+    IterationRate      float64                `json:"iteration_rate"`
+    // Latency measured from the scheduled send time, which includes any delay
+    // caused by earlier slow responses, and from the actual send time.
+    LatencyCorrected   *Histogram             `json:"latency_corrected,omitempty"`
+    LatencyUncorrected *Histogram             `json:"latency_uncorrected,omitempty"`
+    Phases             *PhaseHistograms       `json:"phases,omitempty"`
+    NewConnections     int64                  `json:"new_connections"`
+    ReusedConnections  int64                  `json:"reused_connections"`
+    Streams            int64                  `json:"streams"` // requests sent as HTTP/2 or HTTP/3 streams
+    Protocols          map[string]int64       `json:"protocols,omitempty"` // responses by negotiated protocol
+    TargetIPs          map[string]int64       `json:"target_ips,omitempty"` // responses by the address they came from
+    MessagesReceived   int64                  `json:"messages_received,omitempty"` // streamed response messages
+    MessagesSent       int64                  `json:"messages_sent,omitempty"`
+    ConnectTime        *Histogram             `json:"connect_time,omitempty"` // session setup, e.g. the WebSocket handshake
+    Disconnects        map[string]int64       `json:"disconnects,omitempty"` // dropped sessions by reason
+    ActiveConnections  int64                  `json:"active_connections,omitempty"` // subscriptions open at snapshot time
+    PeakConnections    int64                  `json:"peak_connections,omitempty"`
+    Reconnects         int64                  `json:"reconnects,omitempty"`
+    TimeToFirstEvent   *Histogram             `json:"time_to_first_event,omitempty"`
+    EventGaps          *Histogram             `json:"event_gaps,omitempty"` // between consecutive events of a subscription
+    Endpoints          map[string]*EndpointMetrics `json:"endpoints,omitempty"` // by request name, e.g. of a weighted mix
 }
 
 type MetricsSnapshot struct {
-    TestID    string            `json:"test_id"`
-    Timestamp time.Time         `json:"timestamp"`
-    Workers   []WorkerMetrics   `json:"workers"`
-    Summary   AggregatedMetrics `json:"summary"`
+    TestID      string               `json:"test_id"`
+    Timestamp   time.Time            `json:"timestamp"`
+    Workers     []WorkerMetrics      `json:"workers"`
+    Summary     AggregatedMetrics    `json:"summary"`
+    Annotations []MetricsAnnotation  `json:"annotations,omitempty"`
+}
+
+// MetricsAnnotation marks an operator action, such as a rate change, on the
+// metrics timeline.
+type MetricsAnnotation struct {
+    Timestamp time.Time `json:"timestamp"`
+    Type      string    `json:"type"`
+    Message   string    `json:"message"`
 }
 
 type WorkerMetrics struct {
-    WorkerID           string    `json:"worker_id"`
-    PodName            string    `json:"pod_name"`
-    TotalRequests      int64     `json:"total_requests"`
-    SuccessfulRequests int64     `json:"successful_requests"`
-    FailedRequests     int64     `json:"failed_requests"`
-    AvgResponseTime    float64   `json:"avg_response_time"`
-    LastUpdate         time.Time `json:"last_update"`
+    WorkerID           string     `json:"worker_id"`
+    PodName            string     `json:"pod_name"`
+    Status             string     `json:"status"` // running, succeeded, removed
+    WorkerIndex        int        `json:"worker_index"`
+    TargetRPS          int        `json:"target_rps"`
+    TotalRequests      int64      `json:"total_requests"`
+    SuccessfulRequests int64      `json:"successful_requests"`
+    FailedRequests     int64      `json:"failed_requests"`
+    AvgResponseTime    float64    `json:"avg_response_time"`
+    DroppedIterations  int64      `json:"dropped_iterations"`
+    IterationRate      float64    `json:"iteration_rate"`
+    ActiveConnections  int64      `json:"active_connections,omitempty"`
+    Endpoints          map[string]*EndpointMetrics `json:"endpoints,omitempty"`
+    LastUpdate         time.Time  `json:"last_update"`
+    RemovedAt          *time.Time `json:"removed_at,omitempty"`
 }
 
 type AggregatedMetrics struct {
@@ -44,5 +93,113 @@ type AggregatedMetrics struct {
     AvgResponseTime    float64            `json:"avg_response_time"`
     RequestsPerSecond  float64            `json:"requests_per_second"`
     StatusCodeBreakdown map[string]int64  `json:"status_code_breakdown"`
+    ErrorBreakdown     map[string]int64   `json:"error_breakdown"`
+    ErrorSamples       map[string][]string `json:"error_samples,omitempty"`
     ActiveWorkers      int               `json:"active_workers"`
-}
\ No newline at end of file
+    ElapsedSeconds     float64           `json:"elapsed_seconds"`
+    PausedSeconds      float64           `json:"paused_seconds"`
+    DroppedIterations  int64             `json:"dropped_iterations"`
+    Iterations         int64             `json:"iterations"`
+    IterationRate      float64           `json:"iteration_rate"` // iterations started per second
+    LatencyCorrected   *Histogram        `json:"latency_corrected,omitempty"`
+    LatencyUncorrected *Histogram        `json:"latency_uncorrected,omitempty"`
+    Phases             *PhaseHistograms  `json:"phases,omitempty"`
+    // Share of requests sent on an already open connection, 0 to 1.
+    ConnectionReuseRatio float64         `json:"connection_reuse_ratio"`
+    Connections        int64             `json:"connections"` // connections opened
+    Streams            int64             `json:"streams"`
+    StreamsPerConnection float64         `json:"streams_per_connection"`
+    Protocols          map[string]int64  `json:"protocols,omitempty"`
+    TargetIPs          map[string]int64  `json:"target_ips,omitempty"` // the proxy's address for proxied requests
+    MessagesReceived   int64             `json:"messages_received,omitempty"`
+    MessagesSent       int64             `json:"messages_sent,omitempty"`
+    MessagesPerSecond  float64           `json:"messages_per_second,omitempty"` // received
+    ConnectTime        *Histogram        `json:"connect_time,omitempty"`
+    Disconnects        map[string]int64  `json:"disconnects,omitempty"`
+    // Subscriptions open across live workers; sse and long_poll tests report
+    // this instead of a request rate.
+    ConcurrentConnections int64          `json:"concurrent_connections"`
+    PeakConnections    int64             `json:"peak_connections"` // sum of the workers' peaks
+    Reconnects         int64             `json:"reconnects,omitempty"`
+    TimeToFirstEvent   *Histogram        `json:"time_to_first_event,omitempty"`
+    EventGaps          *Histogram        `json:"event_gaps,omitempty"`
+    Endpoints          map[string]*EndpointMetrics `json:"endpoints,omitempty"`
+}
+
+// EndpointMetrics are the results of the requests sent under one name,
+// such as an endpoint of a weighted mix.
+type EndpointMetrics struct {
+	TotalRequests      int64            `json:"total_requests"`
+	SuccessfulRequests int64            `json:"successful_requests"`
+	FailedRequests     int64            `json:"failed_requests"`
+	ErrorRate          float64          `json:"error_rate"`
+	AvgResponseTime    float64          `json:"avg_response_time"`
+	RequestsPerSecond  float64          `json:"requests_per_second"`
+	StatusCodes        map[string]int64 `json:"status_codes,omitempty"`
+	Errors             map[string]int64 `json:"errors,omitempty"`
+	LatencyCorrected   *Histogram       `json:"latency_corrected,omitempty"`
+	LatencyUncorrected *Histogram       `json:"latency_uncorrected,omitempty"`
+}
+
+func NewEndpointMetrics() *EndpointMetrics {
+	return &EndpointMetrics{
+		StatusCodes:        make(map[string]int64),
+		Errors:             make(map[string]int64),
+		LatencyCorrected:   NewHistogram(),
+		LatencyUncorrected: NewHistogram(),
+	}
+}
+
+// Merge adds the results of other into e.
+func (e *EndpointMetrics) Merge(other *EndpointMetrics) {
+	e.TotalRequests += other.TotalRequests
+	e.SuccessfulRequests += other.SuccessfulRequests
+	e.FailedRequests += other.FailedRequests
+	for code, count := range other.StatusCodes {
+		e.StatusCodes[code] += count
+	}
+	for category, count := range other.Errors {
+		e.Errors[category] += count
+	}
+	e.LatencyCorrected.Merge(other.LatencyCorrected)
+	e.LatencyUncorrected.Merge(other.LatencyUncorrected)
+}
+
+// Summarize fills in the derived fields for results gathered over elapsed
+// seconds.
+func (e *EndpointMetrics) Summarize(elapsed float64) {
+	if e.TotalRequests > 0 {
+		e.ErrorRate = float64(e.FailedRequests) * 100 / float64(e.TotalRequests)
+		e.AvgResponseTime = e.LatencyUncorrected.Sum / float64(e.TotalRequests)
+	}
+	if elapsed > 0 {
+		e.RequestsPerSecond = float64(e.TotalRequests) / elapsed
+	}
+	e.LatencyCorrected.Summarize()
+	e.LatencyUncorrected.Summarize()
+}
+
+// MergeEndpoints adds per-endpoint results into totals, keyed by name.
+func MergeEndpoints(totals, endpoints map[string]*EndpointMetrics) {
+	for name, metrics := range endpoints {
+		if totals[name] == nil {
+			totals[name] = NewEndpointMetrics()
+		}
+		totals[name].Merge(metrics)
+	}
+}
+
+// AddErrorSample records message as a sample for category unless it is
+// already present or the category holds MaxErrorSamples messages.
+func AddErrorSample(samples map[string][]string, category, message string) {
+	existing := samples[category]
+	if len(existing) >= MaxErrorSamples {
+		return
+	}
+	for _, m := range existing {
+		if m == message {
+			return
+		}
+	}
+	samples[category] = append(existing, message)
+}
@@ -0,0 +1,73 @@
+package models
+
+import "net"
+
+// NetworkConfig changes how workers reach the target, e.g. to test a
+// deployment before DNS points at it or to go through an egress proxy.
+type NetworkConfig struct {
+	// Hosts maps host names to the IP address to connect to instead of
+	// resolving them, like curl --resolve but for every port. A public
+	// address must be one the name has in public DNS or a verified
+	// allowlist entry.
+	Hosts map[string]string `json:"hosts,omitempty"`
+	// Proxy is an http://, https://, socks5:// or socks5h:// URL all
+	// requests are sent through. The proxy then resolves the target, so
+	// Hosts and DNSServer only apply to the proxy's own address. Credentials
+	// can be given as {{secret "name"}} references.
+	Proxy string `json:"proxy,omitempty"`
+	// DNSServer is the host:port of a resolver used instead of the
+	// system one.
+	DNSServer string `json:"dns_server,omitempty"`
+	// DNSCacheTTL is how many seconds resolved addresses are reused; 0
+	// resolves for every new connection.
+	DNSCacheTTL int `json:"dns_cache_ttl,omitempty"`
+}
+
+// blockedNetworks are never reachable as targets unless an organization
+// adds a CIDR range inside one of them to its allowlist: private, loopback,
+// link-local, carrier-grade NAT, benchmarking, multicast and reserved space.
+var blockedNetworks = mustParseCIDRs(
+	"0.0.0.0/8",
+	"10.0.0.0/8",
+	"100.64.0.0/10",
+	"127.0.0.0/8",
+	"169.254.0.0/16",
+	"172.16.0.0/12",
+	"192.168.0.0/16",
+	"198.18.0.0/15",
+	"224.0.0.0/4",
+	"240.0.0.0/4",
+	"::/128",
+	"::1/128",
+	"fc00::/7",
+	"fe80::/10",
+	"ff00::/8",
+)
+
+// BlockedNetworks returns a copy of the address blocks targets may not be
+// in without an allowlist entry.
+func BlockedNetworks() []*net.IPNet {
+	return append([]*net.IPNet(nil), blockedNetworks...)
+}
+
+// IsBlockedIP reports whether ip is in one of the blocked networks.
+func IsBlockedIP(ip net.IP) bool {
+	for _, block := range blockedNetworks {
+		if block.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
+
+func mustParseCIDRs(cidrs ...string) []*net.IPNet {
+	networks := make([]*net.IPNet, 0, len(cidrs))
+	for _, cidr := range cidrs {
+		_, network, err := net.ParseCIDR(cidr)
+		if err != nil {
+			panic(err)
+		}
+		networks = append(networks, network)
+	}
+	return networks
+}
@@ -0,0 +1,51 @@
+package models
+
+import (
+	"net"
+	"testing"
+)
+
+func TestIsBlockedIP(t *testing.T) {
+	tests := []struct {
+		ip      string
+		blocked bool
+	}{
+		{"0.0.0.0", true},
+		{"10.1.2.3", true},
+		{"100.64.0.1", true},
+		{"127.0.0.1", true},
+		{"169.254.169.254", true},
+		{"172.31.255.255", true},
+		{"192.168.1.1", true},
+		{"198.18.0.1", true},
+		{"198.19.255.255", true},
+		{"224.0.0.1", true},
+		{"239.255.255.250", true},
+		{"240.0.0.1", true},
+		{"255.255.255.255", true},
+		{"::", true},
+		{"::1", true},
+		{"fd00::1", true},
+		{"fe80::1", true},
+		{"ff02::1", true},
+		{"::ffff:10.0.0.1", true},
+		{"8.8.8.8", false},
+		{"100.128.0.1", false},
+		{"172.32.0.1", false},
+		{"198.20.0.1", false},
+		{"223.255.255.255", false},
+		{"2001:4860:4860::8888", false},
+	}
+	for _, tt := range tests {
+		if got := IsBlockedIP(net.ParseIP(tt.ip)); got != tt.blocked {
+			t.Errorf("IsBlockedIP(%s) = %v, want %v", tt.ip, got, tt.blocked)
+		}
+	}
+}
+
+func TestBlockedNetworksCopy(t *testing.T) {
+	BlockedNetworks()[0] = nil
+	if BlockedNetworks()[0] == nil {
+		t.Error("BlockedNetworks returned the shared slice")
+	}
+}
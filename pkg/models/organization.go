@@ -0,0 +1,24 @@
+package models
+
+import "time"
+
+type Organization struct {
+	ID        string    `json:"id"`
+	Name      string    `json:"name"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// AllowlistEntry is a host or CIDR range an organization may target.
+// Host entries must be verified before use; CIDR entries are only accepted
+// for private ranges and explicitly allow targets inside them.
+type AllowlistEntry struct {
+	ID                 string     `json:"id"`
+	OrganizationID     string     `json:"organization_id"`
+	Kind               string     `json:"kind"` // host, cidr
+	Value              string     `json:"value"`
+	VerificationToken  string     `json:"verification_token,omitempty"`
+	VerificationMethod string     `json:"verification_method,omitempty"` // http, dns
+	Verified           bool       `json:"verified"`
+	VerifiedAt         *time.Time `json:"verified_at,omitempty"`
+	CreatedAt          time.Time  `json:"created_at"`
+}
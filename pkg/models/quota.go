@@ -0,0 +1,22 @@
+package models
+
+import "time"
+
+type UserQuota struct {
+	UserID             string `json:"user_id"`
+	MaxWorkersPerTest  int    `json:"max_workers_per_test"`
+	MaxAggregateRPS    int    `json:"max_aggregate_rps"`
+	MonthlyTestMinutes int    `json:"monthly_test_minutes"`
+}
+
+type UserUsage struct {
+	Quota                UserQuota `json:"quota"`
+	PeriodStart          time.Time `json:"period_start"`
+	PeriodEnd            time.Time `json:"period_end"`
+	TestMinutesUsed      float64   `json:"test_minutes_used"`
+	TestMinutesReserved  float64   `json:"test_minutes_reserved"`
+	TestMinutesRemaining float64   `json:"test_minutes_remaining"`
+	RunningTests         int       `json:"running_tests"`
+	RunningRPS           int       `json:"running_rps"`
+	RemainingRPS         int       `json:"remaining_rps"`
+}
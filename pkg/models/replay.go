@@ -0,0 +1,42 @@
+package models
+
+import "time"
+
+// Access log formats that can be uploaded for replay.
+const (
+	AccessLogCombined = "combined" // nginx and Apache combined or common format
+	AccessLogJSON     = "json"     // one JSON object per line
+)
+
+// AccessLog is an uploaded access log, parsed into the requests a replay
+// sends.
+type AccessLog struct {
+	ID         string    `json:"id"`
+	UserID     string    `json:"user_id"`
+	Name       string    `json:"name"`
+	Format     string    `json:"format"`
+	Entries    int       `json:"entries"`
+	DurationMS int64     `json:"duration_ms"`
+	PeakRPS    int       `json:"peak_rps"` // busiest second at normal speed
+	CreatedAt  time.Time `json:"created_at"`
+
+	// lines that cannot be replayed, only returned by the upload
+	SkippedCount int             `json:"skipped_count,omitempty"`
+	Skipped      []ImportSkipped `json:"skipped,omitempty"`
+}
+
+// ReplayEntry is one request of an access log, OffsetMS after the first.
+type ReplayEntry struct {
+	OffsetMS int64  `json:"t"`
+	Method   string `json:"m"`
+	Path     string `json:"p"` // path and query, sent to the test's target URL
+}
+
+// ReplayConfig replays an access log against the target URL instead of
+// generating load at a fixed rate. Entries are dealt out to the workers in
+// turn, so each worker keeps the log's relative timing for its share.
+type ReplayConfig struct {
+	AccessLogID string  `json:"access_log_id"`
+	Speed       float64 `json:"speed,omitempty"` // time compression: 2 replays twice as fast, 1 by default
+	Loop        bool    `json:"loop,omitempty"`  // start over when the log ends before the test
+}
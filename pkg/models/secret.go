@@ -0,0 +1,13 @@
+package models
+
+import "time"
+
+// Secret is a value a user stores encrypted, such as an API key, and
+// references in tests as {{secret "name"}}. The value itself is never
+// returned.
+type Secret struct {
+	Name      string    `json:"name"`
+	UserID    string    `json:"user_id"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
@@ -0,0 +1,62 @@
+package models
+
+import (
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+)
+
+// SocketConfig describes the payload of a raw tcp or udp load test. Payload
+// and Delimiter are binary, written in Encoding.
+type SocketConfig struct {
+	Payload       string `json:"payload"`
+	Encoding      string `json:"encoding,omitempty"`       // hex (default) or base64
+	Delimiter     string `json:"delimiter,omitempty"`      // tcp: read the response up to and including this sequence
+	ResponseBytes int    `json:"response_bytes,omitempty"` // tcp: read a response of exactly this many bytes
+	NewConnection bool   `json:"new_connection,omitempty"` // tcp: dial for every iteration instead of reusing connections
+	AwaitResponse bool   `json:"await_response,omitempty"` // udp: wait for a datagram back
+}
+
+// Decode returns the binary payload and response delimiter.
+func (c *SocketConfig) Decode() (payload, delimiter []byte, err error) {
+	var decode func(string) ([]byte, error)
+	switch c.Encoding {
+	case "", "hex":
+		decode = hex.DecodeString
+	case "base64":
+		decode = base64.StdEncoding.DecodeString
+	default:
+		return nil, nil, fmt.Errorf("socket.encoding must be hex or base64")
+	}
+
+	if payload, err = decode(c.Payload); err != nil {
+		return nil, nil, fmt.Errorf("invalid socket.payload: %v", err)
+	}
+	if delimiter, err = decode(c.Delimiter); err != nil {
+		return nil, nil, fmt.Errorf("invalid socket.delimiter: %v", err)
+	}
+	return payload, delimiter, nil
+}
+
+// DNSConfig describes the query of a dns load test, sent to the resolver in
+// the target URL.
+type DNSConfig struct {
+	Name      string `json:"name"`
+	Type      string `json:"type,omitempty"`      // record type, A by default
+	Transport string `json:"transport,omitempty"` // udp (default) or tcp
+}
+
+// DNSRecordTypes maps the query types a dns load test may use to their
+// numeric values.
+var DNSRecordTypes = map[string]uint16{
+	"A":     1,
+	"NS":    2,
+	"CNAME": 5,
+	"SOA":   6,
+	"PTR":   12,
+	"MX":    15,
+	"TXT":   16,
+	"AAAA":  28,
+	"SRV":   33,
+	"ANY":   255,
+}
@@ -0,0 +1,10 @@
+package models
+
+// StreamConfig tunes the subscriptions of sse and long_poll tests. Each VU
+// holds one subscription for the whole test, so max_concurrency is the
+// number of concurrent subscribers and requests_per_sec caps how many of
+// them connect per second.
+type StreamConfig struct {
+	ReconnectDelayMS int `json:"reconnect_delay_ms,omitempty"` // 3000 by default; an SSE retry field overrides it
+	PollTimeout      int `json:"poll_timeout,omitempty"`       // seconds a long poll may stay open, 60 by default
+}
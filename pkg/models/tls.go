@@ -0,0 +1,23 @@
+package models
+
+// TLSConfig tunes how workers connect to TLS targets. Certificates and keys
+// are PEM encoded and stored as secrets, referenced here by name.
+type TLSConfig struct {
+	InsecureSkipVerify bool   `json:"insecure_skip_verify,omitempty"` // accept any certificate, like curl -k
+	ServerName         string `json:"server_name,omitempty"`          // SNI and verified name, instead of the target host
+	MinVersion         string `json:"min_version,omitempty"`          // 1.0, 1.1, 1.2 (default) or 1.3
+	ClientCertSecret   string `json:"client_cert_secret,omitempty"`   // client certificate chain for mutual TLS
+	ClientKeySecret    string `json:"client_key_secret,omitempty"`    // private key of the client certificate
+	CASecret           string `json:"ca_secret,omitempty"`            // CA bundle trusted in addition to the system roots
+}
+
+// SecretNames returns the secrets the TLS settings reference.
+func (c *TLSConfig) SecretNames() []string {
+	var names []string
+	for _, name := range []string{c.ClientCertSecret, c.ClientKeySecret, c.CASecret} {
+		if name != "" {
+			names = append(names, name)
+		}
+	}
+	return names
+}
@@ -1,8 +1,9 @@
 package models
 
 type User struct {
-	ID       string `json:"id"`
-	Username string `json:"username"`
-	Email    string `json:"email"`
-	Password string `json:"password,omitempty"`
+	ID             string `json:"id"`
+	Username       string `json:"username"`
+	Email          string `json:"email"`
+	Password       string `json:"password,omitempty"`
+	OrganizationID string `json:"organization_id,omitempty"`
 }
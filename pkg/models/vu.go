@@ -0,0 +1,47 @@
+package models
+
+// Executors a load test can use; the zero value is ExecutorArrivalRate.
+const (
+	// ExecutorArrivalRate starts iterations at requests_per_sec no matter
+	// how long earlier ones take.
+	ExecutorArrivalRate = "arrival_rate"
+	// ExecutorVUs runs max_concurrency virtual users that each start their
+	// next iteration once the previous one, its think time and pacing are
+	// over. requests_per_sec caps how fast iterations start.
+	ExecutorVUs = "vus"
+)
+
+// Think time distributions.
+const (
+	ThinkFixed       = "fixed"
+	ThinkUniform     = "uniform"
+	ThinkNormal      = "normal"
+	ThinkExponential = "exponential"
+)
+
+// ThinkTime is a pause drawn from a distribution, in milliseconds.
+type ThinkTime struct {
+	Distribution string `json:"distribution,omitempty"` // fixed (default), uniform, normal, exponential
+	MS           int    `json:"ms,omitempty"`           // fixed pause, mean of normal and exponential
+	MinMS        int    `json:"min_ms,omitempty"`       // uniform lower bound, floor of the others
+	MaxMS        int    `json:"max_ms,omitempty"`       // uniform upper bound, cap of the others
+	StdDevMS     int    `json:"stddev_ms,omitempty"`    // normal
+}
+
+// CookieConfig controls the cookie jar each http VU keeps. Jars are on by
+// default, so a VU stays logged in once the target sets a session cookie.
+type CookieConfig struct {
+	Disabled          bool     `json:"disabled,omitempty"`
+	ResetPerIteration bool     `json:"reset_per_iteration,omitempty"` // start every iteration with a fresh jar, like a new browser
+	Initial           []Cookie `json:"initial,omitempty"`             // put into every new jar
+}
+
+// Cookie is an initial cookie of a VU. Its value may reference feeder
+// columns as {{column}}; they are filled from the row of the iteration that
+// creates the jar.
+type Cookie struct {
+	Name   string `json:"name"`
+	Value  string `json:"value"`
+	Domain string `json:"domain,omitempty"` // defaults to the target host
+	Path   string `json:"path,omitempty"`
+}
@@ -0,0 +1,13 @@
+package models
+
+import "encoding/json"
+
+// WebSocketConfig describes the messages a WebSocket load test sends. Each
+// VU holds one connection; messages are sent in script order, cycling, at
+// the test's rate. JSON object messages get a unique value in
+// CorrelationField and the response echoing it closes the round trip.
+type WebSocketConfig struct {
+	Messages         []json.RawMessage `json:"messages"`
+	CorrelationField string            `json:"correlation_field,omitempty"` // defaults to "id"
+	Subprotocols     []string          `json:"subprotocols,omitempty"`
+}